@@ -0,0 +1,300 @@
+package class
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FlagContext describes where access flags were found. The same
+// bit has different meanings in different contexts (e.g. 0x0020
+// is ACC_SUPER for classes, but ACC_SYNCHRONIZED for methods),
+// and fields and methods of interfaces follow their own rules.
+type FlagContext uint8
+
+const (
+	ClassFlags FlagContext = iota
+	NestedClassFlags
+	FieldFlags
+	InterfaceFieldFlags
+	MethodFlags
+	InterfaceMethodFlags
+	ParameterFlags
+	ModuleFlags
+	RequiresFlags
+	ExportsFlags // also used for opens
+)
+
+var flagContextNames = map[FlagContext]string{
+	ClassFlags:           "class",
+	NestedClassFlags:     "nested class",
+	FieldFlags:           "field",
+	InterfaceFieldFlags:  "interface field",
+	MethodFlags:          "method",
+	InterfaceMethodFlags: "interface method",
+	ParameterFlags:       "parameter",
+	ModuleFlags:          "module",
+	RequiresFlags:        "requires",
+	ExportsFlags:         "exports",
+}
+
+func (ctx FlagContext) String() string {
+	if name, ok := flagContextNames[ctx]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("FlagContext(%d)", uint8(ctx))
+}
+
+func (ctx FlagContext) names() []flagName {
+	switch ctx {
+	case ClassFlags:
+		return classFlags
+	case NestedClassFlags:
+		return nestedClassFlags
+	case FieldFlags, InterfaceFieldFlags:
+		return fieldFlags
+	case MethodFlags, InterfaceMethodFlags:
+		return methodFlags
+	case ParameterFlags:
+		return parameterFlags
+	case ModuleFlags:
+		return moduleFlags
+	case RequiresFlags:
+		return requiresFlags
+	case ExportsFlags:
+		return moduleGrantFlags
+	}
+
+	return nil
+}
+
+// ContextFlags are access flags together with the
+// context they were found in, so that they can be
+// rendered and validated.
+type ContextFlags struct {
+	AccessFlags
+	Context FlagContext
+}
+
+// In returns flags in the context ctx, e.g.
+// method.AccessFlags.In(MethodFlags).String().
+func (flags AccessFlags) In(ctx FlagContext) ContextFlags {
+	return ContextFlags{flags, ctx}
+}
+
+// String returns the names of all set flags, e.g. "public static
+// final synthetic". Flags that don't have a modifier keyword are
+// named after their constant (e.g. "bridge" for ACC_BRIDGE),
+// unknown flags are given in hex.
+func (f ContextFlags) String() string {
+	var set []string
+	rest := f.AccessFlags
+
+	for _, name := range f.Context.names() {
+		if f.AccessFlags&name.flag == 0 {
+			continue
+		}
+
+		if name.modifier != "" {
+			set = append(set, name.modifier)
+		} else {
+			set = append(set, strings.ToLower(strings.TrimPrefix(name.name, "ACC_")))
+		}
+		rest &^= name.flag
+	}
+
+	if rest != 0 {
+		set = append(set, fmt.Sprintf("0x%04x", uint16(rest)))
+	}
+
+	return strings.Join(set, " ")
+}
+
+// Modifiers returns the flags as Java source modifiers,
+// e.g. "public static final". Flags without a modifier
+// keyword (e.g. ACC_SYNTHETIC) are left out.
+func (f ContextFlags) Modifiers() string {
+	return strings.Join(modifiers(f.AccessFlags, f.Context.names()), " ")
+}
+
+// AccessFlagsError reports an illegal combination of access flags.
+type AccessFlagsError struct {
+	// Location of the flags in the class file, e.g.
+	// "methods[2]", "" if unknown.
+	Path string
+
+	Flags ContextFlags
+
+	// Which rule of the JVMS is broken,
+	// e.g. "final and volatile".
+	Reason string
+}
+
+func (e *AccessFlagsError) Error() string {
+	s := "jclass: "
+	if e.Path != "" {
+		s += e.Path + ": "
+	}
+
+	return fmt.Sprintf("%sinvalid %s access flags (0x%04x) %s: %s",
+		s, e.Flags.Context, uint16(e.Flags.AccessFlags), e.Flags, e.Reason)
+}
+
+// Validate reports the first illegal combination of flags
+// according to JVMS 4.1, 4.5 and 4.6, as an *AccessFlagsError.
+// Fields and methods of interfaces have to be validated in the
+// InterfaceFieldFlags and InterfaceMethodFlags contexts. Methods
+// of interfaces are validated by the rules of Java 8 and later.
+// There are no rules for parameters and modules.
+func (f ContextFlags) Validate() error {
+	flags := f.AccessFlags
+	has := func(flag AccessFlags) bool { return flags&flag != 0 }
+
+	invalid := func(reason string) error {
+		return &AccessFlagsError{Flags: f, Reason: reason}
+	}
+
+	// Public, private and protected share their bits
+	// for nested classes, fields and methods.
+	access := 0
+	for _, flag := range []AccessFlags{FIELD_ACC_PUBLIC, FIELD_ACC_PRIVATE, FIELD_ACC_PROTECTED} {
+		if has(flag) {
+			access++
+		}
+	}
+
+	switch f.Context {
+	case ClassFlags:
+		switch {
+		case has(CLASS_ACC_MODULE) && flags != CLASS_ACC_MODULE:
+			return invalid("module with other flags")
+		case has(CLASS_ACC_INTERFACE) && !has(CLASS_ACC_ABSTRACT):
+			return invalid("interface not abstract")
+		case has(CLASS_ACC_INTERFACE) && has(CLASS_ACC_FINAL|CLASS_ACC_SUPER|CLASS_ACC_ENUM):
+			return invalid("interface with final, super or enum")
+		case !has(CLASS_ACC_INTERFACE) && has(CLASS_ACC_ANNOTATION):
+			return invalid("annotation not an interface")
+		case has(CLASS_ACC_FINAL) && has(CLASS_ACC_ABSTRACT):
+			return invalid("final and abstract")
+		}
+
+	case NestedClassFlags:
+		switch {
+		case access > 1:
+			return invalid("more than one of public, private and protected")
+		case has(NESTED_CLASS_ACC_INTERFACE) && !has(NESTED_CLASS_ACC_ABSTRACT):
+			return invalid("interface not abstract")
+		case has(NESTED_CLASS_ACC_INTERFACE) && has(NESTED_CLASS_ACC_FINAL|NESTED_CLASS_ACC_ENUM):
+			return invalid("interface with final or enum")
+		case !has(NESTED_CLASS_ACC_INTERFACE) && has(NESTED_CLASS_ACC_ANNOTATION):
+			return invalid("annotation not an interface")
+		case has(NESTED_CLASS_ACC_FINAL) && has(NESTED_CLASS_ACC_ABSTRACT):
+			return invalid("final and abstract")
+		}
+
+	case FieldFlags:
+		switch {
+		case access > 1:
+			return invalid("more than one of public, private and protected")
+		case has(FIELD_ACC_FINAL) && has(FIELD_ACC_VOLATILE):
+			return invalid("final and volatile")
+		}
+
+	case InterfaceFieldFlags:
+		required := AccessFlags(FIELD_ACC_PUBLIC | FIELD_ACC_STATIC | FIELD_ACC_FINAL)
+
+		switch {
+		case flags&required != required:
+			return invalid("interface field not public, static and final")
+		case flags&^(required|FIELD_ACC_SYNTHETIC) != 0:
+			return invalid("interface field with flags other than public, static, final and synthetic")
+		}
+
+	case MethodFlags, InterfaceMethodFlags:
+		if access > 1 {
+			return invalid("more than one of public, private and protected")
+		}
+
+		if f.Context == InterfaceMethodFlags {
+			switch {
+			case has(METHOD_ACC_PROTECTED | METHOD_ACC_FINAL | METHOD_ACC_SYNCHRONIZED | METHOD_ACC_NATIVE):
+				return invalid("interface method with protected, final, synchronized or native")
+			case access != 1:
+				return invalid("interface method neither public nor private")
+			}
+		}
+
+		if has(METHOD_ACC_ABSTRACT) && has(METHOD_ACC_PRIVATE|METHOD_ACC_STATIC|METHOD_ACC_FINAL|
+			METHOD_ACC_SYNCHRONIZED|METHOD_ACC_NATIVE|METHOD_ACC_STRICT) {
+			return invalid("abstract with private, static, final, synchronized, native or strict")
+		}
+	}
+
+	return nil
+}
+
+// ValidateAccessFlags checks the access flags of the class, its
+// fields and methods and of the entries of its InnerClasses
+// attribute, see ContextFlags.Validate. Additionally, instance
+// initialization methods (<init>) may only be public, private,
+// protected, varargs, strict or synthetic and, as of version
+// 51.0, the class initialization method (<clinit>) must be
+// static. It returns all *AccessFlagsErrors it found.
+func (c *ClassFile) ValidateAccessFlags() []error {
+	var errs []error
+
+	check := func(path string, flags ContextFlags) {
+		if err := flags.Validate(); err != nil {
+			err.(*AccessFlagsError).Path = path
+			errs = append(errs, err)
+		}
+	}
+
+	check("access_flags", c.AccessFlags.In(ClassFlags))
+
+	fieldContext, methodContext := FieldFlags, MethodFlags
+	if c.AccessFlags&CLASS_ACC_INTERFACE != 0 {
+		fieldContext, methodContext = InterfaceFieldFlags, InterfaceMethodFlags
+	}
+
+	for i, field := range c.Fields {
+		check(fmt.Sprintf("fields[%d]", i), field.AccessFlags.In(fieldContext))
+	}
+
+	for i, method := range c.Methods {
+		path := fmt.Sprintf("methods[%d]", i)
+		flags := method.AccessFlags.In(methodContext)
+
+		// Without a name, the method is checked like any other.
+		name, _ := c.LookupUTF8(method.NameIndex)
+
+		switch name {
+		case "<clinit>":
+			// All other flags are ignored
+			if c.MajorVersion >= 51 && method.AccessFlags&METHOD_ACC_STATIC == 0 {
+				errs = append(errs, &AccessFlagsError{path, flags, "class initializer not static"})
+			}
+
+			continue
+
+		case "<init>":
+			allowed := AccessFlags(METHOD_ACC_PUBLIC | METHOD_ACC_PRIVATE | METHOD_ACC_PROTECTED |
+				METHOD_ACC_VARARGS | METHOD_ACC_STRICT | METHOD_ACC_SYNTHETIC)
+
+			if method.AccessFlags&^allowed != 0 {
+				errs = append(errs, &AccessFlagsError{path, flags, "instance initializer with flags other than public, private, protected, varargs, strict and synthetic"})
+				continue
+			}
+		}
+
+		check(path, flags)
+	}
+
+	for _, attr := range c.Attributes.All(InnerClassesTag) {
+		for i, class := range attr.InnerClasses().Classes {
+			check(fmt.Sprintf("attributes[InnerClasses].classes[%d]", i), class.InnerAccessFlags.In(NestedClassFlags))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,116 @@
+package class
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateFlags(t *testing.T) {
+	tests := []struct {
+		flags  ContextFlags
+		reason string // "" if valid
+	}{
+		{AccessFlags(CLASS_ACC_PUBLIC | CLASS_ACC_SUPER).In(ClassFlags), ""},
+		{AccessFlags(CLASS_ACC_INTERFACE).In(ClassFlags), "interface not abstract"},
+		{AccessFlags(CLASS_ACC_FINAL | CLASS_ACC_ABSTRACT).In(ClassFlags), "final and abstract"},
+		{AccessFlags(CLASS_ACC_MODULE | CLASS_ACC_PUBLIC).In(ClassFlags), "module with other flags"},
+
+		{AccessFlags(FIELD_ACC_PRIVATE | FIELD_ACC_FINAL).In(FieldFlags), ""},
+		{AccessFlags(FIELD_ACC_FINAL | FIELD_ACC_VOLATILE).In(FieldFlags), "final and volatile"},
+		{AccessFlags(FIELD_ACC_PUBLIC | FIELD_ACC_PRIVATE).In(FieldFlags), "more than one of public, private and protected"},
+		{AccessFlags(FIELD_ACC_PUBLIC | FIELD_ACC_STATIC).In(InterfaceFieldFlags), "interface field not public, static and final"},
+
+		{AccessFlags(METHOD_ACC_PUBLIC | METHOD_ACC_ABSTRACT).In(MethodFlags), ""},
+		{AccessFlags(METHOD_ACC_PRIVATE | METHOD_ACC_ABSTRACT).In(MethodFlags), "abstract with private, static, final, synchronized, native or strict"},
+		{AccessFlags(METHOD_ACC_STATIC | METHOD_ACC_ABSTRACT).In(MethodFlags), "abstract with private, static, final, synchronized, native or strict"},
+		{AccessFlags(METHOD_ACC_PUBLIC | METHOD_ACC_FINAL).In(MethodFlags), ""},
+		{AccessFlags(METHOD_ACC_PUBLIC | METHOD_ACC_FINAL).In(InterfaceMethodFlags), "interface method with protected, final, synchronized or native"},
+		{AccessFlags(METHOD_ACC_STATIC).In(InterfaceMethodFlags), "interface method neither public nor private"},
+		{AccessFlags(METHOD_ACC_PRIVATE | METHOD_ACC_STATIC).In(InterfaceMethodFlags), ""},
+
+		{AccessFlags(NESTED_CLASS_ACC_ANNOTATION).In(NestedClassFlags), "annotation not an interface"},
+	}
+
+	for _, test := range tests {
+		err := test.flags.Validate()
+
+		if test.reason == "" {
+			if err != nil {
+				t.Errorf("%s flags %q: %v", test.flags.Context, test.flags, err)
+			}
+			continue
+		}
+
+		var flagsErr *AccessFlagsError
+		if !errors.As(err, &flagsErr) || flagsErr.Reason != test.reason {
+			t.Errorf("%s flags %q: got %v, expected %q", test.flags.Context, test.flags, err, test.reason)
+		}
+	}
+}
+
+func TestValidateAccessFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   AccessFlags
+		version uint16
+		reason  string // "" if valid
+	}{
+		{"<init>", METHOD_ACC_PUBLIC, 52, ""},
+		{"<init>", METHOD_ACC_PUBLIC | METHOD_ACC_STATIC, 52, "instance initializer with flags other than public, private, protected, varargs, strict and synthetic"},
+		{"<clinit>", METHOD_ACC_STATIC, 52, ""},
+		{"<clinit>", 0, 52, "class initializer not static"},
+		{"<clinit>", 0, 50, ""},
+		{"<clinit>", METHOD_ACC_PRIVATE | METHOD_ACC_PUBLIC | METHOD_ACC_STATIC, 52, ""},
+	}
+
+	for _, test := range tests {
+		_, c := readHelloWorld(t)
+		if errs := c.ValidateAccessFlags(); len(errs) > 0 {
+			t.Fatal(errs)
+		}
+
+		c.MajorVersion = test.version
+
+		b := c.ConstPoolBuilder()
+		c.Methods[0].NameIndex = b.AddUTF8(test.name)
+		c.Methods[0].AccessFlags = test.flags
+		if b.Err() != nil {
+			t.Fatal(b.Err())
+		}
+
+		errs := c.ValidateAccessFlags()
+
+		if test.reason == "" {
+			if len(errs) > 0 {
+				t.Errorf("%s 0x%04x: %v", test.name, uint16(test.flags), errs)
+			}
+			continue
+		}
+
+		var flagsErr *AccessFlagsError
+		if len(errs) != 1 || !errors.As(errs[0], &flagsErr) ||
+			flagsErr.Path != "methods[0]" || flagsErr.Reason != test.reason {
+			t.Errorf("%s 0x%04x: got %v, expected %q", test.name, uint16(test.flags), errs, test.reason)
+		}
+	}
+}
+
+func TestContextFlagsString(t *testing.T) {
+	tests := []struct {
+		flags    ContextFlags
+		expected string
+	}{
+		{AccessFlags(0).In(MethodFlags), ""},
+		{AccessFlags(METHOD_ACC_PUBLIC | METHOD_ACC_BRIDGE | METHOD_ACC_SYNTHETIC).In(MethodFlags), "public bridge synthetic"},
+		{AccessFlags(METHOD_ACC_PUBLIC | METHOD_ACC_BRIDGE | METHOD_ACC_SYNTHETIC | 0x0200).In(MethodFlags), "public bridge synthetic 0x0200"},
+		{AccessFlags(0x0020).In(ClassFlags), "super"},
+		{AccessFlags(0x0020).In(MethodFlags), "synchronized"},
+		{AccessFlags(0x8000).In(FieldFlags), "0x8000"},
+	}
+
+	for _, test := range tests {
+		if str := test.flags.String(); str != test.expected {
+			t.Errorf("%s flags 0x%04x: got %q, expected %q", test.flags.Context, uint16(test.flags.AccessFlags), str, test.expected)
+		}
+	}
+}
@@ -0,0 +1,443 @@
+package class
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// These constants are the possible values for the tag
+// of an element_value. They are the same characters used
+// in field descriptors, plus the ones for strings, enums,
+// classes, nested annotations and arrays.
+// http://docs.oracle.com/javase/specs/jvms/se7/html/jvms-4.html#jvms-4.7.16.1
+const (
+	ELEMENT_VALUE_Byte       ElementValueType = 'B'
+	ELEMENT_VALUE_Char                        = 'C'
+	ELEMENT_VALUE_Double                      = 'D'
+	ELEMENT_VALUE_Float                       = 'F'
+	ELEMENT_VALUE_Int                         = 'I'
+	ELEMENT_VALUE_Long                        = 'J'
+	ELEMENT_VALUE_Short                       = 'S'
+	ELEMENT_VALUE_Boolean                     = 'Z'
+	ELEMENT_VALUE_String                      = 's'
+	ELEMENT_VALUE_Enum                        = 'e'
+	ELEMENT_VALUE_Class                       = 'c'
+	ELEMENT_VALUE_Annotation                  = '@'
+	ELEMENT_VALUE_Array                       = '['
+)
+
+// An Annotation as found in the Runtime[In]Visible[Parameter]Annotations
+// attributes. TypeIndex references a CONSTANT_Utf8_info holding the
+// field descriptor of the annotation type (e.g. "Ljava/lang/Deprecated;").
+type Annotation struct {
+	TypeIndex ConstPoolIndex
+	Pairs     []ElementValuePair
+}
+
+// A single name=value pair of an annotation. NameIndex references
+// a CONSTANT_Utf8_info holding the name of the annotation element.
+type ElementValuePair struct {
+	NameIndex ConstPoolIndex
+	Value     ElementValue
+}
+
+// TypeName returns the field descriptor of the annotation type.
+func (a *Annotation) TypeName(constPool ConstantPool) (string, error) {
+	return constPool.LookupUTF8(a.TypeIndex)
+}
+
+// Element returns the value of the element called name, or
+// nil if the annotation doesn't explicitly specify it.
+func (a *Annotation) Element(constPool ConstantPool, name string) (ElementValue, error) {
+	for _, pair := range a.Pairs {
+		pairName, err := constPool.LookupUTF8(pair.NameIndex)
+		if err != nil {
+			return nil, err
+		}
+
+		if pairName == name {
+			return pair.Value, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Annotations returns the annotations of all RuntimeVisibleAnnotations
+// and RuntimeInvisibleAnnotations attributes in attrs.
+func (attrs Attributes) Annotations() []*Annotation {
+	var annotations []*Annotation
+
+	for _, attr := range attrs {
+		switch attr.GetTag() {
+		case RuntimeVisibleAnnotationsTag:
+			annotations = append(annotations, attr.RuntimeVisibleAnnotations().Annotations...)
+		case RuntimeInvisibleAnnotationsTag:
+			annotations = append(annotations, attr.RuntimeInvisibleAnnotations().Annotations...)
+		}
+	}
+
+	return annotations
+}
+
+// FindAnnotation returns the (visible or invisible) annotation
+// whose type has the field descriptor typeName, or nil.
+func (attrs Attributes) FindAnnotation(constPool ConstantPool, typeName string) (*Annotation, error) {
+	return findAnnotation(attrs.Annotations(), constPool, typeName)
+}
+
+func findAnnotation(annotations []*Annotation, constPool ConstantPool, typeName string) (*Annotation, error) {
+	for _, annotation := range annotations {
+		name, err := annotation.TypeName(constPool)
+		if err != nil {
+			return nil, err
+		}
+
+		if name == typeName {
+			return annotation, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func readAnnotations(r io.Reader) ([]*Annotation, error) {
+	var count uint16
+	err := binary.Read(r, byteOrder, &count)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := make([]*Annotation, 0, count)
+
+	for i := uint16(0); i < count; i++ {
+		annotation := &Annotation{}
+		err := annotation.read(r)
+		if err != nil {
+			return nil, err
+		}
+
+		annotations = append(annotations, annotation)
+	}
+
+	return annotations, nil
+}
+
+func writeAnnotations(w io.Writer, annotations []*Annotation) error {
+	err := binary.Write(w, byteOrder, uint16(len(annotations)))
+	if err != nil {
+		return err
+	}
+
+	for _, annotation := range annotations {
+		err := annotation.dump(w)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *Annotation) read(r io.Reader) error {
+	var err error
+
+	var pairsCount uint16
+	err = multiError([]error{
+		binary.Read(r, byteOrder, &a.TypeIndex),
+		binary.Read(r, byteOrder, &pairsCount),
+	})
+	if err != nil {
+		return err
+	}
+
+	a.Pairs = make([]ElementValuePair, 0, pairsCount)
+
+	for i := uint16(0); i < pairsCount; i++ {
+		pair := ElementValuePair{}
+
+		err = binary.Read(r, byteOrder, &pair.NameIndex)
+		if err != nil {
+			return err
+		}
+
+		pair.Value, err = readElementValue(r)
+		if err != nil {
+			return err
+		}
+
+		a.Pairs = append(a.Pairs, pair)
+	}
+
+	return nil
+}
+
+func (a *Annotation) dump(w io.Writer) error {
+	err := multiError([]error{
+		binary.Write(w, byteOrder, a.TypeIndex),
+		binary.Write(w, byteOrder, uint16(len(a.Pairs))),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, pair := range a.Pairs {
+		err := multiError([]error{
+			binary.Write(w, byteOrder, pair.NameIndex),
+			pair.Value.Dump(w),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type ElementValueType uint8
+
+// An ElementValue is a discriminated union representing the
+// value of an element-value pair. Depending on the tag, it is
+// one of ConstElementValue, EnumElementValue, ClassElementValue,
+// AnnotationElementValue or ArrayElementValue.
+type ElementValue interface {
+	Dumper
+
+	read(io.Reader) error
+
+	GetTag() ElementValueType
+}
+
+func readElementValue(r io.Reader) (ElementValue, error) {
+	valueBase := baseElementValue{}
+
+	err := binary.Read(r, byteOrder, &valueBase.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	var value ElementValue
+
+	switch valueBase.Tag {
+	case ELEMENT_VALUE_Byte, ELEMENT_VALUE_Char, ELEMENT_VALUE_Double,
+		ELEMENT_VALUE_Float, ELEMENT_VALUE_Int, ELEMENT_VALUE_Long,
+		ELEMENT_VALUE_Short, ELEMENT_VALUE_Boolean, ELEMENT_VALUE_String:
+		value = &ConstElementValue{baseElementValue: valueBase}
+	case ELEMENT_VALUE_Enum:
+		value = &EnumElementValue{baseElementValue: valueBase}
+	case ELEMENT_VALUE_Class:
+		value = &ClassElementValue{baseElementValue: valueBase}
+	case ELEMENT_VALUE_Annotation:
+		value = &AnnotationElementValue{baseElementValue: valueBase}
+	case ELEMENT_VALUE_Array:
+		value = &ArrayElementValue{baseElementValue: valueBase}
+	default:
+		return nil, errors.New("jclass: unknown element value tag")
+	}
+
+	err = value.read(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+type baseElementValue struct {
+	Tag ElementValueType
+}
+
+func (v baseElementValue) GetTag() ElementValueType { return v.Tag }
+
+// A primitive or String constant. ConstValueIndex references
+// a CONSTANT_Integer_info (for B, C, I, S and Z), CONSTANT_Long_info,
+// CONSTANT_Float_info, CONSTANT_Double_info or, for strings,
+// a CONSTANT_Utf8_info.
+type ConstElementValue struct {
+	baseElementValue
+	ConstValueIndex ConstPoolIndex
+}
+
+func (v *ConstElementValue) read(r io.Reader) error {
+	return binary.Read(r, byteOrder, &v.ConstValueIndex)
+}
+
+func (v *ConstElementValue) Dump(w io.Writer) error { return binary.Write(w, byteOrder, v) }
+
+// Value resolves the constant and returns it as the Go type
+// matching the tag: int8, uint16 (char), float64, float32,
+// int32, int64, int16, bool or string.
+func (v *ConstElementValue) Value(constPool ConstantPool) (interface{}, error) {
+	switch v.Tag {
+	case ELEMENT_VALUE_Double:
+		return constPool.LookupDouble(v.ConstValueIndex)
+	case ELEMENT_VALUE_Float:
+		return constPool.LookupFloat(v.ConstValueIndex)
+	case ELEMENT_VALUE_Long:
+		return constPool.LookupLong(v.ConstValueIndex)
+	case ELEMENT_VALUE_String:
+		return constPool.LookupUTF8(v.ConstValueIndex)
+	}
+
+	value, err := constPool.LookupInteger(v.ConstValueIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v.Tag {
+	case ELEMENT_VALUE_Byte:
+		return int8(value), nil
+	case ELEMENT_VALUE_Char:
+		return uint16(value), nil
+	case ELEMENT_VALUE_Short:
+		return int16(value), nil
+	case ELEMENT_VALUE_Boolean:
+		return value != 0, nil
+	}
+
+	return value, nil
+}
+
+// An enum constant. TypeNameIndex references the field descriptor
+// of the enum type, ConstNameIndex the simple name of the constant.
+type EnumElementValue struct {
+	baseElementValue
+	TypeNameIndex  ConstPoolIndex
+	ConstNameIndex ConstPoolIndex
+}
+
+func (v *EnumElementValue) read(r io.Reader) error {
+	return multiError([]error{
+		binary.Read(r, byteOrder, &v.TypeNameIndex),
+		binary.Read(r, byteOrder, &v.ConstNameIndex),
+	})
+}
+
+func (v *EnumElementValue) Dump(w io.Writer) error { return binary.Write(w, byteOrder, v) }
+
+func (v *EnumElementValue) TypeName(constPool ConstantPool) (string, error) {
+	return constPool.LookupUTF8(v.TypeNameIndex)
+}
+
+func (v *EnumElementValue) ConstName(constPool ConstantPool) (string, error) {
+	return constPool.LookupUTF8(v.ConstNameIndex)
+}
+
+// A class literal. ClassInfoIndex references a CONSTANT_Utf8_info
+// holding the return descriptor of the class (e.g. "Ljava/lang/Object;"
+// or "V" for void.class), not a CONSTANT_Class_info.
+type ClassElementValue struct {
+	baseElementValue
+	ClassInfoIndex ConstPoolIndex
+}
+
+func (v *ClassElementValue) read(r io.Reader) error {
+	return binary.Read(r, byteOrder, &v.ClassInfoIndex)
+}
+
+func (v *ClassElementValue) Dump(w io.Writer) error { return binary.Write(w, byteOrder, v) }
+
+func (v *ClassElementValue) ClassName(constPool ConstantPool) (string, error) {
+	return constPool.LookupUTF8(v.ClassInfoIndex)
+}
+
+// A nested annotation.
+type AnnotationElementValue struct {
+	baseElementValue
+	Annotation
+}
+
+func (v *AnnotationElementValue) read(r io.Reader) error {
+	return v.Annotation.read(r)
+}
+
+func (v *AnnotationElementValue) Dump(w io.Writer) error {
+	return multiError([]error{
+		binary.Write(w, byteOrder, v.baseElementValue),
+		v.Annotation.dump(w),
+	})
+}
+
+// An array of (possibly nested) element values.
+type ArrayElementValue struct {
+	baseElementValue
+	Values []ElementValue
+}
+
+func (v *ArrayElementValue) read(r io.Reader) error {
+	var valuesCount uint16
+	err := binary.Read(r, byteOrder, &valuesCount)
+	if err != nil {
+		return err
+	}
+
+	v.Values = make([]ElementValue, 0, valuesCount)
+
+	for i := uint16(0); i < valuesCount; i++ {
+		value, err := readElementValue(r)
+		if err != nil {
+			return err
+		}
+
+		v.Values = append(v.Values, value)
+	}
+
+	return nil
+}
+
+func (v *ArrayElementValue) Dump(w io.Writer) error {
+	err := multiError([]error{
+		binary.Write(w, byteOrder, v.baseElementValue),
+		binary.Write(w, byteOrder, uint16(len(v.Values))),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, value := range v.Values {
+		err := value.Dump(w)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readParameterAnnotations(r io.Reader) ([][]*Annotation, error) {
+	var count uint8
+	err := binary.Read(r, byteOrder, &count)
+	if err != nil {
+		return nil, err
+	}
+
+	parameters := make([][]*Annotation, 0, count)
+
+	for i := uint8(0); i < count; i++ {
+		annotations, err := readAnnotations(r)
+		if err != nil {
+			return nil, err
+		}
+
+		parameters = append(parameters, annotations)
+	}
+
+	return parameters, nil
+}
+
+func writeParameterAnnotations(w io.Writer, parameters [][]*Annotation) error {
+	err := binary.Write(w, byteOrder, uint8(len(parameters)))
+	if err != nil {
+		return err
+	}
+
+	for _, annotations := range parameters {
+		err := writeAnnotations(w, annotations)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
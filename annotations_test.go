@@ -0,0 +1,209 @@
+package class
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestElementValueReadDump(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		value ElementValue
+	}{
+		{"int", []byte{'I', 0x00, 0x04}, &ConstElementValue{baseElementValue{ELEMENT_VALUE_Int}, 4}},
+		{"long", []byte{'J', 0x01, 0x02}, &ConstElementValue{baseElementValue{ELEMENT_VALUE_Long}, 0x0102}},
+		{"string", []byte{'s', 0x00, 0x0c}, &ConstElementValue{baseElementValue{ELEMENT_VALUE_String}, 12}},
+		{"enum", []byte{'e', 0x00, 0x06, 0x00, 0x07}, &EnumElementValue{baseElementValue{ELEMENT_VALUE_Enum}, 6, 7}},
+		{"class", []byte{'c', 0x00, 0x09}, &ClassElementValue{baseElementValue{ELEMENT_VALUE_Class}, 9}},
+		{
+			"annotation",
+			[]byte{'@', 0x00, 0x0b, 0x00, 0x01, 0x00, 0x03, 's', 0x00, 0x0c},
+			&AnnotationElementValue{baseElementValue{ELEMENT_VALUE_Annotation}, Annotation{11, []ElementValuePair{
+				{3, &ConstElementValue{baseElementValue{ELEMENT_VALUE_String}, 12}},
+			}}},
+		},
+		{"empty array", []byte{'[', 0x00, 0x00}, &ArrayElementValue{baseElementValue{ELEMENT_VALUE_Array}, []ElementValue{}}},
+		{
+			"nested array",
+			[]byte{'[', 0x00, 0x02, '[', 0x00, 0x02, 'Z', 0x00, 0x0e, 'B', 0x00, 0x0f, '[', 0x00, 0x00},
+			&ArrayElementValue{baseElementValue{ELEMENT_VALUE_Array}, []ElementValue{
+				&ArrayElementValue{baseElementValue{ELEMENT_VALUE_Array}, []ElementValue{
+					&ConstElementValue{baseElementValue{ELEMENT_VALUE_Boolean}, 14},
+					&ConstElementValue{baseElementValue{ELEMENT_VALUE_Byte}, 15},
+				}},
+				&ArrayElementValue{baseElementValue{ELEMENT_VALUE_Array}, []ElementValue{}},
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		value, err := readElementValue(bytes.NewReader(test.data))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(value, test.value) {
+			t.Errorf("%s: read as %#v", test.name, value)
+		}
+
+		if got := dump(t, value); !bytes.Equal(got, test.data) {
+			t.Errorf("%s: dumped as % x, want % x", test.name, got, test.data)
+		}
+	}
+
+	for _, data := range [][]byte{{'x', 0x00, 0x01}, {'[', 0x00, 0x01}, {'e', 0x00}} {
+		if _, err := readElementValue(bytes.NewReader(data)); err == nil {
+			t.Errorf("% x: expected an error", data)
+		}
+	}
+}
+
+func annotationsConstPool(name string) ConstantPool {
+	return testConstPool(
+		name,                 // #1
+		"Lfoo/Bar;",          // #2
+		"value",              // #3
+		int32(42),            // #4
+		"kind",               // #5
+		"Lfoo/Kind;",         // #6
+		"FAST",               // #7
+		"type",               // #8
+		"Ljava/lang/String;", // #9
+		"nested",             // #10
+		"Lfoo/Nested;",       // #11
+		"text",               // #12
+		"flags",              // #13
+		int32(1),             // #14
+		int32(-3),            // #15
+	)
+}
+
+// annotationsBody holds a @Bar with all kinds of element
+// values and a @Nested without any.
+var annotationsBody = []byte{
+	0x00, 0x02,
+	0x00, 0x02, 0x00, 0x05,
+	0x00, 0x03, 'I', 0x00, 0x04,
+	0x00, 0x05, 'e', 0x00, 0x06, 0x00, 0x07,
+	0x00, 0x08, 'c', 0x00, 0x09,
+	0x00, 0x0a, '@', 0x00, 0x0b, 0x00, 0x01, 0x00, 0x03, 's', 0x00, 0x0c,
+	0x00, 0x0d, '[', 0x00, 0x03,
+	'[', 0x00, 0x02, 'Z', 0x00, 0x0e, 'B', 0x00, 0x0f,
+	's', 0x00, 0x0c,
+	'[', 0x00, 0x00,
+	0x00, 0x0b, 0x00, 0x00,
+}
+
+func TestAnnotationsReadDump(t *testing.T) {
+	for _, name := range []string{"RuntimeVisibleAnnotations", "RuntimeInvisibleAnnotations"} {
+		attr := readAttributeBody(t, annotationsConstPool(name), annotationsBody)
+
+		annotations := Attributes{attr}.Annotations()
+		if len(annotations) != 2 || len(annotations[0].Pairs) != 5 || len(annotations[1].Pairs) != 0 {
+			t.Errorf("%s: got %v", name, annotations)
+		}
+	}
+
+	constPool := testConstPool("AnnotationDefault", "Lfoo/Kind;", "FAST")
+	attr := readAttributeBody(t, constPool, []byte{'[', 0x00, 0x01, 'e', 0x00, 0x02, 0x00, 0x03})
+
+	value := attr.AnnotationDefault().DefaultValue.(*ArrayElementValue).Values[0].(*EnumElementValue)
+	if name, err := value.ConstName(constPool); err != nil || name != "FAST" {
+		t.Errorf("got %q, %v", name, err)
+	}
+}
+
+func TestAnnotationElements(t *testing.T) {
+	constPool := annotationsConstPool("RuntimeVisibleAnnotations")
+	attrs := Attributes{
+		readAttributeBody(t, constPool, annotationsBody),
+		&RuntimeInvisibleAnnotations{Annotations: []*Annotation{{TypeIndex: 9}}},
+	}
+
+	if annotations := attrs.Annotations(); len(annotations) != 3 {
+		t.Fatalf("got %d annotations", len(annotations))
+	}
+
+	bar, err := attrs.FindAnnotation(constPool, "Lfoo/Bar;")
+	if err != nil || bar == nil {
+		t.Fatalf("got %v, %v", bar, err)
+	}
+
+	if invisible, err := attrs.FindAnnotation(constPool, "Ljava/lang/String;"); err != nil || invisible != attrs[1].RuntimeInvisibleAnnotations().Annotations[0] {
+		t.Errorf("got %v, %v", invisible, err)
+	}
+
+	if missing, err := attrs.FindAnnotation(constPool, "Lfoo/Missing;"); err != nil || missing != nil {
+		t.Errorf("got %v, %v", missing, err)
+	}
+
+	element := func(a *Annotation, name string) ElementValue {
+		value, err := a.Element(constPool, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return value
+	}
+
+	constValue := func(value ElementValue) interface{} {
+		v, err := value.(*ConstElementValue).Value(constPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return v
+	}
+
+	if v := constValue(element(bar, "value")); v != int32(42) {
+		t.Errorf("value: got %#v", v)
+	}
+
+	kind := element(bar, "kind").(*EnumElementValue)
+	typeName, err1 := kind.TypeName(constPool)
+	constName, err2 := kind.ConstName(constPool)
+	if typeName != "Lfoo/Kind;" || constName != "FAST" || err1 != nil || err2 != nil {
+		t.Errorf("kind: got %q %q", typeName, constName)
+	}
+
+	if name, err := element(bar, "type").(*ClassElementValue).ClassName(constPool); err != nil || name != "Ljava/lang/String;" {
+		t.Errorf("type: got %q, %v", name, err)
+	}
+
+	nested := element(bar, "nested").(*AnnotationElementValue)
+	if name, err := nested.TypeName(constPool); err != nil || name != "Lfoo/Nested;" {
+		t.Errorf("nested: got %q, %v", name, err)
+	}
+
+	if v := constValue(element(&nested.Annotation, "value")); v != "text" {
+		t.Errorf("nested value: got %#v", v)
+	}
+
+	flags := element(bar, "flags").(*ArrayElementValue).Values
+	inner := flags[0].(*ArrayElementValue).Values
+	if constValue(inner[0]) != true || constValue(inner[1]) != int8(-3) || constValue(flags[1]) != "text" ||
+		len(flags[2].(*ArrayElementValue).Values) != 0 {
+		t.Errorf("flags: got %v", flags)
+	}
+
+	if value := element(bar, "missing"); value != nil {
+		t.Errorf("missing: got %v", value)
+	}
+
+	// The type and name indexes are checked
+	broken := &Annotation{TypeIndex: 4, Pairs: []ElementValuePair{{99, nil}}}
+	if _, err := broken.TypeName(constPool); err == nil {
+		t.Error("Integer used as type name")
+	}
+
+	if _, err := broken.Element(constPool, "value"); err == nil {
+		t.Error("invalid element name not reported")
+	}
+
+	if _, err := (&ConstElementValue{baseElementValue{ELEMENT_VALUE_Long}, 4}).Value(constPool); err == nil {
+		t.Error("Integer used as long value")
+	}
+}
@@ -0,0 +1,1422 @@
+package class
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The text assembly format is a line based, low level description
+// of a class file. Every line holds a directive or an instruction,
+// tokens are separated by whitespace and everything after a ';'
+// (outside of a string) is a comment. Strings are written as Go
+// string literals, so arbitrary bytes can be expressed. A class
+// file looks like this:
+//
+//	.version 50 0                     ; major minor
+//	.const #1 = Methodref #3 #5       ; constants, in index order
+//	.const #2 = Utf8 "Code"
+//	...
+//	.class public super #7            ; flags and this_class
+//	.super #3
+//	.implements #9
+//	.field private #10 #11            ; flags, name and descriptor
+//	.end field
+//	.method public #12 #13
+//	  .attribute #2 stack 1 locals 1  ; Code
+//	    L0:
+//	      aload_0
+//	      invokespecial #1
+//	      return
+//	    .catch L0 L0 L0 #0            ; start, end, handler and catch type
+//	  .end attribute
+//	.end method
+//	.attribute #15 #16                ; class attributes, e.g. SourceFile
+//
+// Attributes are introduced by .attribute, followed by the index
+// of their name, an optional "length n" to store an attribute_length
+// that differs from the actual one, and a body depending on the
+// attribute's name. Attributes without a dedicated syntax (and any
+// attribute, if desired) are written as "raw" followed by a hex
+// string of the body. Attributes with a multi-line body end with
+// .end attribute. Branch targets and other code positions are either
+// labels (L<name>) or absolute PCs, branch targets outside of 0-65535
+// are given relative to the instruction (+<offset>, e.g. +-100).
+// Comments are only written for convenience and ignored by Assemble,
+// control characters in them are escaped. Constant pool indexes are
+// always given explicitly, the assembler doesn't add or reorder
+// constants.
+// Utf8 constants, whose bytes aren't the Modified UTF-8 encoding of
+// their value (e.g. invalid sequences), are written as Utf8 raw
+// followed by a string literal of the bytes.
+
+// Assemble parses a class file from the text assembly format
+// written by WriteAssembly.
+func Assemble(r io.Reader) (*ClassFile, error) {
+	p := &textParser{c: &ClassFile{Magic: 0xCAFEBABE}}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, math.MaxInt32)
+
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		tokens, err := tokenize(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("jclass: line %d: %s", lineNo, err)
+		}
+
+		if len(tokens) > 0 {
+			p.lines = append(p.lines, tokens)
+			p.lineNos = append(p.lineNos, lineNo)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	p.classFile()
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	return p.c, nil
+}
+
+// WriteAssembly writes the class file in the text assembly format
+// read by Assemble. Assembling the output results in a class file,
+// that dumps (with DumpOriginal) to exactly the same bytes as the
+// original one.
+func (c *ClassFile) WriteAssembly(w io.Writer) error {
+	a := &assemblyWriter{disassembler: &disassembler{w: w, c: c}}
+
+	if c.Magic != 0xCAFEBABE {
+		a.printf(".magic 0x%08x\n", c.Magic)
+	}
+	a.printf(".version %d %d\n", c.MajorVersion, c.MinorVersion)
+
+	if int(c.ConstPoolSize) != constPoolSize(c.ConstantPool) {
+		a.printf(".constpool %d\n", c.ConstPoolSize)
+	}
+	for n, constant := range c.ConstantPool {
+		if constant != nil && n+1 < int(c.ConstPoolSize) {
+			a.constant(ConstPoolIndex(n+1), constant)
+		}
+	}
+
+	a.printf("\n")
+	a.line(fmt.Sprintf(".class %s#%d", flagWords(c.AccessFlags, classFlags), c.ThisClass), a.className(c.ThisClass))
+	if c.SuperClass != 0 {
+		a.line(fmt.Sprintf(".super #%d", c.SuperClass), a.className(c.SuperClass))
+	} else {
+		a.printf(".super #0\n")
+	}
+	for _, index := range c.Interfaces {
+		a.line(fmt.Sprintf(".implements #%d", index), a.className(index))
+	}
+
+	for _, field := range c.Fields {
+		a.printf("\n")
+		a.line(fmt.Sprintf(".field %s#%d #%d", flagWords(field.AccessFlags, fieldFlags), field.NameIndex, field.DescriptorIndex),
+			a.utf8(field.NameIndex)+":"+a.utf8(field.DescriptorIndex))
+		a.attributes(field.Attributes, "  ")
+		a.printf(".end field\n")
+	}
+
+	for _, method := range c.Methods {
+		a.printf("\n")
+		a.line(fmt.Sprintf(".method %s#%d #%d", flagWords(method.AccessFlags, methodFlags), method.NameIndex, method.DescriptorIndex),
+			quoteName(a.utf8(method.NameIndex))+":"+a.utf8(method.DescriptorIndex))
+		a.attributes(method.Attributes, "  ")
+		a.printf(".end method\n")
+	}
+
+	if len(c.Attributes) > 0 {
+		a.printf("\n")
+		a.attributes(c.Attributes, "")
+	}
+
+	return a.err
+}
+
+// tokenize splits a line of the assembly format into tokens,
+// string literals are kept (with their quotes) as a single token.
+func tokenize(line string) ([]string, error) {
+	var tokens []string
+
+	for i := 0; i < len(line); {
+		switch line[i] {
+		case ' ', '\t', '\r':
+			i++
+
+		case ';':
+			return tokens, nil
+
+		case '"':
+			j := i + 1
+			for ; j < len(line) && line[j] != '"'; j++ {
+				if line[j] == '\\' {
+					j++
+				}
+			}
+
+			if j >= len(line) {
+				return nil, errors.New("unterminated string")
+			}
+
+			tokens = append(tokens, line[i:j+1])
+			i = j + 1
+
+		default:
+			j := i
+			for j < len(line) && !strings.ContainsRune(" \t\r;\"", rune(line[j])) {
+				j++
+			}
+
+			tokens = append(tokens, line[i:j])
+			i = j
+		}
+	}
+
+	return tokens, nil
+}
+
+// flagWords formats flags as the lower case flag names (e.g.
+// "public static "), unknown flags are given in hex.
+func flagWords(flags AccessFlags, names []flagName) string {
+	var words []string
+	rest := flags
+
+	for _, name := range names {
+		if flags&name.flag != 0 && rest&name.flag != 0 {
+			words = append(words, strings.ToLower(strings.TrimPrefix(name.name, "ACC_")))
+			rest &^= name.flag
+		}
+	}
+
+	if rest != 0 {
+		words = append(words, fmt.Sprintf("0x%04x", uint16(rest)))
+	}
+
+	if len(words) == 0 {
+		return ""
+	}
+
+	return strings.Join(words, " ") + " "
+}
+
+type assemblyWriter struct {
+	*disassembler
+
+	// formats code positions, set while writing a Code attribute
+	pcRef func(pc int) string
+}
+
+// line prints text, followed by comment (if there is one).
+// Control characters in the comment are escaped, so that
+// constants (e.g. a Utf8 with a newline) can't break the line.
+func (a *assemblyWriter) line(text string, comment string) {
+	if comment != "" {
+		if len(text) < commentColumn-2 {
+			text += strings.Repeat(" ", commentColumn-2-len(text))
+		}
+
+		text += " ; " + escapeControl(comment)
+	}
+
+	a.printf("%s\n", text)
+}
+
+// escapeControl escapes the control characters in s
+// like strconv.Quote does, all others are kept.
+func escapeControl(s string) string {
+	if strings.IndexFunc(s, unicode.IsControl) < 0 {
+		return s
+	}
+
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			quoted := strconv.QuoteRune(r)
+			b.WriteString(quoted[1 : len(quoted)-1])
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+func (a *assemblyWriter) constant(index ConstPoolIndex, constant Constant) {
+	var args string
+
+	switch constant := constant.(type) {
+	case *ClassRef:
+		args = fmt.Sprintf("#%d", constant.NameIndex)
+	case *FieldRef:
+		args = fmt.Sprintf("#%d #%d", constant.ClassIndex, constant.NameAndTypeIndex)
+	case *MethodRef:
+		args = fmt.Sprintf("#%d #%d", constant.ClassIndex, constant.NameAndTypeIndex)
+	case *InterfaceMethodRef:
+		args = fmt.Sprintf("#%d #%d", constant.ClassIndex, constant.NameAndTypeIndex)
+	case *StringRef:
+		args = fmt.Sprintf("#%d", constant.Index)
+	case *IntegerRef:
+		args = strconv.FormatInt(int64(constant.Value), 10)
+	case *FloatRef:
+		if math.IsNaN(float64(constant.Value)) {
+			args = fmt.Sprintf("nan:0x%08x", math.Float32bits(constant.Value))
+		} else {
+			args = strconv.FormatFloat(float64(constant.Value), 'g', -1, 32)
+		}
+	case *LongRef:
+		args = strconv.FormatInt(constant.Value, 10)
+	case *DoubleRef:
+		if math.IsNaN(constant.Value) {
+			args = fmt.Sprintf("nan:0x%016x", math.Float64bits(constant.Value))
+		} else {
+			args = strconv.FormatFloat(constant.Value, 'g', -1, 64)
+		}
+	case *NameAndTypeRef:
+		args = fmt.Sprintf("#%d #%d", constant.NameIndex, constant.DescriptorIndex)
+	case *UTF8Ref:
+		// Keep the original bytes, if they differ from
+		// the encoding of the (decoded) value.
+		if str := constant.Bytes(); !bytes.Equal(str, encodeModifiedUTF8(constant.Value)) {
+			args = "raw " + strconv.Quote(string(str))
+		} else {
+			args = strconv.Quote(constant.Value)
+		}
+	case *MethodHandleRef:
+		args = fmt.Sprintf("%d #%d", constant.ReferenceKind, constant.ReferenceIndex)
+	case *MethodTypeRef:
+		args = fmt.Sprintf("#%d", constant.DescriptorIndex)
+	case *DynamicRef:
+		args = fmt.Sprintf("%d #%d", constant.BootstrapMethodAttrIndex, constant.NameAndTypeIndex)
+	case *InvokeDynamicRef:
+		args = fmt.Sprintf("%d #%d", constant.BootstrapMethodAttrIndex, constant.NameAndTypeIndex)
+	case *ModuleRef:
+		args = fmt.Sprintf("#%d", constant.NameIndex)
+	case *PackageRef:
+		args = fmt.Sprintf("#%d", constant.NameIndex)
+	}
+
+	a.line(fmt.Sprintf(".const #%d = %s %s", index, constantNames[constant.GetTag()], args), a.constantComment(constant))
+}
+
+func (a *assemblyWriter) attributes(attrs Attributes, indent string) {
+	for _, attr := range attrs {
+		a.attribute(attr, indent)
+	}
+}
+
+func (a *assemblyWriter) attribute(attr Attribute, indent string) {
+	var buf bytes.Buffer
+
+	err := attr.Dump(&buf)
+	if err != nil {
+		a.err = err
+		return
+	}
+
+	if buf.Len() < 6 {
+		a.err = errors.New("jclass: invalid attribute")
+		return
+	}
+
+	raw := buf.Bytes()
+	nameIndex := ConstPoolIndex(byteOrder.Uint16(raw))
+	length := byteOrder.Uint32(raw[2:])
+	body := raw[6:]
+
+	head := fmt.Sprintf("%s.attribute #%d", indent, nameIndex)
+	if int(length) != len(body) {
+		head += fmt.Sprintf(" length %d", length)
+	}
+
+	name := a.utf8(nameIndex)
+	inner := indent + "  "
+
+	switch attr := attr.(type) {
+	case *ConstantValue:
+		a.line(fmt.Sprintf("%s #%d", head, attr.Index), name+" "+a.constantValue(attr.Index))
+
+	case *Code:
+		a.code(attr, head, indent)
+
+	case *Exceptions:
+		a.line(head+indexList(attr.ExceptionsTable), name)
+
+	case *InnerClasses:
+		a.line(head, name)
+		for _, class := range attr.Classes {
+			a.line(fmt.Sprintf("%s#%d #%d #%d %s", inner, class.InnerClassIndex, class.OuterClassIndex, class.InnerName,
+				strings.TrimSpace(flagWords(class.InnerAccessFlags, nestedClassFlags))), a.className(class.InnerClassIndex))
+		}
+		a.printf("%s.end attribute\n", indent)
+
+	case *EnclosingMethod:
+		a.line(fmt.Sprintf("%s #%d #%d", head, attr.ClassIndex, attr.MethodIndex), name)
+
+	case *Synthetic, *Deprecated:
+		a.line(head, name)
+
+	case *Signature:
+		a.line(fmt.Sprintf("%s #%d", head, attr.SignatureIndex), name+" "+a.utf8(attr.SignatureIndex))
+
+	case *SourceFile:
+		a.line(fmt.Sprintf("%s #%d", head, attr.SourceFileIndex), name+" \""+escapeString(a.utf8(attr.SourceFileIndex))+"\"")
+
+	case *LineNumberTable:
+		a.line(head, name)
+		for _, line := range attr.Table {
+			a.printf("%s%s %d\n", inner, a.pc(int(line.StartPC)), line.LineNumber)
+		}
+		a.printf("%s.end attribute\n", indent)
+
+	case *LocalVariableTable:
+		a.line(head, name)
+		for _, v := range attr.Table {
+			a.line(fmt.Sprintf("%s%s %d #%d #%d %d", inner, a.pc(int(v.StartPC)), v.Length, v.NameIndex, v.DescriptorIndex, v.Index),
+				a.utf8(v.NameIndex)+" "+a.utf8(v.DescriptorIndex))
+		}
+		a.printf("%s.end attribute\n", indent)
+
+	case *LocalVariableTypeTable:
+		a.line(head, name)
+		for _, v := range attr.Table {
+			a.line(fmt.Sprintf("%s%s %d #%d #%d %d", inner, a.pc(int(v.StartPC)), v.Length, v.NameIndex, v.SignatureIndex, v.Index),
+				a.utf8(v.NameIndex)+" "+a.utf8(v.SignatureIndex))
+		}
+		a.printf("%s.end attribute\n", indent)
+
+	case *BootstrapMethods:
+		a.line(head, name)
+		for _, method := range attr.Methods {
+			a.printf("%s#%d%s\n", inner, method.MethodRef, indexList(method.Args))
+		}
+		a.printf("%s.end attribute\n", indent)
+
+	case *NestHost:
+		a.line(fmt.Sprintf("%s #%d", head, attr.HostClassIndex), name+" "+a.className(attr.HostClassIndex))
+
+	case *NestMembers:
+		a.line(head+indexList(attr.Classes), name)
+
+	case *PermittedSubclasses:
+		a.line(head+indexList(attr.Classes), name)
+
+	case *ModulePackages:
+		a.line(head+indexList(attr.Packages), name)
+
+	case *ModuleMainClass:
+		a.line(fmt.Sprintf("%s #%d", head, attr.MainClassIndex), name+" "+a.className(attr.MainClassIndex))
+
+	case *MethodParameters:
+		a.line(head, name)
+		for _, param := range attr.Parameters {
+			a.printf("%s#%d %s\n", inner, param.NameIndex, strings.TrimSpace(flagWords(param.AccessFlags, parameterFlags)))
+		}
+		a.printf("%s.end attribute\n", indent)
+
+	default:
+		a.line(strings.TrimRight(fmt.Sprintf("%s raw %x", head, body), " "), name)
+	}
+}
+
+func indexList(indexes []ConstPoolIndex) string {
+	var list string
+	for _, index := range indexes {
+		list += fmt.Sprintf(" #%d", index)
+	}
+
+	return list
+}
+
+// pc formats a code position, as a label or a plain number.
+func (a *assemblyWriter) pc(pc int) string {
+	if a.pcRef != nil {
+		return a.pcRef(pc)
+	}
+
+	return strconv.Itoa(pc)
+}
+
+// target formats the target of a branch of inst. Targets that
+// aren't valid code positions (i.e. negative or above 65535) are
+// written as offsets relative to the instruction, e.g. "+-100".
+func (a *assemblyWriter) target(inst *Instruction, offset int32) string {
+	pc := inst.PC + int(offset)
+	if pc < 0 || pc > math.MaxUint16 {
+		return "+" + strconv.Itoa(int(offset))
+	}
+
+	return a.pc(pc)
+}
+
+func (a *assemblyWriter) code(code *Code, head, indent string) {
+	a.line(fmt.Sprintf("%s stack %d locals %d", head, code.MaxStackSize, code.MaxLocalsCount), "Code")
+
+	insts, err := code.Instructions()
+	if err == nil {
+		var encoded []uint8
+		encoded, err = EncodeInstructions(insts)
+		if err == nil && !bytes.Equal(encoded, code.ByteCode) {
+			err = errors.New("jclass: bytecode doesn't round-trip")
+		}
+	}
+
+	boundaries := map[int]bool{}
+	if err == nil {
+		boundaries[len(code.ByteCode)] = true
+		for _, inst := range insts {
+			boundaries[inst.PC] = true
+		}
+	}
+
+	// Collect the referenced positions first, so the labels
+	// can be printed along with the instructions.
+	referenced := map[int]bool{}
+	a.pcRef = func(pc int) string {
+		if boundaries[pc] {
+			referenced[pc] = true
+			return "L" + strconv.Itoa(pc)
+		}
+
+		return strconv.Itoa(pc)
+	}
+	defer func() { a.pcRef = nil }()
+
+	w := a.w
+	a.w = io.Discard
+	a.codeBody(code, insts, err == nil, referenced, indent)
+	a.w = w
+	a.codeBody(code, insts, err == nil, referenced, indent)
+
+	a.printf("%s.end attribute\n", indent)
+}
+
+func (a *assemblyWriter) codeBody(code *Code, insts []*Instruction, decoded bool, labels map[int]bool, indent string) {
+	inner := indent + "  "
+
+	if !decoded {
+		a.printf("%sbytecode %x\n", inner, code.ByteCode)
+	}
+
+	for _, inst := range insts {
+		if labels[inst.PC] {
+			a.printf("%sL%d:\n", inner, inst.PC)
+		}
+
+		a.instruction(inst, inner+"  ")
+	}
+
+	if decoded && labels[len(code.ByteCode)] {
+		a.printf("%sL%d:\n", inner, len(code.ByteCode))
+	}
+
+	for _, e := range code.ExceptionsTable {
+		comment := "any"
+		if e.CatchType != 0 {
+			comment = a.className(e.CatchType)
+		}
+
+		a.line(fmt.Sprintf("%s.catch %s %s %s #%d", inner, a.pc(int(e.StartPC)), a.pc(int(e.EndPC)), a.pc(int(e.HandlerPC)), e.CatchType), comment)
+	}
+
+	a.attributes(code.Attributes, inner)
+}
+
+func (a *assemblyWriter) instruction(inst *Instruction, indent string) {
+	text := inst.Mnemonic()
+	if inst.Wide {
+		text = "wide " + text
+	}
+
+	var comment string
+
+	switch opcodes[inst.Opcode].format {
+	case operandByte, operandShort:
+		text += fmt.Sprintf(" %d", inst.Const)
+	case operandConstPoolByte, operandConstPool:
+		text += fmt.Sprintf(" #%d", inst.Index)
+		comment = a.constantValue(inst.Index)
+	case operandLocal:
+		text += fmt.Sprintf(" %d", inst.Local)
+	case operandIinc:
+		text += fmt.Sprintf(" %d %d", inst.Local, inst.Const)
+	case operandBranch, operandBranchWide:
+		text += " " + a.target(inst, inst.Offset)
+	case operandTableSwitch:
+		text += fmt.Sprintf(" %d", inst.Low)
+		for _, c := range inst.Cases {
+			text += " " + a.target(inst, c.Offset)
+		}
+		text += " default " + a.target(inst, inst.Offset)
+	case operandLookupSwitch:
+		for _, c := range inst.Cases {
+			text += fmt.Sprintf(" %d %s", c.Match, a.target(inst, c.Offset))
+		}
+		text += " default " + a.target(inst, inst.Offset)
+	case operandInvokeInterface, operandMultiANewArray:
+		text += fmt.Sprintf(" #%d %d", inst.Index, inst.Const)
+		comment = a.constantValue(inst.Index)
+	case operandInvokeDynamic:
+		text += fmt.Sprintf(" #%d", inst.Index)
+		comment = a.constantValue(inst.Index)
+	case operandNewArray:
+		if name, ok := arrayTypeNames[inst.Const]; ok {
+			text += " " + name
+		} else {
+			text += fmt.Sprintf(" %d", inst.Const)
+		}
+	}
+
+	a.line(indent+text, comment)
+}
+
+type textParser struct {
+	lines   [][]string
+	lineNos []int
+	pos     int
+
+	c   *ClassFile
+	err error
+
+	// labels and fixups of the Code attribute being parsed
+	labels map[string]*Label
+	placed map[*Label]bool
+	fixups []func()
+}
+
+var mnemonics map[string]Opcode
+
+func init() {
+	mnemonics = make(map[string]Opcode)
+	for op := range opcodes {
+		if Opcode(op).IsValid() {
+			mnemonics[opcodes[op].mnemonic] = Opcode(op)
+		}
+	}
+}
+
+// fail records the first error, along with the current line.
+func (p *textParser) fail(format string, args ...interface{}) {
+	if p.err != nil {
+		return
+	}
+
+	line := 0
+	if p.pos > 0 && p.pos <= len(p.lineNos) {
+		line = p.lineNos[p.pos-1]
+	}
+
+	p.err = fmt.Errorf("jclass: line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// next returns the tokens of the next line, or nil at the end.
+func (p *textParser) next() []string {
+	if p.err != nil || p.pos >= len(p.lines) {
+		return nil
+	}
+
+	p.pos++
+	return p.lines[p.pos-1]
+}
+
+func (p *textParser) int(token string, bitSize int) int64 {
+	value, err := strconv.ParseInt(token, 0, bitSize)
+	if err != nil {
+		p.fail("invalid number %q", token)
+	}
+
+	return value
+}
+
+func (p *textParser) uint(token string, bitSize int) uint64 {
+	value, err := strconv.ParseUint(token, 0, bitSize)
+	if err != nil {
+		p.fail("invalid number %q", token)
+	}
+
+	return value
+}
+
+func (p *textParser) index(token string) ConstPoolIndex {
+	if !strings.HasPrefix(token, "#") {
+		p.fail("expected a constant pool index, got %q", token)
+		return 0
+	}
+
+	return ConstPoolIndex(p.uint(token[1:], 16))
+}
+
+func (p *textParser) indexes(tokens []string) []ConstPoolIndex {
+	indexes := make([]ConstPoolIndex, 0, len(tokens))
+	for _, token := range tokens {
+		indexes = append(indexes, p.index(token))
+	}
+
+	return indexes
+}
+
+func (p *textParser) flags(tokens []string, names []flagName) AccessFlags {
+	var flags AccessFlags
+
+tokens:
+	for _, token := range tokens {
+		for _, name := range names {
+			if token == strings.ToLower(strings.TrimPrefix(name.name, "ACC_")) {
+				flags |= name.flag
+				continue tokens
+			}
+		}
+
+		flags |= AccessFlags(p.uint(token, 16))
+	}
+
+	return flags
+}
+
+func (p *textParser) args(tokens []string, count int) bool {
+	if len(tokens) != count {
+		p.fail("%s expects %d arguments, got %d", tokens[0], count-1, len(tokens)-1)
+		return false
+	}
+
+	return true
+}
+
+func (p *textParser) classFile() {
+	c := p.c
+	poolSize := -1
+
+	for tokens := p.next(); tokens != nil; tokens = p.next() {
+		switch tokens[0] {
+		case ".magic":
+			if p.args(tokens, 2) {
+				c.Magic = uint32(p.uint(tokens[1], 32))
+			}
+
+		case ".version":
+			if p.args(tokens, 3) {
+				c.MajorVersion = uint16(p.uint(tokens[1], 16))
+				c.MinorVersion = uint16(p.uint(tokens[2], 16))
+			}
+
+		case ".constpool":
+			if p.args(tokens, 2) {
+				poolSize = int(p.uint(tokens[1], 16))
+			}
+
+		case ".const":
+			p.constant(tokens)
+
+		case ".class":
+			if len(tokens) < 2 {
+				p.fail(".class expects this_class")
+				break
+			}
+
+			c.AccessFlags = p.flags(tokens[1:len(tokens)-1], classFlags)
+			c.ThisClass = p.index(tokens[len(tokens)-1])
+
+		case ".super":
+			if p.args(tokens, 2) {
+				c.SuperClass = p.index(tokens[1])
+			}
+
+		case ".implements":
+			if p.args(tokens, 2) {
+				c.Interfaces = append(c.Interfaces, p.index(tokens[1]))
+			}
+
+		case ".field":
+			fieldMethod := p.fieldMethod(tokens, fieldFlags, ".end field")
+			c.Fields = append(c.Fields, &Field{*fieldMethod})
+
+		case ".method":
+			fieldMethod := p.fieldMethod(tokens, methodFlags, ".end method")
+			c.Methods = append(c.Methods, &Method{*fieldMethod})
+
+		case ".attribute":
+			c.Attributes = append(c.Attributes, p.attribute(tokens))
+
+		default:
+			p.fail("unexpected %q", tokens[0])
+		}
+	}
+
+	// Just like the parser does, keep one more slot than
+	// there are constants (which is always nil).
+	if poolSize < 0 {
+		poolSize = constPoolSize(c.ConstantPool)
+	}
+
+	c.ConstPoolSize = uint16(poolSize)
+	for len(c.ConstantPool) < poolSize {
+		c.ConstantPool = append(c.ConstantPool, nil)
+	}
+}
+
+func (p *textParser) constant(tokens []string) {
+	if len(tokens) < 4 || tokens[2] != "=" {
+		p.fail("expected .const #index = Kind ...")
+		return
+	}
+
+	index := p.index(tokens[1])
+	kind, args := tokens[3], tokens[4:]
+
+	var tag ConstantType
+	for t, name := range constantNames {
+		if name == kind {
+			tag = t
+		}
+	}
+
+	argsCount := map[ConstantType]int{
+		CONSTANT_Class: 1, CONSTANT_String: 1, CONSTANT_Integer: 1, CONSTANT_Float: 1,
+		CONSTANT_Long: 1, CONSTANT_Double: 1, CONSTANT_UTF8: 1, CONSTANT_MethodType: 1,
+		CONSTANT_Module: 1, CONSTANT_Package: 1,
+	}[tag]
+	if argsCount == 0 || tag == CONSTANT_UTF8 && len(args) == 2 && args[0] == "raw" {
+		argsCount = 2
+	}
+
+	if tag == 0 {
+		p.fail("unknown constant kind %q", kind)
+		return
+	}
+
+	if len(args) != argsCount || index == 0 {
+		p.fail("invalid %s constant", kind)
+		return
+	}
+
+	base := baseConstant{Tag: tag}
+	var constant Constant
+
+	switch tag {
+	case CONSTANT_Class:
+		constant = &ClassRef{base, p.index(args[0])}
+	case CONSTANT_FieldRef:
+		constant = &FieldRef{fieldMethodInterfaceRef{base, p.index(args[0]), p.index(args[1])}}
+	case CONSTANT_MethodRef:
+		constant = &MethodRef{fieldMethodInterfaceRef{base, p.index(args[0]), p.index(args[1])}}
+	case CONSTANT_InterfaceMethodRef:
+		constant = &InterfaceMethodRef{fieldMethodInterfaceRef{base, p.index(args[0]), p.index(args[1])}}
+	case CONSTANT_String:
+		constant = &StringRef{base, p.index(args[0])}
+	case CONSTANT_Integer:
+		constant = &IntegerRef{base, int32(p.int(args[0], 32))}
+	case CONSTANT_Float:
+		if strings.HasPrefix(args[0], "nan:") {
+			constant = &FloatRef{base, math.Float32frombits(uint32(p.uint(args[0][4:], 32)))}
+		} else {
+			value, err := strconv.ParseFloat(args[0], 32)
+			if err != nil {
+				p.fail("invalid float %q", args[0])
+			}
+			constant = &FloatRef{base, float32(value)}
+		}
+	case CONSTANT_Long:
+		constant = &LongRef{base, p.int(args[0], 64)}
+	case CONSTANT_Double:
+		if strings.HasPrefix(args[0], "nan:") {
+			constant = &DoubleRef{base, math.Float64frombits(p.uint(args[0][4:], 64))}
+		} else {
+			value, err := strconv.ParseFloat(args[0], 64)
+			if err != nil {
+				p.fail("invalid double %q", args[0])
+			}
+			constant = &DoubleRef{base, value}
+		}
+	case CONSTANT_NameAndType:
+		constant = &NameAndTypeRef{base, p.index(args[0]), p.index(args[1])}
+	case CONSTANT_UTF8:
+		if len(args) == 2 {
+			str := []byte(p.string(args[1]))
+			constant = &UTF8Ref{base, decodeModifiedUTF8(str), str}
+		} else {
+			constant = &UTF8Ref{baseConstant: base, Value: p.string(args[0])}
+		}
+	case CONSTANT_MethodHandle:
+		constant = &MethodHandleRef{base, uint8(p.uint(args[0], 8)), p.index(args[1])}
+	case CONSTANT_MethodType:
+		constant = &MethodTypeRef{base, p.index(args[0])}
+	case CONSTANT_Dynamic:
+		constant = &DynamicRef{base, ConstPoolIndex(p.uint(args[0], 16)), p.index(args[1])}
+	case CONSTANT_InvokeDynamic:
+		constant = &InvokeDynamicRef{base, ConstPoolIndex(p.uint(args[0], 16)), p.index(args[1])}
+	case CONSTANT_Module:
+		constant = &ModuleRef{base, p.index(args[0])}
+	case CONSTANT_Package:
+		constant = &PackageRef{base, p.index(args[0])}
+	}
+
+	for len(p.c.ConstantPool) < int(index) {
+		p.c.ConstantPool = append(p.c.ConstantPool, nil)
+	}
+
+	if p.c.ConstantPool[index-1] != nil {
+		p.fail("constant #%d is defined twice", index)
+	}
+
+	p.c.ConstantPool[index-1] = constant
+}
+
+func (p *textParser) string(token string) string {
+	value, err := strconv.Unquote(token)
+	if err != nil {
+		p.fail("invalid string %s", token)
+	}
+
+	return value
+}
+
+func (p *textParser) fieldMethod(tokens []string, names []flagName, end string) *fieldMethod {
+	fom := &fieldMethod{}
+
+	if len(tokens) < 3 {
+		p.fail("%s expects a name and a descriptor", tokens[0])
+		return fom
+	}
+
+	fom.AccessFlags = p.flags(tokens[1:len(tokens)-2], names)
+	fom.NameIndex = p.index(tokens[len(tokens)-2])
+	fom.DescriptorIndex = p.index(tokens[len(tokens)-1])
+	fom.Attributes = p.attributesUntil(end)
+
+	return fom
+}
+
+// attributesUntil parses .attribute directives up to the end directive.
+func (p *textParser) attributesUntil(end string) Attributes {
+	attrs := Attributes{}
+
+	for tokens := p.next(); tokens != nil; tokens = p.next() {
+		if strings.Join(tokens, " ") == end {
+			return attrs
+		}
+
+		if tokens[0] != ".attribute" {
+			p.fail("expected .attribute or %s, got %q", end, tokens[0])
+			return attrs
+		}
+
+		attrs = append(attrs, p.attribute(tokens))
+	}
+
+	p.fail("missing %s", end)
+	return attrs
+}
+
+// bodyLines returns the lines of a multi-line attribute body.
+func (p *textParser) bodyLines() [][]string {
+	var lines [][]string
+
+	for tokens := p.next(); tokens != nil; tokens = p.next() {
+		if len(tokens) == 2 && tokens[0] == ".end" && tokens[1] == "attribute" {
+			return lines
+		}
+
+		lines = append(lines, tokens)
+	}
+
+	p.fail("missing .end attribute")
+	return lines
+}
+
+func (p *textParser) attribute(tokens []string) Attribute {
+	if len(tokens) < 2 {
+		p.fail(".attribute expects a name index")
+		return &UnknownAttr{}
+	}
+
+	base := baseAttribute{NameIndex: p.index(tokens[1])}
+	args := tokens[2:]
+
+	length := -1
+	if len(args) >= 2 && args[0] == "length" {
+		length = int(p.uint(args[1], 32))
+		args = args[2:]
+	}
+
+	name, _ := p.c.ConstantPool.LookupUTF8(base.NameIndex)
+
+	var attr Attribute
+
+	switch {
+	case len(args) == 2 && args[0] == "raw":
+		attr = p.rawAttribute(base, args[1], length)
+	case len(args) == 1 && args[0] == "raw":
+		attr = p.rawAttribute(base, "", length)
+	default:
+		attr = p.attributeBody(name, base, args)
+	}
+
+	if length >= 0 {
+		attr.(interface{ setLength(uint32) }).setLength(uint32(length))
+	} else if p.err == nil {
+		err := updateLength(attr)
+		if err != nil {
+			p.fail("%s", err)
+		}
+	}
+
+	return attr
+}
+
+func (p *textParser) rawAttribute(base baseAttribute, body string, length int) Attribute {
+	data, err := hex.DecodeString(body)
+	if err != nil {
+		p.fail("invalid hex string")
+	}
+
+	base.Length = uint32(len(data))
+	if length >= 0 {
+		base.Length = uint32(length)
+	}
+
+	// Read the body like the parser would, falling back
+	// to an unknown attribute if that's not possible.
+	if _, err := p.c.ConstantPool.LookupUTF8(base.NameIndex); err == nil {
+		r := bytes.NewReader(data)
+
+		attr, err := fillAttribute(r, base, p.c.ConstantPool)
+		if err == nil && r.Len() == 0 {
+			return attr
+		}
+	}
+
+	return &UnknownAttr{baseAttribute: base, Data: data}
+}
+
+func (p *textParser) attributeBody(name string, base baseAttribute, args []string) Attribute {
+	switch name {
+	case "ConstantValue":
+		if len(args) == 1 {
+			return &ConstantValue{base, p.index(args[0])}
+		}
+
+	case "Code":
+		if len(args) == 4 && args[0] == "stack" && args[2] == "locals" {
+			return p.code(base, args)
+		}
+
+	case "Exceptions":
+		return &Exceptions{base, p.indexes(args)}
+
+	case "InnerClasses":
+		if len(args) == 0 {
+			attr := &InnerClasses{baseAttribute: base, Classes: []InnerClass{}}
+			for _, line := range p.bodyLines() {
+				if len(line) < 3 {
+					p.fail("invalid inner class")
+					continue
+				}
+
+				attr.Classes = append(attr.Classes, InnerClass{
+					InnerClassIndex:  p.index(line[0]),
+					OuterClassIndex:  p.index(line[1]),
+					InnerName:        p.index(line[2]),
+					InnerAccessFlags: p.flags(line[3:], nestedClassFlags),
+				})
+			}
+
+			return attr
+		}
+
+	case "EnclosingMethod":
+		if len(args) == 2 {
+			return &EnclosingMethod{base, p.index(args[0]), p.index(args[1])}
+		}
+
+	case "Synthetic":
+		if len(args) == 0 {
+			return &Synthetic{base}
+		}
+
+	case "Deprecated":
+		if len(args) == 0 {
+			return &Deprecated{base}
+		}
+
+	case "Signature":
+		if len(args) == 1 {
+			return &Signature{base, p.index(args[0])}
+		}
+
+	case "SourceFile":
+		if len(args) == 1 {
+			return &SourceFile{base, p.index(args[0])}
+		}
+
+	case "LineNumberTable":
+		if len(args) == 0 {
+			attr := &LineNumberTable{baseAttribute: base, Table: []LineNumber{}}
+			for _, line := range p.bodyLines() {
+				if len(line) != 2 {
+					p.fail("invalid line number")
+					continue
+				}
+
+				n := len(attr.Table)
+				attr.Table = append(attr.Table, LineNumber{LineNumber: uint16(p.uint(line[1], 16))})
+				p.pc(line[0], func(pc int) { attr.Table[n].StartPC = uint16(pc) })
+			}
+
+			return attr
+		}
+
+	case "LocalVariableTable":
+		if len(args) == 0 {
+			attr := &LocalVariableTable{baseAttribute: base, Table: []LocalVariable{}}
+			for _, line := range p.bodyLines() {
+				if len(line) != 5 {
+					p.fail("invalid local variable")
+					continue
+				}
+
+				n := len(attr.Table)
+				attr.Table = append(attr.Table, LocalVariable{
+					Length:          uint16(p.uint(line[1], 16)),
+					NameIndex:       p.index(line[2]),
+					DescriptorIndex: p.index(line[3]),
+					Index:           uint16(p.uint(line[4], 16)),
+				})
+				p.pc(line[0], func(pc int) { attr.Table[n].StartPC = uint16(pc) })
+			}
+
+			return attr
+		}
+
+	case "LocalVariableTypeTable":
+		if len(args) == 0 {
+			attr := &LocalVariableTypeTable{baseAttribute: base, Table: []LocalVariableType{}}
+			for _, line := range p.bodyLines() {
+				if len(line) != 5 {
+					p.fail("invalid local variable type")
+					continue
+				}
+
+				n := len(attr.Table)
+				attr.Table = append(attr.Table, LocalVariableType{
+					Length:         uint16(p.uint(line[1], 16)),
+					NameIndex:      p.index(line[2]),
+					SignatureIndex: p.index(line[3]),
+					Index:          uint16(p.uint(line[4], 16)),
+				})
+				p.pc(line[0], func(pc int) { attr.Table[n].StartPC = uint16(pc) })
+			}
+
+			return attr
+		}
+
+	case "BootstrapMethods":
+		if len(args) == 0 {
+			attr := &BootstrapMethods{baseAttribute: base, Methods: []BootstrapMethod{}}
+			for _, line := range p.bodyLines() {
+				indexes := p.indexes(line)
+				attr.Methods = append(attr.Methods, BootstrapMethod{MethodRef: indexes[0], Args: indexes[1:]})
+			}
+
+			return attr
+		}
+
+	case "NestHost":
+		if len(args) == 1 {
+			return &NestHost{base, p.index(args[0])}
+		}
+
+	case "NestMembers":
+		return &NestMembers{base, p.indexes(args)}
+
+	case "PermittedSubclasses":
+		return &PermittedSubclasses{base, p.indexes(args)}
+
+	case "ModulePackages":
+		return &ModulePackages{base, p.indexes(args)}
+
+	case "ModuleMainClass":
+		if len(args) == 1 {
+			return &ModuleMainClass{base, p.index(args[0])}
+		}
+
+	case "MethodParameters":
+		if len(args) == 0 {
+			attr := &MethodParameters{baseAttribute: base, Parameters: []MethodParameter{}}
+			for _, line := range p.bodyLines() {
+				attr.Parameters = append(attr.Parameters, MethodParameter{
+					NameIndex:   p.index(line[0]),
+					AccessFlags: p.flags(line[1:], parameterFlags),
+				})
+			}
+
+			return attr
+		}
+	}
+
+	p.fail("invalid body of attribute %q", name)
+	return &UnknownAttr{baseAttribute: base}
+}
+
+// pc parses a code position (a label or a number) and calls set
+// with it, once the position is known.
+func (p *textParser) pc(token string, set func(pc int)) {
+	if !strings.HasPrefix(token, "L") {
+		pc := int(p.uint(token, 16))
+
+		// Inside of code, offsets can only be computed
+		// after the layout of the instructions.
+		if p.labels != nil {
+			p.fixups = append(p.fixups, func() { set(pc) })
+		} else {
+			set(pc)
+		}
+
+		return
+	}
+
+	if p.labels == nil {
+		p.fail("label %s used outside of code", token)
+		return
+	}
+
+	label := p.label(token)
+	p.fixups = append(p.fixups, func() { set(label.PC) })
+}
+
+func (p *textParser) label(name string) *Label {
+	label, ok := p.labels[name]
+	if !ok {
+		label = &Label{}
+		p.labels[name] = label
+	}
+
+	return label
+}
+
+func (p *textParser) code(base baseAttribute, args []string) *Code {
+	code := &Code{
+		baseAttribute:   base,
+		MaxStackSize:    uint16(p.uint(args[1], 16)),
+		MaxLocalsCount:  uint16(p.uint(args[3], 16)),
+		ByteCode:        []uint8{},
+		ExceptionsTable: []CodeException{},
+		Attributes:      Attributes{},
+	}
+
+	p.labels, p.placed, p.fixups = map[string]*Label{}, map[*Label]bool{}, nil
+	defer func() { p.labels, p.placed, p.fixups = nil, nil, nil }()
+
+	var elems []CodeElement
+	var insts []*Instruction
+	var raw []uint8
+
+	for tokens := p.next(); tokens != nil; tokens = p.next() {
+		switch {
+		case len(tokens) == 2 && tokens[0] == ".end" && tokens[1] == "attribute":
+			if raw != nil && len(insts) > 0 {
+				p.fail("code has both bytecode and instructions")
+			}
+
+			pc := 0
+			for _, elem := range elems {
+				switch elem := elem.(type) {
+				case *Instruction:
+					elem.PC = pc
+					pc += elem.Size()
+				case *Label:
+					elem.PC = pc
+				}
+			}
+
+			for name, label := range p.labels {
+				if !p.placed[label] {
+					p.fail("undefined label %s", name)
+				}
+			}
+
+			for _, fixup := range p.fixups {
+				fixup()
+			}
+
+			if raw != nil {
+				code.ByteCode = raw
+			} else {
+				encoded, err := EncodeInstructions(insts)
+				if err != nil {
+					p.fail("%s", err)
+				}
+				code.ByteCode = encoded
+			}
+
+			return code
+
+		case len(tokens) == 1 && strings.HasSuffix(tokens[0], ":"):
+			label := p.label(strings.TrimSuffix(tokens[0], ":"))
+			if p.placed[label] {
+				p.fail("label %s is defined twice", tokens[0])
+			}
+
+			p.placed[label] = true
+			elems = append(elems, label)
+
+		case tokens[0] == "bytecode":
+			if p.args(tokens, 2) {
+				var err error
+				raw, err = hex.DecodeString(tokens[1])
+				if err != nil {
+					p.fail("invalid hex string")
+				}
+			}
+
+		case tokens[0] == ".catch":
+			if p.args(tokens, 5) {
+				n := len(code.ExceptionsTable)
+				code.ExceptionsTable = append(code.ExceptionsTable, CodeException{CatchType: p.index(tokens[4])})
+				p.pc(tokens[1], func(pc int) { code.ExceptionsTable[n].StartPC = uint16(pc) })
+				p.pc(tokens[2], func(pc int) { code.ExceptionsTable[n].EndPC = uint16(pc) })
+				p.pc(tokens[3], func(pc int) { code.ExceptionsTable[n].HandlerPC = uint16(pc) })
+			}
+
+		case tokens[0] == ".attribute":
+			code.Attributes = append(code.Attributes, p.attribute(tokens))
+
+		default:
+			inst := p.instruction(tokens)
+			insts = append(insts, inst)
+			elems = append(elems, inst)
+		}
+	}
+
+	p.fail("missing .end attribute")
+	return code
+}
+
+func (p *textParser) instruction(tokens []string) *Instruction {
+	inst := &Instruction{}
+
+	if tokens[0] == "wide" {
+		inst.Wide = true
+		tokens = tokens[1:]
+
+		if len(tokens) == 0 {
+			p.fail("wide without instruction")
+			return inst
+		}
+	}
+
+	op, ok := mnemonics[tokens[0]]
+	if !ok {
+		p.fail("unknown instruction %q", tokens[0])
+		return inst
+	}
+
+	inst.Opcode = op
+	args := tokens[1:]
+
+	// branch sets the offset relative to the instruction,
+	// which may also be given directly (e.g. "+-100").
+	branch := func(token string, offset *int32) {
+		if strings.HasPrefix(token, "+") {
+			*offset = int32(p.int(token[1:], 32))
+			return
+		}
+
+		p.pc(token, func(pc int) { *offset = int32(pc - inst.PC) })
+	}
+
+	switch opcodes[op].format {
+	case operandNone:
+		p.args(tokens, 1)
+
+	case operandByte, operandShort:
+		if p.args(tokens, 2) {
+			inst.Const = int32(p.int(args[0], 32))
+		}
+
+	case operandConstPoolByte, operandConstPool, operandInvokeDynamic:
+		if p.args(tokens, 2) {
+			inst.Index = p.index(args[0])
+		}
+
+	case operandLocal:
+		if p.args(tokens, 2) {
+			inst.Local = uint16(p.uint(args[0], 16))
+		}
+
+	case operandIinc:
+		if p.args(tokens, 3) {
+			inst.Local = uint16(p.uint(args[0], 16))
+			inst.Const = int32(p.int(args[1], 32))
+		}
+
+	case operandBranch, operandBranchWide:
+		if p.args(tokens, 2) {
+			branch(args[0], &inst.Offset)
+		}
+
+	case operandTableSwitch:
+		if len(args) < 3 || args[len(args)-2] != "default" {
+			p.fail("invalid tableswitch")
+			break
+		}
+
+		inst.Low = int32(p.int(args[0], 32))
+		targets := args[1 : len(args)-2]
+
+		inst.Cases = make([]SwitchCase, len(targets))
+		for n, target := range targets {
+			inst.Cases[n].Match = inst.Low + int32(n)
+			branch(target, &inst.Cases[n].Offset)
+		}
+		branch(args[len(args)-1], &inst.Offset)
+
+	case operandLookupSwitch:
+		if len(args) < 2 || len(args)%2 != 0 || args[len(args)-2] != "default" {
+			p.fail("invalid lookupswitch")
+			break
+		}
+
+		pairs := args[:len(args)-2]
+
+		inst.Cases = make([]SwitchCase, len(pairs)/2)
+		for n := range inst.Cases {
+			inst.Cases[n].Match = int32(p.int(pairs[2*n], 32))
+			branch(pairs[2*n+1], &inst.Cases[n].Offset)
+		}
+		branch(args[len(args)-1], &inst.Offset)
+
+	case operandInvokeInterface, operandMultiANewArray:
+		if p.args(tokens, 3) {
+			inst.Index = p.index(args[0])
+			inst.Const = int32(p.uint(args[1], 8))
+		}
+
+	case operandNewArray:
+		if p.args(tokens, 2) {
+			for atype, name := range arrayTypeNames {
+				if name == args[0] {
+					inst.Const = atype
+					return inst
+				}
+			}
+
+			inst.Const = int32(p.uint(args[0], 8))
+		}
+
+	case operandWide:
+		p.fail("wide has to prefix an instruction")
+	}
+
+	return inst
+}
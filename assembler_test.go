@@ -0,0 +1,283 @@
+package class
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func writeAssembly(t *testing.T, c *ClassFile) string {
+	var buf bytes.Buffer
+	if err := c.WriteAssembly(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
+
+func assemble(t *testing.T, text string) *ClassFile {
+	c, err := Assemble(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func TestWriteAssemblyRoundTrip(t *testing.T) {
+	data, c := readHelloWorld(t)
+	text := writeAssembly(t, c)
+
+	assembled := assemble(t, text)
+	if got := dump(t, assembled); !bytes.Equal(got, data) {
+		t.Error("assembled class differs from the parsed one")
+	}
+
+	if got := writeAssembly(t, assembled); got != text {
+		t.Errorf("assembly of the assembled class differs:\n%s", got)
+	}
+}
+
+func TestAssembleRawAttribute(t *testing.T) {
+	_, c := readHelloWorld(t)
+	text := writeAssembly(t, c)
+
+	// HelloWorld has no dedicated syntax for annotations
+	if !strings.Contains(text, ".attribute #29 raw 0001001e0000") {
+		t.Fatalf("annotations not written raw:\n%s", text)
+	}
+
+	tests := []struct {
+		name   string
+		line   string
+		check  func(attr Attribute) bool
+		length uint32
+	}{
+		{
+			"known attribute",
+			".attribute #31 raw 0020", // SourceFile #32
+			func(attr Attribute) bool {
+				sourceFile, ok := attr.(*SourceFile)
+				return ok && sourceFile.SourceFileIndex == 32
+			},
+			2,
+		},
+		{
+			"unknown attribute",
+			".attribute #41 raw 0102ff", // HelloWorld
+			func(attr Attribute) bool {
+				unknown, ok := attr.(*UnknownAttr)
+				return ok && bytes.Equal(unknown.Data, []byte{1, 2, 0xff})
+			},
+			3,
+		},
+		{
+			"empty body",
+			".attribute #41 raw",
+			func(attr Attribute) bool {
+				unknown, ok := attr.(*UnknownAttr)
+				return ok && len(unknown.Data) == 0
+			},
+			0,
+		},
+		{
+			"invalid body of known attribute",
+			".attribute #31 raw 00", // SourceFile with a truncated index
+			func(attr Attribute) bool {
+				unknown, ok := attr.(*UnknownAttr)
+				return ok && bytes.Equal(unknown.Data, []byte{0})
+			},
+			1,
+		},
+		{
+			"explicit length",
+			".attribute #31 length 7 raw 0020",
+			func(attr Attribute) bool {
+				_, ok := attr.(*SourceFile)
+				return ok
+			},
+			7,
+		},
+	}
+
+	for _, test := range tests {
+		assembled := assemble(t, strings.Replace(text, ".attribute #31 #32", test.line, 1))
+
+		attr := assembled.Attributes[0]
+		if !test.check(attr) {
+			t.Errorf("%s: got %#v", test.name, attr)
+		}
+
+		if length := byteOrder.Uint32(dump(t, attr)[2:]); length != test.length {
+			t.Errorf("%s: got length %d, expected %d", test.name, length, test.length)
+		}
+	}
+
+	method := assemble(t, text).Methods[2]
+	if _, ok := method.Attributes[2].(*RuntimeVisibleAnnotations); !ok {
+		t.Errorf("got %#v", method.Attributes[2])
+	}
+}
+
+func TestAssembleRawUTF8(t *testing.T) {
+	_, c := readHelloWorld(t)
+	text := writeAssembly(t, c)
+
+	// #49 is the unused last slot of HelloWorld's constant pool
+	text += ".const #49 = Utf8 raw \"a\\xffb\\xc0\\x80\"\n"
+
+	assembled := assemble(t, text)
+
+	utf8 := assembled.ConstantPool[48].(*UTF8Ref)
+	if !bytes.Equal(utf8.Raw, []byte("a\xffb\xc0\x80")) {
+		t.Errorf("got raw bytes %q", utf8.Raw)
+	}
+
+	parsed, err := Parse(bytes.NewReader(dump(t, assembled)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := parsed.ConstantPool[48].(*UTF8Ref); !bytes.Equal(got.Raw, utf8.Raw) || got.Value != utf8.Value {
+		t.Errorf("got %q (%q), expected %q (%q)", got.Value, got.Raw, utf8.Value, utf8.Raw)
+	}
+
+	if !strings.Contains(writeAssembly(t, parsed), `.const #49 = Utf8 raw "a\xffb\xc0\x80"`) {
+		t.Error("invalid Utf8 constant not written raw")
+	}
+
+	// Valid Modified UTF-8 is written as a plain string
+	valid := assemble(t, strings.Replace(text, `"a\xffb\xc0\x80"`, `"a\xc0\x80"`, 1))
+	if got := writeAssembly(t, valid); !strings.Contains(got, `.const #49 = Utf8 "a\x00"`) {
+		t.Errorf("valid Utf8 constant written as:\n%s", got)
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	_, c := readHelloWorld(t)
+	text := writeAssembly(t, c)
+
+	tests := []struct {
+		name, old, new, err string
+	}{
+		{"unknown constant kind", "= Long 2", "= Quad 2", `unknown constant kind "Quad"`},
+		{"unknown directive", ".super #10", ".superclass #10", `unexpected ".superclass"`},
+		{"unknown attribute", ".attribute #31 #32", ".attribute #41 #32", `invalid body of attribute "HelloWorld"`},
+		{"unknown instruction", "areturn", "areturn2", "areturn2"},
+		{"duplicate constant", ".const #4 =", ".const #1 =", "constant #1 is defined twice"},
+		{"invalid hex", ".attribute #29 raw 0001001e0000", ".attribute #29 raw 0001001e000", "invalid hex string"},
+		{"unterminated string", `Utf8 "main"`, `Utf8 "main`, "line"},
+	}
+
+	for _, test := range tests {
+		if !strings.Contains(text, test.old) {
+			t.Fatalf("%s: %q not in assembly", test.name, test.old)
+		}
+
+		_, err := Assemble(strings.NewReader(strings.Replace(text, test.old, test.new, 1)))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got %v, expected %q", test.name, err, test.err)
+		}
+	}
+}
+
+// assemblyRoundTrip writes the assembly of the class in data,
+// assembles it and checks, that it dumps to the same bytes.
+func assemblyRoundTrip(t *testing.T, name string, data []byte) string {
+	c, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+
+	text := writeAssembly(t, c)
+
+	assembled, err := Assemble(strings.NewReader(text))
+	if err != nil {
+		t.Errorf("%s: %v", name, err)
+		return text
+	}
+
+	var buf bytes.Buffer
+	if err := assembled.DumpOriginal(&buf); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("%s: assembled class differs from the parsed one", name)
+	}
+
+	return text
+}
+
+func TestAssemblyControlCharacters(t *testing.T) {
+	values := []string{
+		"Ljava/util/List<Ljava/lang/\nInteger;>;",
+		"Ljava/util/List<Ljava/lang/\rInteger;>;",
+		"Ljava/util/List<Ljava/lang/\x00Integer;>;",
+		"Ljava/util/List<Ljava/lang/\x7f\u0085Integer;>;",
+	}
+
+	for _, value := range values {
+		_, c := readHelloWorld(t)
+
+		// The Signature of myList, which is written in comments
+		c.ConstantPool[18] = &UTF8Ref{baseConstant{CONSTANT_UTF8}, value, nil}
+
+		text := assemblyRoundTrip(t, strconv.Quote(value), dump(t, c))
+		if strings.Count(text, "Ljava/lang/") != strings.Count(writeAssembly(t, c), "Ljava/lang/") {
+			t.Errorf("%q: constant split", value)
+		}
+	}
+}
+
+func TestAssemblyBranchOutOfRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     []uint8
+		operands string
+	}{
+		{"negative", []uint8{uint8(GOTO), 0xff, 0x9c}, "goto +-100"},
+		{"above 65535", []uint8{uint8(JSR_W), 0x00, 0x01, 0x2a, 0x0d}, "jsr_w +76301"},
+		{"negative wide", []uint8{uint8(GOTO_W), 0xff, 0xff, 0xff, 0xf0}, "goto_w +-16"},
+		{"in range", []uint8{uint8(GOTO), 0x7f, 0x00}, "goto 32520"},
+		{
+			"switch",
+			// padding to pc 12, default -12, low 0, high 1
+			[]uint8{uint8(TABLESWITCH), 0, 0, 0, 0xff, 0xff, 0xff, 0xf4, 0, 0, 0, 0, 0, 0, 0, 1,
+				0, 0, 0, 0, 0, 1, 0, 0},
+			"tableswitch 0 L8 +65536 default +-12",
+		},
+	}
+
+	for _, test := range tests {
+		_, c := readHelloWorld(t)
+
+		// Replace the return at pc 8 of main
+		code := c.Methods[1].Code()
+		code.ByteCode = append(code.ByteCode[:8:8], test.code...)
+
+		text := assemblyRoundTrip(t, test.name, dump(t, c))
+		if !strings.Contains(text, test.operands) {
+			t.Errorf("%s: %q not in assembly:\n%s", test.name, test.operands, text)
+		}
+	}
+}
+
+func TestAssembleRelativeBranch(t *testing.T) {
+	_, c := readHelloWorld(t)
+	text := strings.Replace(writeAssembly(t, c), "areturn", "goto +-3\n      goto_w +70000", 1)
+
+	insts, err := assemble(t, text).Methods[2].Code().Instructions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if insts[1].Opcode != GOTO || insts[1].Offset != -3 {
+		t.Errorf("got %s %d", insts[1].Mnemonic(), insts[1].Offset)
+	}
+
+	if insts[2].Opcode != GOTO_W || insts[2].Offset != 70000 {
+		t.Errorf("got %s %d", insts[2].Mnemonic(), insts[2].Offset)
+	}
+}
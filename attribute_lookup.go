@@ -0,0 +1,109 @@
+package class
+
+import (
+	"errors"
+	"fmt"
+)
+
+// multipleAttributes are the attributes, of which an attributes
+// table may contain more than one. Of all others there may be at
+// most one, duplicates are rejected when parsing (but not by
+// Assemble). Unknown attributes can't be checked and Synthetic
+// and Deprecated don't carry data.
+var multipleAttributes = map[AttributeType]bool{
+	UnknownTag:                true,
+	SyntheticTag:              true,
+	DeprecatedTag:             true,
+	LineNumberTableTag:        true,
+	LocalVariableTableTag:     true,
+	LocalVariableTypeTableTag: true,
+}
+
+// First returns the first attribute with the given tag, or nil.
+func (attrs Attributes) First(tag AttributeType) Attribute {
+	for _, attr := range attrs {
+		if attr.GetTag() == tag {
+			return attr
+		}
+	}
+
+	return nil
+}
+
+// All returns all attributes with the given tag, in order.
+func (attrs Attributes) All(tag AttributeType) Attributes {
+	var found Attributes
+
+	for _, attr := range attrs {
+		if attr.GetTag() == tag {
+			found = append(found, attr)
+		}
+	}
+
+	return found
+}
+
+// Find returns the first attribute of type T, or the zero value
+// (i.e. nil) if there is none, e.g. Find[*Signature](m.Attributes).
+func Find[T Attribute](attrs Attributes) T {
+	for _, attr := range attrs {
+		if found, ok := attr.(T); ok {
+			return found
+		}
+	}
+
+	var zero T
+	return zero
+}
+
+// AttributeError reports an attribute of another type than expected.
+type AttributeError struct {
+	Expected AttributeType
+	Actual   AttributeType
+}
+
+func (e *AttributeError) Error() string {
+	return fmt.Sprintf("jclass: attribute is %s, not %s", e.Actual, e.Expected)
+}
+
+// As returns attr as T, e.g. As[*Code](attr). Unlike the type
+// accessors of Attribute (e.g. attr.Code()), it doesn't panic
+// but returns an *AttributeError, if attr isn't a T.
+func As[T Attribute](attr Attribute) (T, error) {
+	var zero T
+
+	if attr == nil {
+		return zero, errors.New("jclass: attribute is nil")
+	}
+
+	found, ok := attr.(T)
+	if !ok {
+		return zero, &AttributeError{Expected: zero.GetTag(), Actual: attr.GetTag()}
+	}
+
+	return found, nil
+}
+
+// Code returns the method's Code attribute, nil
+// for abstract and native methods.
+func (m *Method) Code() *Code {
+	return Find[*Code](m.Attributes)
+}
+
+// ConstantValue returns the field's ConstantValue
+// attribute, or nil if it has none.
+func (f *Field) ConstantValue() *ConstantValue {
+	return Find[*ConstantValue](f.Attributes)
+}
+
+// SourceFile returns the class' SourceFile
+// attribute, or nil if it has none.
+func (c *ClassFile) SourceFile() *SourceFile {
+	return Find[*SourceFile](c.Attributes)
+}
+
+// BootstrapMethods returns the class' BootstrapMethods
+// attribute, or nil if it has none.
+func (c *ClassFile) BootstrapMethods() *BootstrapMethods {
+	return Find[*BootstrapMethods](c.Attributes)
+}
@@ -0,0 +1,121 @@
+package class
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDuplicateAttributes(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *ClassFile)
+		path   string // "" if valid
+	}{
+		{
+			"SourceFile",
+			func(c *ClassFile) {
+				c.Attributes = append(c.Attributes, &SourceFile{baseAttribute{NameIndex: 31}, 32})
+			},
+			"attributes[1]",
+		},
+		{
+			"Signature",
+			func(c *ClassFile) {
+				field := c.Fields[2]
+				field.Attributes = append(field.Attributes, &Signature{baseAttribute{NameIndex: 18}, 19})
+			},
+			"fields[2].attributes[1]",
+		},
+		{
+			"Code",
+			func(c *ClassFile) {
+				method := c.Methods[1]
+				method.Attributes = append(method.Attributes, method.Attributes[0])
+			},
+			"methods[1].attributes[1]",
+		},
+		{
+			"LineNumberTable",
+			func(c *ClassFile) {
+				code := c.Methods[1].Code()
+				code.Attributes = append(code.Attributes, &LineNumberTable{baseAttribute{NameIndex: 23}, []LineNumber{{8, 11}}})
+			},
+			"",
+		},
+		{
+			"Deprecated",
+			func(c *ClassFile) {
+				method := c.Methods[2]
+				method.Attributes = append(method.Attributes, &Deprecated{baseAttribute{NameIndex: 28}})
+			},
+			"",
+		},
+		{
+			"unknown",
+			func(c *ClassFile) {
+				// The name of the class isn't an attribute name
+				c.Attributes = append(c.Attributes,
+					&UnknownAttr{baseAttribute{NameIndex: 41}, []uint8{1}},
+					&UnknownAttr{baseAttribute{NameIndex: 41}, []uint8{2}})
+			},
+			"",
+		},
+	}
+
+	for _, test := range tests {
+		_, c := readHelloWorld(t)
+		test.modify(c)
+
+		parsed, err := Parse(bytes.NewReader(dump(t, c)))
+
+		if test.path == "" {
+			if err != nil {
+				t.Errorf("%s: %v", test.name, err)
+			} else if !bytes.Equal(dump(t, parsed), dump(t, c)) {
+				t.Errorf("%s: parsed class differs", test.name)
+			}
+			continue
+		}
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || parseErr.Path != test.path {
+			t.Errorf("%s: got %v", test.name, err)
+		}
+
+		// Such files can still be assembled
+		if assembled := assemble(t, writeAssembly(t, c)); !bytes.Equal(dump(t, assembled), dump(t, c)) {
+			t.Errorf("%s: assembled class differs", test.name)
+		}
+	}
+}
+
+func TestFindAttributes(t *testing.T) {
+	_, c := readHelloWorld(t)
+
+	code := c.Methods[1].Code()
+	if code == nil || code != c.Methods[1].Attributes.First(CodeTag) {
+		t.Errorf("got Code %v", code)
+	}
+
+	if c.Fields[0].ConstantValue() != nil || c.Fields[1].ConstantValue() == nil {
+		t.Error("wrong ConstantValue")
+	}
+
+	if sourceFile := c.SourceFile(); sourceFile == nil || sourceFile.SourceFileIndex != 32 {
+		t.Errorf("got SourceFile %v", sourceFile)
+	}
+
+	if c.BootstrapMethods() != nil || Find[*Signature](c.Methods[0].Attributes) != nil {
+		t.Error("found missing attribute")
+	}
+
+	attrs := c.Methods[2].Attributes
+	if all := attrs.All(DeprecatedTag); len(all) != 1 || all[0] != attrs[1] {
+		t.Errorf("got %v", all)
+	}
+
+	if all := attrs.All(SignatureTag); len(all) != 0 {
+		t.Errorf("got %v", all)
+	}
+}
@@ -1,7 +1,9 @@
 package class
 
 import (
+	"bytes"
 	"encoding/binary"
+	"fmt"
 	"io"
 )
 
@@ -13,13 +15,29 @@ func readAttributes(r io.Reader, constPool ConstantPool) (Attributes, error) {
 	}
 
 	attrs := make(Attributes, 0, count)
+	seen := make(map[AttributeType]bool)
 
 	for i := uint16(0); i < count; i++ {
+		offset := bytesRead(r)
+
 		attr, err := readAttribute(r, constPool)
 		if err != nil {
+			// Unless the attribute's name is known
+			// use its position for the path.
+			if _, ok := err.(*ParseError); !ok {
+				err = newParseError(err, fmt.Sprintf("attributes[%d]", i), offset)
+			}
+
 			return nil, err
 		}
 
+		tag := attr.GetTag()
+		if seen[tag] && !multipleAttributes[tag] {
+			err = fmt.Errorf("jclass: duplicate %s attribute", tag)
+			return nil, newParseError(err, fmt.Sprintf("attributes[%d]", i), offset)
+		}
+		seen[tag] = true
+
 		attrs = append(attrs, attr)
 	}
 
@@ -42,8 +60,56 @@ func writeAttributes(w io.Writer, attrs Attributes) error {
 	return nil
 }
 
+func (a *baseAttribute) setLength(length uint32) { a.Length = length }
+
+// updateLength sets the stored length of attr to the length
+// of its body, as it is dumped.
+func updateLength(attr Attribute) error {
+	var buf bytes.Buffer
+
+	err := attr.Dump(&buf)
+	if err != nil {
+		return err
+	}
+
+	// attribute_name_index and attribute_length
+	// aren't included in the length
+	attr.(interface{ setLength(uint32) }).setLength(uint32(buf.Len() - 6))
+	return nil
+}
+
+// updateLengths sets the stored length of all attrs (and
+// the attributes nested in them) to the length of their body.
+func updateLengths(attrs Attributes) error {
+	for _, attr := range attrs {
+		var err error
+
+		switch attr := attr.(type) {
+		case *Code:
+			err = updateLengths(attr.Attributes)
+		case *Record:
+			for _, component := range attr.Components {
+				if err == nil {
+					err = updateLengths(component.Attributes)
+				}
+			}
+		}
+
+		if err == nil {
+			err = updateLength(attr)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func readAttribute(r io.Reader, constPool ConstantPool) (Attribute, error) {
 	attrBase := baseAttribute{}
+	offset := bytesRead(r)
 
 	err := multiError([]error{
 		binary.Read(r, byteOrder, &attrBase.NameIndex),
@@ -54,20 +120,34 @@ func readAttribute(r io.Reader, constPool ConstantPool) (Attribute, error) {
 		return nil, err
 	}
 
-	return fillAttribute(r, attrBase, constPool)
+	name, err := constPool.LookupUTF8(attrBase.NameIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	attr, err := fillAttribute(r, attrBase, constPool)
+	if err != nil {
+		return nil, newParseError(err, "attributes["+name+"]", offset)
+	}
+
+	return attr, nil
 }
 
 func fillAttribute(r io.Reader, attrBase baseAttribute, constPool ConstantPool) (Attribute, error) {
 	var attr Attribute
-	name := constPool.GetUTF8(attrBase.NameIndex)
+
+	name, err := constPool.LookupUTF8(attrBase.NameIndex)
+	if err != nil {
+		return nil, err
+	}
 
 	switch name {
 	case "ConstantValue":
 		attr = &ConstantValue{baseAttribute: attrBase}
 	case "Code":
 		attr = &Code{baseAttribute: attrBase}
-	// case "StackMapTable":
-	//     attr = &StackMapTable{baseAttribute: attrBase}
+	case "StackMapTable":
+		attr = &StackMapTable{baseAttribute: attrBase}
 	case "Exceptions":
 		attr = &Exceptions{baseAttribute: attrBase}
 	case "InnerClasses":
@@ -90,23 +170,43 @@ func fillAttribute(r io.Reader, attrBase baseAttribute, constPool ConstantPool)
 		attr = &LocalVariableTypeTable{baseAttribute: attrBase}
 	case "Deprecated":
 		attr = &Deprecated{baseAttribute: attrBase}
-	// case "RuntimeVisibleAnnotations":
-	// 	attr = &RuntimeVisibleAnnotations{baseAttribute: attrBase}
-	// case "RuntimeInvisibleAnnotations":
-	// 	attr = &RuntimeInvisibleAnnotations{baseAttribute: attrBase}
-	// case "RuntimeVisibleParameterAnnotations":
-	// 	attr = &RuntimeVisibleParameterAnnotations{baseAttribute: attrBase}
-	// case "RuntimeInvisibleParameterAnnotations":
-	// 	attr = &RuntimeInvisibleParameterAnnotations{baseAttribute: attrBase}
-	// case "AnnotationDefault":
-	// 	attr = &AnnotationDefault{baseAttribute: attrBase}
+	case "RuntimeVisibleAnnotations":
+		attr = &RuntimeVisibleAnnotations{baseAttribute: attrBase}
+	case "RuntimeInvisibleAnnotations":
+		attr = &RuntimeInvisibleAnnotations{baseAttribute: attrBase}
+	case "RuntimeVisibleParameterAnnotations":
+		attr = &RuntimeVisibleParameterAnnotations{baseAttribute: attrBase}
+	case "RuntimeInvisibleParameterAnnotations":
+		attr = &RuntimeInvisibleParameterAnnotations{baseAttribute: attrBase}
+	case "AnnotationDefault":
+		attr = &AnnotationDefault{baseAttribute: attrBase}
 	case "BootstrapMethods":
 		attr = &BootstrapMethods{baseAttribute: attrBase}
+	case "RuntimeVisibleTypeAnnotations":
+		attr = &RuntimeVisibleTypeAnnotations{baseAttribute: attrBase}
+	case "RuntimeInvisibleTypeAnnotations":
+		attr = &RuntimeInvisibleTypeAnnotations{baseAttribute: attrBase}
+	case "Module":
+		attr = &Module{baseAttribute: attrBase}
+	case "ModulePackages":
+		attr = &ModulePackages{baseAttribute: attrBase}
+	case "ModuleMainClass":
+		attr = &ModuleMainClass{baseAttribute: attrBase}
+	case "NestHost":
+		attr = &NestHost{baseAttribute: attrBase}
+	case "NestMembers":
+		attr = &NestMembers{baseAttribute: attrBase}
+	case "Record":
+		attr = &Record{baseAttribute: attrBase}
+	case "PermittedSubclasses":
+		attr = &PermittedSubclasses{baseAttribute: attrBase}
+	case "MethodParameters":
+		attr = &MethodParameters{baseAttribute: attrBase}
 	default:
 		attr = &UnknownAttr{baseAttribute: attrBase}
 	}
 
-	err := attr.Read(r, constPool)
+	err = attr.Read(r, constPool)
 	if err != nil {
 		return nil, err
 	}
@@ -116,6 +216,48 @@ func fillAttribute(r io.Reader, attrBase baseAttribute, constPool ConstantPool)
 
 type AttributeType uint8
 
+var attributeNames = map[AttributeType]string{
+	UnknownTag:                              "Unknown",
+	ConstantValueTag:                        "ConstantValue",
+	CodeTag:                                 "Code",
+	StackMapTableTag:                        "StackMapTable",
+	ExceptionsTag:                           "Exceptions",
+	InnerClassesTag:                         "InnerClasses",
+	EnclosingMethodTag:                      "EnclosingMethod",
+	SyntheticTag:                            "Synthetic",
+	SignatureTag:                            "Signature",
+	SourceFileTag:                           "SourceFile",
+	SourceDebugExtensionTag:                 "SourceDebugExtension",
+	LineNumberTableTag:                      "LineNumberTable",
+	LocalVariableTableTag:                   "LocalVariableTable",
+	LocalVariableTypeTableTag:               "LocalVariableTypeTable",
+	DeprecatedTag:                           "Deprecated",
+	RuntimeVisibleAnnotationsTag:            "RuntimeVisibleAnnotations",
+	RuntimeInvisibleAnnotationsTag:          "RuntimeInvisibleAnnotations",
+	RuntimeVisibleParameterAnnotationsTag:   "RuntimeVisibleParameterAnnotations",
+	RuntimeInvisibleParameterAnnotationsTag: "RuntimeInvisibleParameterAnnotations",
+	AnnotationDefaultTag:                    "AnnotationDefault",
+	BootstrapMethodsTag:                     "BootstrapMethods",
+	RuntimeVisibleTypeAnnotationsTag:        "RuntimeVisibleTypeAnnotations",
+	RuntimeInvisibleTypeAnnotationsTag:      "RuntimeInvisibleTypeAnnotations",
+	ModuleTag:                               "Module",
+	ModulePackagesTag:                       "ModulePackages",
+	ModuleMainClassTag:                      "ModuleMainClass",
+	NestHostTag:                             "NestHost",
+	NestMembersTag:                          "NestMembers",
+	RecordTag:                               "Record",
+	PermittedSubclassesTag:                  "PermittedSubclasses",
+	MethodParametersTag:                     "MethodParameters",
+}
+
+func (t AttributeType) String() string {
+	if name, ok := attributeNames[t]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("AttributeType(%d)", uint8(t))
+}
+
 type baseAttribute struct {
 	NameIndex ConstPoolIndex
 	Length    uint32
@@ -164,6 +306,36 @@ func (a baseAttribute) AnnotationDefault() *AnnotationDefault {
 func (a baseAttribute) BootstrapMethods() *BootstrapMethods {
 	panic("jclass: value is not BootstrapMethods")
 }
+func (a baseAttribute) RuntimeVisibleTypeAnnotations() *RuntimeVisibleTypeAnnotations {
+	panic("jclass: value is not RuntimeVisibleTypeAnnotations")
+}
+func (a baseAttribute) RuntimeInvisibleTypeAnnotations() *RuntimeInvisibleTypeAnnotations {
+	panic("jclass: value is not RuntimeInvisibleTypeAnnotations")
+}
+func (a baseAttribute) Module() *Module {
+	panic("jclass: value is not Module")
+}
+func (a baseAttribute) ModulePackages() *ModulePackages {
+	panic("jclass: value is not ModulePackages")
+}
+func (a baseAttribute) ModuleMainClass() *ModuleMainClass {
+	panic("jclass: value is not ModuleMainClass")
+}
+func (a baseAttribute) NestHost() *NestHost {
+	panic("jclass: value is not NestHost")
+}
+func (a baseAttribute) NestMembers() *NestMembers {
+	panic("jclass: value is not NestMembers")
+}
+func (a baseAttribute) Record() *Record {
+	panic("jclass: value is not Record")
+}
+func (a baseAttribute) PermittedSubclasses() *PermittedSubclasses {
+	panic("jclass: value is not PermittedSubclasses")
+}
+func (a baseAttribute) MethodParameters() *MethodParameters {
+	panic("jclass: value is not MethodParameters")
+}
 
 type UnknownAttr struct {
 	baseAttribute
@@ -276,11 +448,55 @@ func (a *Code) Dump(w io.Writer) error {
 	})
 }
 
+// Code, may single
+// (implicit when missing, if version >= 50.0)
 type StackMapTable struct {
 	baseAttribute
+	Entries []StackMapFrame
 }
 
 func (a *StackMapTable) StackMapTable() *StackMapTable { return a }
+func (a *StackMapTable) GetTag() AttributeType         { return StackMapTableTag }
+
+func (a *StackMapTable) Read(r io.Reader, _ ConstantPool) error {
+	var entriesCount uint16
+	err := binary.Read(r, byteOrder, &entriesCount)
+	if err != nil {
+		return err
+	}
+
+	a.Entries = make([]StackMapFrame, 0, entriesCount)
+
+	for i := uint16(0); i < entriesCount; i++ {
+		frame, err := readStackMapFrame(r)
+		if err != nil {
+			return err
+		}
+
+		a.Entries = append(a.Entries, frame)
+	}
+
+	return nil
+}
+
+func (a *StackMapTable) Dump(w io.Writer) error {
+	err := multiError([]error{
+		binary.Write(w, byteOrder, a.baseAttribute),
+		binary.Write(w, byteOrder, uint16(len(a.Entries))),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, frame := range a.Entries {
+		err := frame.Dump(w)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
 
 // method_info, may single
 type Exceptions struct {
@@ -372,7 +588,7 @@ type Synthetic struct{ baseAttribute }
 
 func (a *Synthetic) Synthetic() *Synthetic                  { return a }
 func (a *Synthetic) GetTag() AttributeType                  { return SyntheticTag }
-func (a *Synthetic) Read(a io.Reader, _ ConstantPool) error { return nil }
+func (a *Synthetic) Read(r io.Reader, _ ConstantPool) error { return nil }
 func (a *Synthetic) Dump(w io.Writer) error                 { return binary.Write(w, byteOrder, a) }
 
 // ClassFile, field_info, or method_info, may single
@@ -415,16 +631,10 @@ func (a *SourceDebugExtension) SourceDebugExtension() *SourceDebugExtension { re
 func (a *SourceDebugExtension) GetTag() AttributeType                       { return SourceDebugExtensionTag }
 
 func (a *SourceDebugExtension) Read(r io.Reader, _ ConstantPool) error {
-	var err error
-
-	var length uint32
-	err = binary.Read(r, byteOrder, &length)
-	if err != nil {
-		return err
-	}
-
-	str := make([]uint8, length)
-	err = binary.Read(r, byteOrder, str)
+	// The extension takes up the whole attribute,
+	// there is no separate length.
+	str := make([]uint8, a.Length)
+	err := binary.Read(r, byteOrder, str)
 	if err != nil {
 		return err
 	}
@@ -435,12 +645,10 @@ func (a *SourceDebugExtension) Read(r io.Reader, _ ConstantPool) error {
 }
 
 func (a *SourceDebugExtension) Dump(w io.Writer) error {
-	err := binary.Write(w, byteOrder, uint16(len(a.DebugExtension)))
-	if err != nil {
-		return err
-	}
-
-	return binary.Write(w, byteOrder, []byte(a.DebugExtension))
+	return multiError([]error{
+		binary.Write(w, byteOrder, a.baseAttribute),
+		binary.Write(w, byteOrder, []byte(a.DebugExtension)),
+	})
 }
 
 // Code, may multiple
@@ -558,15 +766,32 @@ func (a *Deprecated) GetTag() AttributeType                  { return Deprecated
 func (a *Deprecated) Read(r io.Reader, _ ConstantPool) error { return nil }
 func (a *Deprecated) Dump(w io.Writer) error                 { return binary.Write(w, byteOrder, a) }
 
+// ClassFile, field_info, or method_info, may single
 type RuntimeVisibleAnnotations struct {
 	baseAttribute
+	Annotations []*Annotation
 }
 
 func (a *RuntimeVisibleAnnotations) RuntimeVisibleAnnotations() *RuntimeVisibleAnnotations { return a }
 func (a *RuntimeVisibleAnnotations) GetTag() AttributeType                                 { return RuntimeVisibleAnnotationsTag }
 
+func (a *RuntimeVisibleAnnotations) Read(r io.Reader, _ ConstantPool) error {
+	var err error
+	a.Annotations, err = readAnnotations(r)
+	return err
+}
+
+func (a *RuntimeVisibleAnnotations) Dump(w io.Writer) error {
+	return multiError([]error{
+		binary.Write(w, byteOrder, a.baseAttribute),
+		writeAnnotations(w, a.Annotations),
+	})
+}
+
+// ClassFile, field_info, or method_info, may single
 type RuntimeInvisibleAnnotations struct {
 	baseAttribute
+	Annotations []*Annotation
 }
 
 func (a *RuntimeInvisibleAnnotations) RuntimeInvisibleAnnotations() *RuntimeInvisibleAnnotations {
@@ -574,8 +799,24 @@ func (a *RuntimeInvisibleAnnotations) RuntimeInvisibleAnnotations() *RuntimeInvi
 }
 func (a *RuntimeInvisibleAnnotations) GetTag() AttributeType { return RuntimeInvisibleAnnotationsTag }
 
+func (a *RuntimeInvisibleAnnotations) Read(r io.Reader, _ ConstantPool) error {
+	var err error
+	a.Annotations, err = readAnnotations(r)
+	return err
+}
+
+func (a *RuntimeInvisibleAnnotations) Dump(w io.Writer) error {
+	return multiError([]error{
+		binary.Write(w, byteOrder, a.baseAttribute),
+		writeAnnotations(w, a.Annotations),
+	})
+}
+
+// method_info, may single
 type RuntimeVisibleParameterAnnotations struct {
 	baseAttribute
+	// one entry per (annotatable) parameter
+	Parameters [][]*Annotation
 }
 
 func (a *RuntimeVisibleParameterAnnotations) RuntimeVisibleParameterAnnotations() *RuntimeVisibleParameterAnnotations {
@@ -585,8 +826,24 @@ func (a *RuntimeVisibleParameterAnnotations) GetTag() AttributeType {
 	return RuntimeVisibleParameterAnnotationsTag
 }
 
+func (a *RuntimeVisibleParameterAnnotations) Read(r io.Reader, _ ConstantPool) error {
+	var err error
+	a.Parameters, err = readParameterAnnotations(r)
+	return err
+}
+
+func (a *RuntimeVisibleParameterAnnotations) Dump(w io.Writer) error {
+	return multiError([]error{
+		binary.Write(w, byteOrder, a.baseAttribute),
+		writeParameterAnnotations(w, a.Parameters),
+	})
+}
+
+// method_info, may single
 type RuntimeInvisibleParameterAnnotations struct {
 	baseAttribute
+	// one entry per (annotatable) parameter
+	Parameters [][]*Annotation
 }
 
 func (a *RuntimeInvisibleParameterAnnotations) RuntimeInvisibleParameterAnnotations() *RuntimeInvisibleParameterAnnotations {
@@ -596,15 +853,45 @@ func (a *RuntimeInvisibleParameterAnnotations) GetTag() AttributeType {
 	return RuntimeInvisibleParameterAnnotationsTag
 }
 
+func (a *RuntimeInvisibleParameterAnnotations) Read(r io.Reader, _ ConstantPool) error {
+	var err error
+	a.Parameters, err = readParameterAnnotations(r)
+	return err
+}
+
+func (a *RuntimeInvisibleParameterAnnotations) Dump(w io.Writer) error {
+	return multiError([]error{
+		binary.Write(w, byteOrder, a.baseAttribute),
+		writeParameterAnnotations(w, a.Parameters),
+	})
+}
+
+// method_info, may single
+// iff method is an element of an annotation type
 type AnnotationDefault struct {
 	baseAttribute
+	DefaultValue ElementValue
 }
 
 func (a *AnnotationDefault) AnnotationDefault() *AnnotationDefault { return a }
 func (a *AnnotationDefault) GetTag() AttributeType                 { return AnnotationDefaultTag }
 
+func (a *AnnotationDefault) Read(r io.Reader, _ ConstantPool) error {
+	var err error
+	a.DefaultValue, err = readElementValue(r)
+	return err
+}
+
+func (a *AnnotationDefault) Dump(w io.Writer) error {
+	return multiError([]error{
+		binary.Write(w, byteOrder, a.baseAttribute),
+		a.DefaultValue.Dump(w),
+	})
+}
+
 // ClassFile, may single
 // iff constpool conatains CONSTANT_InvokeDynamic_info
+// or CONSTANT_Dynamic_info
 type BootstrapMethods struct {
 	baseAttribute
 	Methods []BootstrapMethod
@@ -668,7 +955,7 @@ func (a *BootstrapMethod) read(r io.Reader) error {
 	}
 
 	var argsCount uint16
-	err = binary.Read(r, byteOrder, &a.MethodRef)
+	err = binary.Read(r, byteOrder, &argsCount)
 	if err != nil {
 		return err
 	}
@@ -684,3 +971,510 @@ func (a *BootstrapMethod) dump(w io.Writer) error {
 		binary.Write(w, byteOrder, a.Args),
 	})
 }
+
+// ClassFile, field_info, method_info or Code, may single
+type RuntimeVisibleTypeAnnotations struct {
+	baseAttribute
+	Annotations []*TypeAnnotation
+}
+
+func (a *RuntimeVisibleTypeAnnotations) RuntimeVisibleTypeAnnotations() *RuntimeVisibleTypeAnnotations {
+	return a
+}
+func (a *RuntimeVisibleTypeAnnotations) GetTag() AttributeType {
+	return RuntimeVisibleTypeAnnotationsTag
+}
+
+func (a *RuntimeVisibleTypeAnnotations) Read(r io.Reader, _ ConstantPool) error {
+	var err error
+	a.Annotations, err = readTypeAnnotations(r)
+	return err
+}
+
+func (a *RuntimeVisibleTypeAnnotations) Dump(w io.Writer) error {
+	return multiError([]error{
+		binary.Write(w, byteOrder, a.baseAttribute),
+		writeTypeAnnotations(w, a.Annotations),
+	})
+}
+
+// ClassFile, field_info, method_info or Code, may single
+type RuntimeInvisibleTypeAnnotations struct {
+	baseAttribute
+	Annotations []*TypeAnnotation
+}
+
+func (a *RuntimeInvisibleTypeAnnotations) RuntimeInvisibleTypeAnnotations() *RuntimeInvisibleTypeAnnotations {
+	return a
+}
+func (a *RuntimeInvisibleTypeAnnotations) GetTag() AttributeType {
+	return RuntimeInvisibleTypeAnnotationsTag
+}
+
+func (a *RuntimeInvisibleTypeAnnotations) Read(r io.Reader, _ ConstantPool) error {
+	var err error
+	a.Annotations, err = readTypeAnnotations(r)
+	return err
+}
+
+func (a *RuntimeInvisibleTypeAnnotations) Dump(w io.Writer) error {
+	return multiError([]error{
+		binary.Write(w, byteOrder, a.baseAttribute),
+		writeTypeAnnotations(w, a.Annotations),
+	})
+}
+
+// ClassFile, may single
+// iff ACC_MODULE is set
+type Module struct {
+	baseAttribute
+	NameIndex    ConstPoolIndex
+	Flags        AccessFlags
+	VersionIndex ConstPoolIndex
+
+	Requires []ModuleRequires
+	Exports  []ModuleExports
+	Opens    []ModuleOpens
+	Uses     []ConstPoolIndex
+	Provides []ModuleProvides
+}
+
+type ModuleRequires struct {
+	// references a CONSTANT_Module_info
+	RequiresIndex ConstPoolIndex
+	Flags         AccessFlags
+	// may be zero, if no version information is present
+	VersionIndex ConstPoolIndex
+}
+
+type ModuleExports struct {
+	// references a CONSTANT_Package_info
+	ExportsIndex ConstPoolIndex
+	Flags        AccessFlags
+	// empty if exported unqualified
+	ExportsTo []ConstPoolIndex
+}
+
+type ModuleOpens struct {
+	// references a CONSTANT_Package_info
+	OpensIndex ConstPoolIndex
+	Flags      AccessFlags
+	// empty if opened unqualified
+	OpensTo []ConstPoolIndex
+}
+
+type ModuleProvides struct {
+	// references a CONSTANT_Class_info of the service interface
+	ProvidesIndex ConstPoolIndex
+	ProvidesWith  []ConstPoolIndex
+}
+
+func (a *Module) Module() *Module       { return a }
+func (a *Module) GetTag() AttributeType { return ModuleTag }
+
+func (a *Module) Read(r io.Reader, _ ConstantPool) error {
+	var err error
+
+	var requiresCount uint16
+	err = multiError([]error{
+		binary.Read(r, byteOrder, &a.NameIndex),
+		binary.Read(r, byteOrder, &a.Flags),
+		binary.Read(r, byteOrder, &a.VersionIndex),
+		binary.Read(r, byteOrder, &requiresCount),
+	})
+	if err != nil {
+		return err
+	}
+
+	a.Requires = make([]ModuleRequires, requiresCount)
+	err = binary.Read(r, byteOrder, a.Requires)
+	if err != nil {
+		return err
+	}
+
+	var exportsCount uint16
+	err = binary.Read(r, byteOrder, &exportsCount)
+	if err != nil {
+		return err
+	}
+
+	a.Exports = make([]ModuleExports, exportsCount)
+	for i := range a.Exports {
+		exports := &a.Exports[i]
+
+		err = multiError([]error{
+			binary.Read(r, byteOrder, &exports.ExportsIndex),
+			binary.Read(r, byteOrder, &exports.Flags),
+		})
+		if err != nil {
+			return err
+		}
+
+		exports.ExportsTo, err = readConstPoolIndexes(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	var opensCount uint16
+	err = binary.Read(r, byteOrder, &opensCount)
+	if err != nil {
+		return err
+	}
+
+	a.Opens = make([]ModuleOpens, opensCount)
+	for i := range a.Opens {
+		opens := &a.Opens[i]
+
+		err = multiError([]error{
+			binary.Read(r, byteOrder, &opens.OpensIndex),
+			binary.Read(r, byteOrder, &opens.Flags),
+		})
+		if err != nil {
+			return err
+		}
+
+		opens.OpensTo, err = readConstPoolIndexes(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	a.Uses, err = readConstPoolIndexes(r)
+	if err != nil {
+		return err
+	}
+
+	var providesCount uint16
+	err = binary.Read(r, byteOrder, &providesCount)
+	if err != nil {
+		return err
+	}
+
+	a.Provides = make([]ModuleProvides, providesCount)
+	for i := range a.Provides {
+		provides := &a.Provides[i]
+
+		err = binary.Read(r, byteOrder, &provides.ProvidesIndex)
+		if err != nil {
+			return err
+		}
+
+		provides.ProvidesWith, err = readConstPoolIndexes(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *Module) Dump(w io.Writer) error {
+	err := multiError([]error{
+		binary.Write(w, byteOrder, a.baseAttribute),
+		binary.Write(w, byteOrder, a.NameIndex),
+		binary.Write(w, byteOrder, a.Flags),
+		binary.Write(w, byteOrder, a.VersionIndex),
+		binary.Write(w, byteOrder, uint16(len(a.Requires))),
+		binary.Write(w, byteOrder, a.Requires),
+		binary.Write(w, byteOrder, uint16(len(a.Exports))),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, exports := range a.Exports {
+		err := multiError([]error{
+			binary.Write(w, byteOrder, exports.ExportsIndex),
+			binary.Write(w, byteOrder, exports.Flags),
+			writeConstPoolIndexes(w, exports.ExportsTo),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = binary.Write(w, byteOrder, uint16(len(a.Opens)))
+	if err != nil {
+		return err
+	}
+
+	for _, opens := range a.Opens {
+		err := multiError([]error{
+			binary.Write(w, byteOrder, opens.OpensIndex),
+			binary.Write(w, byteOrder, opens.Flags),
+			writeConstPoolIndexes(w, opens.OpensTo),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = multiError([]error{
+		writeConstPoolIndexes(w, a.Uses),
+		binary.Write(w, byteOrder, uint16(len(a.Provides))),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, provides := range a.Provides {
+		err := multiError([]error{
+			binary.Write(w, byteOrder, provides.ProvidesIndex),
+			writeConstPoolIndexes(w, provides.ProvidesWith),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ClassFile, may single
+// iff ACC_MODULE is set
+type ModulePackages struct {
+	baseAttribute
+	// references CONSTANT_Package_info entries
+	Packages []ConstPoolIndex
+}
+
+func (a *ModulePackages) ModulePackages() *ModulePackages { return a }
+func (a *ModulePackages) GetTag() AttributeType           { return ModulePackagesTag }
+
+func (a *ModulePackages) Read(r io.Reader, _ ConstantPool) error {
+	var err error
+	a.Packages, err = readConstPoolIndexes(r)
+	return err
+}
+
+func (a *ModulePackages) Dump(w io.Writer) error {
+	return multiError([]error{
+		binary.Write(w, byteOrder, a.baseAttribute),
+		writeConstPoolIndexes(w, a.Packages),
+	})
+}
+
+// ClassFile, may single
+// iff ACC_MODULE is set
+type ModuleMainClass struct {
+	baseAttribute
+	MainClassIndex ConstPoolIndex
+}
+
+func (a *ModuleMainClass) ModuleMainClass() *ModuleMainClass { return a }
+func (a *ModuleMainClass) GetTag() AttributeType             { return ModuleMainClassTag }
+
+func (a *ModuleMainClass) Read(r io.Reader, _ ConstantPool) error {
+	return binary.Read(r, byteOrder, &a.MainClassIndex)
+}
+
+func (a *ModuleMainClass) Dump(w io.Writer) error { return binary.Write(w, byteOrder, a) }
+
+// Reads a u2 count, followed by as many constant pool indexes.
+func readConstPoolIndexes(r io.Reader) ([]ConstPoolIndex, error) {
+	var count uint16
+	err := binary.Read(r, byteOrder, &count)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]ConstPoolIndex, count)
+	err = binary.Read(r, byteOrder, indexes)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexes, nil
+}
+
+func writeConstPoolIndexes(w io.Writer, indexes []ConstPoolIndex) error {
+	return multiError([]error{
+		binary.Write(w, byteOrder, uint16(len(indexes))),
+		binary.Write(w, byteOrder, indexes),
+	})
+}
+
+// ClassFile, may single
+// not together with NestMembers
+type NestHost struct {
+	baseAttribute
+	HostClassIndex ConstPoolIndex
+}
+
+func (a *NestHost) NestHost() *NestHost   { return a }
+func (a *NestHost) GetTag() AttributeType { return NestHostTag }
+
+func (a *NestHost) Read(r io.Reader, _ ConstantPool) error {
+	return binary.Read(r, byteOrder, &a.HostClassIndex)
+}
+
+func (a *NestHost) Dump(w io.Writer) error { return binary.Write(w, byteOrder, a) }
+
+// HostClassName returns the internal name of the nest host.
+func (a *NestHost) HostClassName(constPool ConstantPool) (string, error) {
+	return constPool.className(a.HostClassIndex)
+}
+
+// ClassFile, may single
+// not together with NestHost
+type NestMembers struct {
+	baseAttribute
+	Classes []ConstPoolIndex
+}
+
+func (a *NestMembers) NestMembers() *NestMembers { return a }
+func (a *NestMembers) GetTag() AttributeType     { return NestMembersTag }
+
+func (a *NestMembers) Read(r io.Reader, _ ConstantPool) error {
+	var err error
+	a.Classes, err = readConstPoolIndexes(r)
+	return err
+}
+
+func (a *NestMembers) Dump(w io.Writer) error {
+	return multiError([]error{
+		binary.Write(w, byteOrder, a.baseAttribute),
+		writeConstPoolIndexes(w, a.Classes),
+	})
+}
+
+// ClassNames returns the internal names of all nest members.
+func (a *NestMembers) ClassNames(constPool ConstantPool) ([]string, error) {
+	return constPool.classNames(a.Classes)
+}
+
+// ClassFile, may single
+// iff record class
+type Record struct {
+	baseAttribute
+	Components []*RecordComponent
+}
+
+type RecordComponent struct {
+	NameIndex       ConstPoolIndex
+	DescriptorIndex ConstPoolIndex
+	// only Signature, Runtime[In]VisibleAnnotations,
+	// Runtime[In]VisibleTypeAnnotations
+	Attributes
+}
+
+func (a *Record) Record() *Record       { return a }
+func (a *Record) GetTag() AttributeType { return RecordTag }
+
+func (a *Record) Read(r io.Reader, constPool ConstantPool) error {
+	var componentsCount uint16
+	err := binary.Read(r, byteOrder, &componentsCount)
+	if err != nil {
+		return err
+	}
+
+	a.Components = make([]*RecordComponent, 0, componentsCount)
+
+	for i := uint16(0); i < componentsCount; i++ {
+		component := &RecordComponent{}
+		offset := bytesRead(r)
+
+		err := multiError([]error{
+			binary.Read(r, byteOrder, &component.NameIndex),
+			binary.Read(r, byteOrder, &component.DescriptorIndex),
+		})
+		if err == nil {
+			component.Attributes, err = readAttributes(r, constPool)
+		}
+
+		if err != nil {
+			return newParseError(err, fmt.Sprintf("components[%d]", i), offset)
+		}
+
+		a.Components = append(a.Components, component)
+	}
+
+	return nil
+}
+
+func (a *Record) Dump(w io.Writer) error {
+	err := multiError([]error{
+		binary.Write(w, byteOrder, a.baseAttribute),
+		binary.Write(w, byteOrder, uint16(len(a.Components))),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, component := range a.Components {
+		err := multiError([]error{
+			binary.Write(w, byteOrder, component.NameIndex),
+			binary.Write(w, byteOrder, component.DescriptorIndex),
+			writeAttributes(w, component.Attributes),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ClassFile, may single
+// iff sealed class or interface
+type PermittedSubclasses struct {
+	baseAttribute
+	Classes []ConstPoolIndex
+}
+
+func (a *PermittedSubclasses) PermittedSubclasses() *PermittedSubclasses { return a }
+func (a *PermittedSubclasses) GetTag() AttributeType                     { return PermittedSubclassesTag }
+
+func (a *PermittedSubclasses) Read(r io.Reader, _ ConstantPool) error {
+	var err error
+	a.Classes, err = readConstPoolIndexes(r)
+	return err
+}
+
+func (a *PermittedSubclasses) Dump(w io.Writer) error {
+	return multiError([]error{
+		binary.Write(w, byteOrder, a.baseAttribute),
+		writeConstPoolIndexes(w, a.Classes),
+	})
+}
+
+// ClassNames returns the internal names of all permitted subclasses.
+func (a *PermittedSubclasses) ClassNames(constPool ConstantPool) ([]string, error) {
+	return constPool.classNames(a.Classes)
+}
+
+// method_info, may single
+type MethodParameters struct {
+	baseAttribute
+	Parameters []MethodParameter
+}
+
+type MethodParameter struct {
+	// may be zero, if the parameter has no name
+	NameIndex ConstPoolIndex
+	// see PARAMETER_ACC_*
+	AccessFlags
+}
+
+func (a *MethodParameters) MethodParameters() *MethodParameters { return a }
+func (a *MethodParameters) GetTag() AttributeType               { return MethodParametersTag }
+
+func (a *MethodParameters) Read(r io.Reader, _ ConstantPool) error {
+	var parametersCount uint8
+	err := binary.Read(r, byteOrder, &parametersCount)
+	if err != nil {
+		return err
+	}
+
+	a.Parameters = make([]MethodParameter, parametersCount)
+	return binary.Read(r, byteOrder, a.Parameters)
+}
+
+func (a *MethodParameters) Dump(w io.Writer) error {
+	return multiError([]error{
+		binary.Write(w, byteOrder, a.baseAttribute),
+		binary.Write(w, byteOrder, uint8(len(a.Parameters))),
+		binary.Write(w, byteOrder, a.Parameters),
+	})
+}
@@ -0,0 +1,93 @@
+package class
+
+import (
+	"errors"
+)
+
+// BootstrapSpecifier is the resolved form of a CONSTANT_Dynamic_info
+// or CONSTANT_InvokeDynamic_info entry: the name and descriptor
+// of the constant or call site, together with the bootstrap
+// method handle and its static arguments, as referenced
+// through the BootstrapMethods attribute.
+type BootstrapSpecifier struct {
+	Name       string
+	Descriptor string
+
+	// Index of the used entry in BootstrapMethods.Methods.
+	BootstrapMethodAttrIndex ConstPoolIndex
+
+	MethodHandle *MethodHandleRef
+	// The static arguments, as found in the constant pool.
+	Args []Constant
+}
+
+// ResolveDynamic resolves the CONSTANT_Dynamic_info at index
+// against the class' BootstrapMethods attribute.
+func (c *ClassFile) ResolveDynamic(index ConstPoolIndex) (*BootstrapSpecifier, error) {
+	dynamic, err := c.LookupDynamic(index)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.resolveBootstrap(dynamic.BootstrapMethodAttrIndex, dynamic.NameAndTypeIndex)
+}
+
+// ResolveInvokeDynamic resolves the CONSTANT_InvokeDynamic_info at
+// index against the class' BootstrapMethods attribute.
+func (c *ClassFile) ResolveInvokeDynamic(index ConstPoolIndex) (*BootstrapSpecifier, error) {
+	invokeDynamic, err := c.LookupInvokeDynamic(index)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.resolveBootstrap(invokeDynamic.BootstrapMethodAttrIndex, invokeDynamic.NameAndTypeIndex)
+}
+
+func (c *ClassFile) resolveBootstrap(attrIndex, nameAndTypeIndex ConstPoolIndex) (*BootstrapSpecifier, error) {
+	bootstrapMethods := c.BootstrapMethods()
+	if bootstrapMethods == nil {
+		return nil, errors.New("jclass: class has no BootstrapMethods attribute")
+	}
+
+	if int(attrIndex) >= len(bootstrapMethods.Methods) {
+		return nil, errors.New("jclass: bootstrap method index out of range")
+	}
+
+	method := bootstrapMethods.Methods[attrIndex]
+
+	nameAndType, err := c.LookupNameAndType(nameAndTypeIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &BootstrapSpecifier{
+		BootstrapMethodAttrIndex: attrIndex,
+		Args:                     make([]Constant, 0, len(method.Args)),
+	}
+
+	spec.Name, err = c.LookupUTF8(nameAndType.NameIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	spec.Descriptor, err = c.LookupUTF8(nameAndType.DescriptorIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	spec.MethodHandle, err = c.LookupMethodHandle(method.MethodRef)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, index := range method.Args {
+		arg, err := c.Lookup(index)
+		if err != nil {
+			return nil, err
+		}
+
+		spec.Args = append(spec.Args, arg)
+	}
+
+	return spec, nil
+}
@@ -0,0 +1,55 @@
+package class
+
+import "testing"
+
+func TestResolveDynamic(t *testing.T) {
+	_, c := readHelloWorld(t)
+
+	b := c.ConstPoolBuilder()
+	handle := b.AddMethodHandle(REF_invokeStatic, b.AddMethodRef("Boot", "boot", "()I"))
+	arg := b.AddInteger(42)
+	dynamic := b.AddDynamic(0, "value", "I")
+	invalid := b.AddDynamic(1, "value", "I")
+	indy := b.AddInvokeDynamic(0, "run", "()Ljava/lang/Runnable;")
+	if b.Err() != nil {
+		t.Fatal(b.Err())
+	}
+
+	bootstrap := &BootstrapMethods{Methods: []BootstrapMethod{{handle, []ConstPoolIndex{arg}}}}
+	c.Attributes = append(c.Attributes, bootstrap)
+
+	spec, err := c.ResolveDynamic(dynamic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if spec.Name != "value" || spec.Descriptor != "I" || spec.MethodHandle.ReferenceKind != REF_invokeStatic ||
+		len(spec.Args) != 1 || spec.Args[0].Integer().Value != 42 {
+		t.Errorf("wrong bootstrap specifier %+v", spec)
+	}
+
+	if spec, err := c.ResolveInvokeDynamic(indy); err != nil || spec.Name != "run" {
+		t.Errorf("wrong invokedynamic specifier %+v, %v", spec, err)
+	}
+
+	if _, err := c.ResolveDynamic(invalid); err == nil {
+		t.Error("bootstrap method index out of range not reported")
+	}
+
+	if _, err := c.ResolveDynamic(indy); err == nil {
+		t.Error("wrong constant type not reported")
+	}
+
+	for _, index := range []ConstPoolIndex{0, 3, 9999} {
+		bootstrap.Methods[0].Args[0] = index
+		if _, err := c.ResolveDynamic(dynamic); err == nil {
+			t.Errorf("invalid argument #%d not reported", index)
+		}
+	}
+
+	bootstrap.Methods[0].Args[0] = arg
+	bootstrap.Methods[0].MethodRef = arg
+	if _, err := c.ResolveDynamic(dynamic); err == nil {
+		t.Error("invalid method handle not reported")
+	}
+}
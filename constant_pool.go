@@ -2,8 +2,10 @@ package class
 
 import (
 	"encoding/binary"
-	// "fmt"
+	"errors"
+	"fmt"
 	"io"
+	"math"
 )
 
 func (constPool ConstantPool) GetUTF8(index ConstPoolIndex) string {
@@ -58,18 +60,261 @@ func (constPool ConstantPool) GetMethodType(index ConstPoolIndex) *MethodTypeRef
 	return constPool[index-1].MethodType()
 }
 
+func (constPool ConstantPool) GetDynamic(index ConstPoolIndex) *DynamicRef {
+	return constPool[index-1].Dynamic()
+}
+
 func (constPool ConstantPool) GetInvokeDynamic(index ConstPoolIndex) *InvokeDynamicRef {
 	return constPool[index-1].InvokeDynamic()
 }
 
+func (constPool ConstantPool) GetModule(index ConstPoolIndex) *ModuleRef {
+	return constPool[index-1].Module()
+}
+
+func (constPool ConstantPool) GetPackage(index ConstPoolIndex) *PackageRef {
+	return constPool[index-1].Package()
+}
+
+// A ConstantError is returned by the Lookup* methods of
+// ConstantPool, if an index doesn't refer to a constant
+// of the expected type.
+type ConstantError struct {
+	Index ConstPoolIndex
+
+	// Tag of the expected constant, zero if any
+	// constant was expected.
+	Expected ConstantType
+
+	// Tag of the constant found at Index, zero if there
+	// is none (i.e. Index is zero, out of range or refers
+	// to the second slot of a Long or Double constant).
+	Actual ConstantType
+}
+
+func (e *ConstantError) Error() string {
+	if e.Actual == 0 {
+		if e.Expected == 0 {
+			return fmt.Sprintf("jclass: invalid constant pool index %d", e.Index)
+		}
+
+		return fmt.Sprintf("jclass: invalid constant pool index %d, expected %s", e.Index, e.Expected)
+	}
+
+	return fmt.Sprintf("jclass: constant pool index %d is %s, expected %s", e.Index, e.Actual, e.Expected)
+}
+
+// Lookup returns the constant at index or, if there is no
+// constant at index, a *ConstantError. Unlike the Get*
+// methods, the Lookup* methods never panic.
+func (constPool ConstantPool) Lookup(index ConstPoolIndex) (Constant, error) {
+	if index == 0 || int(index) > len(constPool) || constPool[index-1] == nil {
+		return nil, &ConstantError{Index: index}
+	}
+
+	return constPool[index-1], nil
+}
+
+func (constPool ConstantPool) lookup(index ConstPoolIndex, tag ConstantType) (Constant, error) {
+	if index == 0 || int(index) > len(constPool) || constPool[index-1] == nil {
+		return nil, &ConstantError{Index: index, Expected: tag}
+	}
+
+	constant := constPool[index-1]
+	if constant.GetTag() != tag {
+		return nil, &ConstantError{Index: index, Expected: tag, Actual: constant.GetTag()}
+	}
+
+	return constant, nil
+}
+
+func (constPool ConstantPool) LookupUTF8(index ConstPoolIndex) (string, error) {
+	constant, err := constPool.lookup(index, CONSTANT_UTF8)
+	if err != nil {
+		return "", err
+	}
+
+	return constant.UTF8().Value, nil
+}
+
+func (constPool ConstantPool) LookupInteger(index ConstPoolIndex) (int32, error) {
+	constant, err := constPool.lookup(index, CONSTANT_Integer)
+	if err != nil {
+		return 0, err
+	}
+
+	return constant.Integer().Value, nil
+}
+
+func (constPool ConstantPool) LookupFloat(index ConstPoolIndex) (float32, error) {
+	constant, err := constPool.lookup(index, CONSTANT_Float)
+	if err != nil {
+		return 0, err
+	}
+
+	return constant.Float().Value, nil
+}
+
+func (constPool ConstantPool) LookupLong(index ConstPoolIndex) (int64, error) {
+	constant, err := constPool.lookup(index, CONSTANT_Long)
+	if err != nil {
+		return 0, err
+	}
+
+	return constant.Long().Value, nil
+}
+
+func (constPool ConstantPool) LookupDouble(index ConstPoolIndex) (float64, error) {
+	constant, err := constPool.lookup(index, CONSTANT_Double)
+	if err != nil {
+		return 0, err
+	}
+
+	return constant.Double().Value, nil
+}
+
+func (constPool ConstantPool) LookupClass(index ConstPoolIndex) (*ClassRef, error) {
+	constant, err := constPool.lookup(index, CONSTANT_Class)
+	if err != nil {
+		return nil, err
+	}
+
+	return constant.Class(), nil
+}
+
+func (constPool ConstantPool) LookupString(index ConstPoolIndex) (*StringRef, error) {
+	constant, err := constPool.lookup(index, CONSTANT_String)
+	if err != nil {
+		return nil, err
+	}
+
+	return constant.StringRef(), nil
+}
+
+func (constPool ConstantPool) LookupField(index ConstPoolIndex) (*FieldRef, error) {
+	constant, err := constPool.lookup(index, CONSTANT_FieldRef)
+	if err != nil {
+		return nil, err
+	}
+
+	return constant.Field(), nil
+}
+
+func (constPool ConstantPool) LookupMethod(index ConstPoolIndex) (*MethodRef, error) {
+	constant, err := constPool.lookup(index, CONSTANT_MethodRef)
+	if err != nil {
+		return nil, err
+	}
+
+	return constant.Method(), nil
+}
+
+func (constPool ConstantPool) LookupInterfaceMethod(index ConstPoolIndex) (*InterfaceMethodRef, error) {
+	constant, err := constPool.lookup(index, CONSTANT_InterfaceMethodRef)
+	if err != nil {
+		return nil, err
+	}
+
+	return constant.InterfaceMethod(), nil
+}
+
+func (constPool ConstantPool) LookupNameAndType(index ConstPoolIndex) (*NameAndTypeRef, error) {
+	constant, err := constPool.lookup(index, CONSTANT_NameAndType)
+	if err != nil {
+		return nil, err
+	}
+
+	return constant.NameAndType(), nil
+}
+
+func (constPool ConstantPool) LookupMethodHandle(index ConstPoolIndex) (*MethodHandleRef, error) {
+	constant, err := constPool.lookup(index, CONSTANT_MethodHandle)
+	if err != nil {
+		return nil, err
+	}
+
+	return constant.MethodHandle(), nil
+}
+
+func (constPool ConstantPool) LookupMethodType(index ConstPoolIndex) (*MethodTypeRef, error) {
+	constant, err := constPool.lookup(index, CONSTANT_MethodType)
+	if err != nil {
+		return nil, err
+	}
+
+	return constant.MethodType(), nil
+}
+
+func (constPool ConstantPool) LookupDynamic(index ConstPoolIndex) (*DynamicRef, error) {
+	constant, err := constPool.lookup(index, CONSTANT_Dynamic)
+	if err != nil {
+		return nil, err
+	}
+
+	return constant.Dynamic(), nil
+}
+
+func (constPool ConstantPool) LookupInvokeDynamic(index ConstPoolIndex) (*InvokeDynamicRef, error) {
+	constant, err := constPool.lookup(index, CONSTANT_InvokeDynamic)
+	if err != nil {
+		return nil, err
+	}
+
+	return constant.InvokeDynamic(), nil
+}
+
+func (constPool ConstantPool) LookupModule(index ConstPoolIndex) (*ModuleRef, error) {
+	constant, err := constPool.lookup(index, CONSTANT_Module)
+	if err != nil {
+		return nil, err
+	}
+
+	return constant.Module(), nil
+}
+
+func (constPool ConstantPool) LookupPackage(index ConstPoolIndex) (*PackageRef, error) {
+	constant, err := constPool.lookup(index, CONSTANT_Package)
+	if err != nil {
+		return nil, err
+	}
+
+	return constant.Package(), nil
+}
+
+// className returns the name of the CONSTANT_Class_info at index.
+func (constPool ConstantPool) className(index ConstPoolIndex) (string, error) {
+	class, err := constPool.LookupClass(index)
+	if err != nil {
+		return "", err
+	}
+
+	return constPool.LookupUTF8(class.NameIndex)
+}
+
+func (constPool ConstantPool) classNames(indexes []ConstPoolIndex) ([]string, error) {
+	names := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		name, err := constPool.className(index)
+		if err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
 func (c *ClassFile) writeConstPool(w io.Writer) error {
 	err := binary.Write(w, byteOrder, c.ConstPoolSize)
 	if err != nil {
 		return err
 	}
 
-	for i := uint16(0); i < c.ConstPoolSize-1; i++ {
-		constant := c.ConstantPool[i]
+	for i, constant := range c.ConstantPool {
+		if i >= int(c.ConstPoolSize)-1 {
+			break
+		}
 
 		// In place because of the most annoying spec ever!
 		// For more info, see: readConstPool
@@ -86,6 +331,25 @@ func (c *ClassFile) writeConstPool(w io.Writer) error {
 	return nil
 }
 
+// constPoolSize returns the constant_pool_count, which
+// results from the highest used slot in constPool.
+func constPoolSize(constPool ConstantPool) int {
+	size := 1
+
+	for n, constant := range constPool {
+		if constant == nil {
+			continue
+		}
+
+		size = n + 2
+		if tag := constant.GetTag(); tag == CONSTANT_Long || tag == CONSTANT_Double {
+			size++
+		}
+	}
+
+	return size
+}
+
 func (c *ClassFile) readConstPool(r io.Reader) error {
 	err := binary.Read(r, byteOrder, &c.ConstPoolSize)
 	if err != nil {
@@ -95,9 +359,11 @@ func (c *ClassFile) readConstPool(r io.Reader) error {
 	c.ConstantPool = make(ConstantPool, c.ConstPoolSize)
 
 	for i := uint16(1); i < c.ConstPoolSize; i++ {
+		offset := bytesRead(r)
+
 		constant, err := readConstant(r)
 		if err != nil {
-			return err
+			return newParseError(err, fmt.Sprintf("constant_pool[%d]", i), offset)
 		}
 
 		c.ConstantPool[i-1] = constant
@@ -121,6 +387,8 @@ func (c *ClassFile) readConstPool(r io.Reader) error {
 	return nil
 }
 
+var errUnknownConstantTag = errors.New("jclass: unknown constant pool tag")
+
 func readConstant(r io.Reader) (Constant, error) {
 	constBase := baseConstant{}
 
@@ -162,10 +430,16 @@ func fillConstant(r io.Reader, constBase baseConstant) (Constant, error) {
 		constant = &MethodHandleRef{baseConstant: constBase}
 	case CONSTANT_MethodType:
 		constant = &MethodTypeRef{baseConstant: constBase}
+	case CONSTANT_Dynamic:
+		constant = &DynamicRef{baseConstant: constBase}
 	case CONSTANT_InvokeDynamic:
 		constant = &InvokeDynamicRef{baseConstant: constBase}
+	case CONSTANT_Module:
+		constant = &ModuleRef{baseConstant: constBase}
+	case CONSTANT_Package:
+		constant = &PackageRef{baseConstant: constBase}
 	default:
-		panic("jclass: unknown constant pool tag")
+		return nil, &ParseError{Tag: uint8(constBase.Tag), HasTag: true, Err: errUnknownConstantTag}
 	}
 
 	err := constant.Read(r)
@@ -178,6 +452,14 @@ func fillConstant(r io.Reader, constBase baseConstant) (Constant, error) {
 
 type ConstantType uint8
 
+func (t ConstantType) String() string {
+	if name, ok := constantNames[t]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("ConstantType(%d)", uint8(t))
+}
+
 type baseConstant struct {
 	Tag ConstantType
 }
@@ -201,9 +483,12 @@ func (b baseConstant) NameAndType() *NameAndTypeRef   { panic("jclass: constant
 func (b baseConstant) UTF8() *UTF8Ref                 { panic("jclass: constant is not UTF8") }
 func (b baseConstant) MethodHandle() *MethodHandleRef { panic("jclass: constant is not MethodHandle") }
 func (b baseConstant) MethodType() *MethodTypeRef     { panic("jclass: constant is not MethodType") }
+func (b baseConstant) Dynamic() *DynamicRef           { panic("jclass: constant is not Dynamic") }
 func (b baseConstant) InvokeDynamic() *InvokeDynamicRef {
 	panic("jclass: constant is not InvokeDynamic")
 }
+func (b baseConstant) Module() *ModuleRef   { panic("jclass: constant is not Module") }
+func (b baseConstant) Package() *PackageRef { panic("jclass: constant is not Package") }
 
 type ClassRef struct {
 	baseConstant
@@ -352,11 +637,17 @@ func (c *NameAndTypeRef) Dump(w io.Writer) error {
 type UTF8Ref struct {
 	baseConstant
 	Value string
+
+	// The bytes (in Modified UTF-8) Value was decoded from,
+	// when the constant was read. As long as they still
+	// decode to Value, Dump writes these bytes instead of
+	// encoding Value, so even invalid sequences (which are
+	// replaced by U+FFFD in Value) are kept.
+	Raw []byte
 }
 
 func (c *UTF8Ref) UTF8() *UTF8Ref { return c }
 
-// TODO: check if the string is handled correctly
 func (c *UTF8Ref) Read(r io.Reader) error {
 	var err error
 
@@ -372,21 +663,37 @@ func (c *UTF8Ref) Read(r io.Reader) error {
 		return err
 	}
 
-	c.Value = string(str)
+	c.Value = decodeModifiedUTF8(str)
+	c.Raw = str
 
 	return nil
 }
 
+// Bytes returns the Modified UTF-8 encoding of
+// the constant, as it's written by Dump.
+func (c *UTF8Ref) Bytes() []byte {
+	if c.Raw != nil && decodeModifiedUTF8(c.Raw) == c.Value {
+		return c.Raw
+	}
+
+	return encodeModifiedUTF8(c.Value)
+}
+
 func (c *UTF8Ref) Dump(w io.Writer) error {
+	str := c.Bytes()
+	if len(str) > math.MaxUint16 {
+		return errStringTooLong
+	}
+
 	err := multiError([]error{
 		binary.Write(w, byteOrder, c.baseConstant),
-		binary.Write(w, byteOrder, uint16(len(c.Value))),
+		binary.Write(w, byteOrder, uint16(len(str))),
 	})
 	if err != nil {
 		return err
 	}
 
-	return binary.Write(w, byteOrder, []byte(c.Value))
+	return binary.Write(w, byteOrder, str)
 }
 
 type MethodHandleRef struct {
@@ -423,6 +730,27 @@ func (c *MethodTypeRef) Dump(w io.Writer) error {
 	return binary.Write(w, byteOrder, c)
 }
 
+// A dynamically-computed constant, whose value is produced
+// by invoking a bootstrap method (see BootstrapMethods).
+type DynamicRef struct {
+	baseConstant
+	BootstrapMethodAttrIndex ConstPoolIndex
+	NameAndTypeIndex         ConstPoolIndex
+}
+
+func (c *DynamicRef) Dynamic() *DynamicRef { return c }
+
+func (c *DynamicRef) Read(r io.Reader) error {
+	return multiError([]error{
+		binary.Read(r, byteOrder, &c.BootstrapMethodAttrIndex),
+		binary.Read(r, byteOrder, &c.NameAndTypeIndex),
+	})
+}
+
+func (c *DynamicRef) Dump(w io.Writer) error {
+	return binary.Write(w, byteOrder, c)
+}
+
 type InvokeDynamicRef struct {
 	baseConstant
 	BootstrapMethodAttrIndex ConstPoolIndex
@@ -441,3 +769,33 @@ func (c *InvokeDynamicRef) Read(r io.Reader) error {
 func (c *InvokeDynamicRef) Dump(w io.Writer) error {
 	return binary.Write(w, byteOrder, c)
 }
+
+type ModuleRef struct {
+	baseConstant
+	NameIndex ConstPoolIndex
+}
+
+func (c *ModuleRef) Module() *ModuleRef { return c }
+
+func (c *ModuleRef) Read(r io.Reader) error {
+	return binary.Read(r, byteOrder, &c.NameIndex)
+}
+
+func (c *ModuleRef) Dump(w io.Writer) error {
+	return binary.Write(w, byteOrder, c)
+}
+
+type PackageRef struct {
+	baseConstant
+	NameIndex ConstPoolIndex
+}
+
+func (c *PackageRef) Package() *PackageRef { return c }
+
+func (c *PackageRef) Read(r io.Reader) error {
+	return binary.Read(r, byteOrder, &c.NameIndex)
+}
+
+func (c *PackageRef) Dump(w io.Writer) error {
+	return binary.Write(w, byteOrder, c)
+}
@@ -0,0 +1,250 @@
+package class
+
+import (
+	"errors"
+	"math"
+)
+
+// ConstPoolBuilder adds constants to the constant pool of
+// a class file. Constants are interned: adding a constant
+// that is equal to one already in the pool returns the index
+// of the existing entry instead of adding a new one. The
+// builder keeps ConstPoolSize up to date and takes care of
+// the second slot used by Long and Double constants.
+type ConstPoolBuilder struct {
+	c       *ClassFile
+	indexes map[constantKey]ConstPoolIndex
+	err     error
+
+	// The index the next constant is added at.
+	next int
+}
+
+// constantKey identifies a constant by its contents.
+// Floating point values are compared by their bits, so
+// e.g. 0.0 and -0.0 remain different constants.
+type constantKey struct {
+	tag  ConstantType
+	a, b uint64
+	s    string
+}
+
+// ConstPoolBuilder returns a builder that adds constants
+// to the constant pool of c. Only one builder should be
+// used at a time, and the constant pool must not be changed
+// otherwise, while the builder is in use.
+func (c *ClassFile) ConstPoolBuilder() *ConstPoolBuilder {
+	b := &ConstPoolBuilder{
+		c:       c,
+		indexes: make(map[constantKey]ConstPoolIndex),
+		next:    constPoolSize(c.ConstantPool),
+	}
+
+	for n, constant := range c.ConstantPool {
+		if constant == nil {
+			continue
+		}
+
+		// Keep the first one of duplicate entries
+		key := keyOf(constant)
+		if _, ok := b.indexes[key]; !ok {
+			b.indexes[key] = ConstPoolIndex(n + 1)
+		}
+	}
+
+	return b
+}
+
+func keyOf(constant Constant) constantKey {
+	key := constantKey{tag: constant.GetTag()}
+
+	switch constant := constant.(type) {
+	case *ClassRef:
+		key.a = uint64(constant.NameIndex)
+	case *FieldRef:
+		key.a, key.b = uint64(constant.ClassIndex), uint64(constant.NameAndTypeIndex)
+	case *MethodRef:
+		key.a, key.b = uint64(constant.ClassIndex), uint64(constant.NameAndTypeIndex)
+	case *InterfaceMethodRef:
+		key.a, key.b = uint64(constant.ClassIndex), uint64(constant.NameAndTypeIndex)
+	case *StringRef:
+		key.a = uint64(constant.Index)
+	case *IntegerRef:
+		key.a = uint64(constant.Value)
+	case *FloatRef:
+		key.a = uint64(math.Float32bits(constant.Value))
+	case *LongRef:
+		key.a = uint64(constant.Value)
+	case *DoubleRef:
+		key.a = math.Float64bits(constant.Value)
+	case *NameAndTypeRef:
+		key.a, key.b = uint64(constant.NameIndex), uint64(constant.DescriptorIndex)
+	case *UTF8Ref:
+		key.s = constant.Value
+	case *MethodHandleRef:
+		key.a, key.b = uint64(constant.ReferenceKind), uint64(constant.ReferenceIndex)
+	case *MethodTypeRef:
+		key.a = uint64(constant.DescriptorIndex)
+	case *DynamicRef:
+		key.a, key.b = uint64(constant.BootstrapMethodAttrIndex), uint64(constant.NameAndTypeIndex)
+	case *InvokeDynamicRef:
+		key.a, key.b = uint64(constant.BootstrapMethodAttrIndex), uint64(constant.NameAndTypeIndex)
+	case *ModuleRef:
+		key.a = uint64(constant.NameIndex)
+	case *PackageRef:
+		key.a = uint64(constant.NameIndex)
+	}
+
+	return key
+}
+
+// Err returns the first error that occurred while adding
+// constants. Once an error occurred, all Add methods
+// return 0 without changing the constant pool.
+func (b *ConstPoolBuilder) Err() error {
+	return b.err
+}
+
+// Add adds constant to the constant pool, unless an equal
+// constant already exists, and returns its index.
+func (b *ConstPoolBuilder) Add(constant Constant) ConstPoolIndex {
+	if b.err != nil {
+		return 0
+	}
+
+	key := keyOf(constant)
+	if index, ok := b.indexes[key]; ok {
+		return index
+	}
+
+	slots := 1
+	if tag := constant.GetTag(); tag == CONSTANT_Long || tag == CONSTANT_Double {
+		slots = 2
+	}
+
+	index := b.next
+	if index+slots > math.MaxUint16 {
+		b.err = errors.New("jclass: constant pool too large")
+		return 0
+	}
+
+	// Just like a parsed constant pool, keep one more
+	// slot than the pool's entries, which is nil.
+	size := index + slots
+	for len(b.c.ConstantPool) < size {
+		b.c.ConstantPool = append(b.c.ConstantPool, nil)
+	}
+
+	b.c.ConstantPool[index-1] = constant
+	b.c.ConstPoolSize = uint16(size)
+	b.indexes[key] = ConstPoolIndex(index)
+	b.next = size
+
+	return ConstPoolIndex(index)
+}
+
+// AddUTF8 adds a CONSTANT_Utf8_info for s.
+func (b *ConstPoolBuilder) AddUTF8(s string) ConstPoolIndex {
+	return b.Add(&UTF8Ref{baseConstant: baseConstant{CONSTANT_UTF8}, Value: s})
+}
+
+// AddInteger adds a CONSTANT_Integer_info for value.
+func (b *ConstPoolBuilder) AddInteger(value int32) ConstPoolIndex {
+	return b.Add(&IntegerRef{baseConstant{CONSTANT_Integer}, value})
+}
+
+// AddFloat adds a CONSTANT_Float_info for value.
+func (b *ConstPoolBuilder) AddFloat(value float32) ConstPoolIndex {
+	return b.Add(&FloatRef{baseConstant{CONSTANT_Float}, value})
+}
+
+// AddLong adds a CONSTANT_Long_info for value,
+// which takes up two slots of the constant pool.
+func (b *ConstPoolBuilder) AddLong(value int64) ConstPoolIndex {
+	return b.Add(&LongRef{baseConstant{CONSTANT_Long}, value})
+}
+
+// AddDouble adds a CONSTANT_Double_info for value,
+// which takes up two slots of the constant pool.
+func (b *ConstPoolBuilder) AddDouble(value float64) ConstPoolIndex {
+	return b.Add(&DoubleRef{baseConstant{CONSTANT_Double}, value})
+}
+
+// AddClass adds a CONSTANT_Class_info for the class with
+// the internal name (e.g. java/lang/Object) or the array
+// type descriptor name.
+func (b *ConstPoolBuilder) AddClass(name string) ConstPoolIndex {
+	return b.Add(&ClassRef{baseConstant{CONSTANT_Class}, b.AddUTF8(name)})
+}
+
+// AddString adds a CONSTANT_String_info for s.
+func (b *ConstPoolBuilder) AddString(s string) ConstPoolIndex {
+	return b.Add(&StringRef{baseConstant{CONSTANT_String}, b.AddUTF8(s)})
+}
+
+// AddNameAndType adds a CONSTANT_NameAndType_info for
+// the name and descriptor of a field or method.
+func (b *ConstPoolBuilder) AddNameAndType(name, descriptor string) ConstPoolIndex {
+	return b.Add(&NameAndTypeRef{baseConstant{CONSTANT_NameAndType}, b.AddUTF8(name), b.AddUTF8(descriptor)})
+}
+
+func (b *ConstPoolBuilder) memberRef(tag ConstantType, owner, name, descriptor string) fieldMethodInterfaceRef {
+	return fieldMethodInterfaceRef{baseConstant{tag}, b.AddClass(owner), b.AddNameAndType(name, descriptor)}
+}
+
+// AddFieldRef adds a CONSTANT_Fieldref_info for the field
+// name with the given descriptor, declared by owner.
+func (b *ConstPoolBuilder) AddFieldRef(owner, name, descriptor string) ConstPoolIndex {
+	return b.Add(&FieldRef{b.memberRef(CONSTANT_FieldRef, owner, name, descriptor)})
+}
+
+// AddMethodRef adds a CONSTANT_Methodref_info for the method
+// name with the given descriptor, declared by the class owner.
+func (b *ConstPoolBuilder) AddMethodRef(owner, name, descriptor string) ConstPoolIndex {
+	return b.Add(&MethodRef{b.memberRef(CONSTANT_MethodRef, owner, name, descriptor)})
+}
+
+// AddInterfaceMethodRef adds a CONSTANT_InterfaceMethodref_info for
+// the method name with the given descriptor, declared by the
+// interface owner.
+func (b *ConstPoolBuilder) AddInterfaceMethodRef(owner, name, descriptor string) ConstPoolIndex {
+	return b.Add(&InterfaceMethodRef{b.memberRef(CONSTANT_InterfaceMethodRef, owner, name, descriptor)})
+}
+
+// AddMethodHandle adds a CONSTANT_MethodHandle_info of the
+// given kind (one of the REF_* constants), referencing the
+// field or method ref at index reference.
+func (b *ConstPoolBuilder) AddMethodHandle(kind uint8, reference ConstPoolIndex) ConstPoolIndex {
+	return b.Add(&MethodHandleRef{baseConstant{CONSTANT_MethodHandle}, kind, reference})
+}
+
+// AddMethodType adds a CONSTANT_MethodType_info for
+// the method descriptor.
+func (b *ConstPoolBuilder) AddMethodType(descriptor string) ConstPoolIndex {
+	return b.Add(&MethodTypeRef{baseConstant{CONSTANT_MethodType}, b.AddUTF8(descriptor)})
+}
+
+// AddDynamic adds a CONSTANT_Dynamic_info, whose value is
+// computed by the bootstrap method at index bootstrapMethod
+// of the BootstrapMethods attribute.
+func (b *ConstPoolBuilder) AddDynamic(bootstrapMethod uint16, name, descriptor string) ConstPoolIndex {
+	return b.Add(&DynamicRef{baseConstant{CONSTANT_Dynamic}, ConstPoolIndex(bootstrapMethod), b.AddNameAndType(name, descriptor)})
+}
+
+// AddInvokeDynamic adds a CONSTANT_InvokeDynamic_info for a call
+// site, which is linked by the bootstrap method at index
+// bootstrapMethod of the BootstrapMethods attribute.
+func (b *ConstPoolBuilder) AddInvokeDynamic(bootstrapMethod uint16, name, descriptor string) ConstPoolIndex {
+	return b.Add(&InvokeDynamicRef{baseConstant{CONSTANT_InvokeDynamic}, ConstPoolIndex(bootstrapMethod), b.AddNameAndType(name, descriptor)})
+}
+
+// AddModule adds a CONSTANT_Module_info for the module name.
+func (b *ConstPoolBuilder) AddModule(name string) ConstPoolIndex {
+	return b.Add(&ModuleRef{baseConstant{CONSTANT_Module}, b.AddUTF8(name)})
+}
+
+// AddPackage adds a CONSTANT_Package_info for the package
+// with the internal name (e.g. java/lang).
+func (b *ConstPoolBuilder) AddPackage(name string) ConstPoolIndex {
+	return b.Add(&PackageRef{baseConstant{CONSTANT_Package}, b.AddUTF8(name)})
+}
@@ -0,0 +1,67 @@
+package class
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestConstPoolBuilder(t *testing.T) {
+	_, c := readHelloWorld(t)
+	size := c.ConstPoolSize
+
+	b := c.ConstPoolBuilder()
+
+	if index := b.AddUTF8("myField"); index != c.Fields[0].NameIndex {
+		t.Errorf("existing constant added again at #%d", index)
+	}
+
+	long := b.AddLong(1 << 40)
+	if long != ConstPoolIndex(size) {
+		t.Errorf("first new constant at #%d, want #%d", long, size)
+	}
+
+	// The Long takes up two slots
+	if index := b.AddInteger(7); index != long+2 {
+		t.Errorf("constant after Long at #%d, want #%d", index, long+2)
+	}
+
+	method := b.AddMethodRef("Foo", "bar", "()V")
+	if b.Err() != nil {
+		t.Fatal(b.Err())
+	}
+
+	if int(c.ConstPoolSize) != constPoolSize(c.ConstantPool) {
+		t.Errorf("ConstPoolSize %d, want %d", c.ConstPoolSize, constPoolSize(c.ConstantPool))
+	}
+
+	parsed, err := Parse(bytes.NewReader(dump(t, c)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := parsed.LookupMethod(method)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name, _ := parsed.className(ref.ClassIndex); name != "Foo" {
+		t.Errorf("method ref of class %q", name)
+	}
+}
+
+func TestConstPoolBuilderFull(t *testing.T) {
+	c := &ClassFile{}
+	b := c.ConstPoolBuilder()
+
+	for i := int32(0); i < 65534; i++ {
+		b.AddInteger(i)
+	}
+
+	if b.Err() != nil || c.ConstPoolSize != 65535 {
+		t.Fatalf("full pool: size %d, %v", c.ConstPoolSize, b.Err())
+	}
+
+	if index := b.AddInteger(65534); index != 0 || b.Err() == nil {
+		t.Error("overflow not reported")
+	}
+}
@@ -0,0 +1,450 @@
+package class
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CompactConstPool removes all constants, that aren't referenced
+// from the class file (directly, or through other constants), and
+// rewrites all indexes into the constant pool accordingly. This
+// includes the operands of instructions and the arguments of
+// bootstrap methods. The remaining constants keep their order,
+// so indexes never grow (and the operand of ldc always fits).
+// Attributes unknown to this package may contain indexes that
+// can't be rewritten, so their presence results in an error.
+// On error, c isn't modified.
+func (c *ClassFile) CompactConstPool() error {
+	refs := &indexRefs{}
+
+	err := refs.classFile(c)
+	if err != nil {
+		return err
+	}
+
+	used := make([]bool, len(c.ConstantPool)+1)
+
+	var mark func(index ConstPoolIndex) error
+	mark = func(index ConstPoolIndex) error {
+		if index == 0 {
+			return nil
+		}
+
+		if int(index) > len(c.ConstantPool) || c.ConstantPool[index-1] == nil {
+			return fmt.Errorf("jclass: invalid constant pool index %d", index)
+		}
+
+		if used[index] {
+			return nil
+		}
+
+		used[index] = true
+		for _, child := range constantIndexes(c.ConstantPool[index-1]) {
+			err := mark(*child)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for _, index := range refs.indexes {
+		err := mark(*index)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Build the new pool, keeping the second slot
+	// of Long and Double constants.
+	newIndexes := make([]ConstPoolIndex, len(c.ConstantPool)+1)
+	constPool := ConstantPool{}
+
+	for n, constant := range c.ConstantPool {
+		if !used[n+1] {
+			continue
+		}
+
+		constPool = append(constPool, constant)
+		newIndexes[n+1] = ConstPoolIndex(len(constPool))
+
+		if tag := constant.GetTag(); tag == CONSTANT_Long || tag == CONSTANT_Double {
+			constPool = append(constPool, nil)
+		}
+	}
+
+	// Check the bytecode before making any changes.
+	for _, code := range refs.code {
+		for _, inst := range code.insts {
+			inst.Index = newIndexes[inst.Index]
+		}
+
+		bytecode, err := EncodeInstructions(code.insts)
+		if err != nil {
+			return err
+		}
+
+		code.bytecode = bytecode
+	}
+
+	for _, code := range refs.code {
+		code.attr.ByteCode = code.bytecode
+	}
+
+	for _, index := range refs.indexes {
+		*index = newIndexes[*index]
+	}
+
+	for _, constant := range constPool {
+		if constant == nil {
+			continue
+		}
+
+		for _, child := range constantIndexes(constant) {
+			*child = newIndexes[*child]
+		}
+	}
+
+	// Just like a parsed constant pool, keep one
+	// more slot than there are entries.
+	c.ConstantPool = append(constPool, nil)
+	c.ConstPoolSize = uint16(len(c.ConstantPool))
+
+	return nil
+}
+
+// constantIndexes returns pointers to the indexes of all
+// constants, that constant refers to. The bootstrap method
+// of (Invoke)Dynamic isn't included, since it's an index
+// into the BootstrapMethods attribute.
+func constantIndexes(constant Constant) []*ConstPoolIndex {
+	switch constant := constant.(type) {
+	case *ClassRef:
+		return []*ConstPoolIndex{&constant.NameIndex}
+	case *FieldRef:
+		return []*ConstPoolIndex{&constant.ClassIndex, &constant.NameAndTypeIndex}
+	case *MethodRef:
+		return []*ConstPoolIndex{&constant.ClassIndex, &constant.NameAndTypeIndex}
+	case *InterfaceMethodRef:
+		return []*ConstPoolIndex{&constant.ClassIndex, &constant.NameAndTypeIndex}
+	case *StringRef:
+		return []*ConstPoolIndex{&constant.Index}
+	case *NameAndTypeRef:
+		return []*ConstPoolIndex{&constant.NameIndex, &constant.DescriptorIndex}
+	case *MethodHandleRef:
+		return []*ConstPoolIndex{&constant.ReferenceIndex}
+	case *MethodTypeRef:
+		return []*ConstPoolIndex{&constant.DescriptorIndex}
+	case *DynamicRef:
+		return []*ConstPoolIndex{&constant.NameAndTypeIndex}
+	case *InvokeDynamicRef:
+		return []*ConstPoolIndex{&constant.NameAndTypeIndex}
+	case *ModuleRef:
+		return []*ConstPoolIndex{&constant.NameIndex}
+	case *PackageRef:
+		return []*ConstPoolIndex{&constant.NameIndex}
+	}
+
+	return nil
+}
+
+// indexRefs collects pointers to all indexes into the
+// constant pool, found outside of the constant pool.
+type indexRefs struct {
+	indexes []*ConstPoolIndex
+
+	// Decoded bytecode, the indexes of the instructions
+	// aren't part of indexes.
+	code []*codeRefs
+}
+
+type codeRefs struct {
+	attr     *Code
+	insts    []*Instruction
+	bytecode []uint8
+}
+
+func (refs *indexRefs) add(indexes ...*ConstPoolIndex) {
+	refs.indexes = append(refs.indexes, indexes...)
+}
+
+func (refs *indexRefs) addAll(indexes []ConstPoolIndex) {
+	for n := range indexes {
+		refs.add(&indexes[n])
+	}
+}
+
+func (refs *indexRefs) classFile(c *ClassFile) error {
+	refs.add(&c.ThisClass, &c.SuperClass)
+	refs.addAll(c.Interfaces)
+
+	var errs []error
+
+	for _, field := range c.Fields {
+		refs.add(&field.NameIndex, &field.DescriptorIndex)
+		errs = append(errs, refs.attributes(field.Attributes, c.ConstantPool))
+	}
+
+	for _, method := range c.Methods {
+		refs.add(&method.NameIndex, &method.DescriptorIndex)
+		errs = append(errs, refs.attributes(method.Attributes, c.ConstantPool))
+	}
+
+	errs = append(errs, refs.attributes(c.Attributes, c.ConstantPool))
+
+	return multiError(errs)
+}
+
+func (refs *indexRefs) attributes(attrs Attributes, constPool ConstantPool) error {
+	for _, attr := range attrs {
+		err := refs.attribute(attr, constPool)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (refs *indexRefs) attribute(attr Attribute, constPool ConstantPool) error {
+	switch attr := attr.(type) {
+	case *UnknownAttr:
+		name, err := constPool.LookupUTF8(attr.NameIndex)
+		if err != nil {
+			return err
+		}
+
+		return fmt.Errorf("jclass: can't rewrite constant pool indexes of attribute %q", name)
+
+	case *ConstantValue:
+		refs.add(&attr.NameIndex, &attr.Index)
+
+	case *Code:
+		refs.add(&attr.NameIndex)
+
+		insts, err := attr.Instructions()
+		if err != nil {
+			return err
+		}
+
+		refs.code = append(refs.code, &codeRefs{attr: attr, insts: insts})
+
+		// The instructions' indexes are only used for marking,
+		// they're rewritten along with the bytecode.
+		for _, inst := range insts {
+			if inst.Index != 0 {
+				index := inst.Index
+				refs.add(&index)
+			}
+		}
+
+		for n := range attr.ExceptionsTable {
+			refs.add(&attr.ExceptionsTable[n].CatchType)
+		}
+
+		return refs.attributes(attr.Attributes, constPool)
+
+	case *StackMapTable:
+		refs.add(&attr.NameIndex)
+
+		for _, frame := range attr.Entries {
+			var infos []VerificationTypeInfo
+
+			switch frame := frame.(type) {
+			case *SameLocals1StackItemFrame:
+				infos = []VerificationTypeInfo{frame.Stack}
+			case *SameLocals1StackItemFrameExtended:
+				infos = []VerificationTypeInfo{frame.Stack}
+			case *AppendFrame:
+				infos = frame.Locals
+			case *FullFrame:
+				infos = append(append(infos, frame.Locals...), frame.Stack...)
+			}
+
+			for _, info := range infos {
+				if object, ok := info.(*ObjectVariable); ok {
+					refs.add(&object.ClassIndex)
+				}
+			}
+		}
+
+	case *Exceptions:
+		refs.add(&attr.NameIndex)
+		refs.addAll(attr.ExceptionsTable)
+
+	case *InnerClasses:
+		refs.add(&attr.NameIndex)
+		for n := range attr.Classes {
+			class := &attr.Classes[n]
+			refs.add(&class.InnerClassIndex, &class.OuterClassIndex, &class.InnerName)
+		}
+
+	case *EnclosingMethod:
+		refs.add(&attr.NameIndex, &attr.ClassIndex, &attr.MethodIndex)
+
+	case *Synthetic:
+		refs.add(&attr.NameIndex)
+
+	case *Signature:
+		refs.add(&attr.NameIndex, &attr.SignatureIndex)
+
+	case *SourceFile:
+		refs.add(&attr.NameIndex, &attr.SourceFileIndex)
+
+	case *SourceDebugExtension:
+		refs.add(&attr.NameIndex)
+
+	case *LineNumberTable:
+		refs.add(&attr.NameIndex)
+
+	case *LocalVariableTable:
+		refs.add(&attr.NameIndex)
+		for n := range attr.Table {
+			refs.add(&attr.Table[n].NameIndex, &attr.Table[n].DescriptorIndex)
+		}
+
+	case *LocalVariableTypeTable:
+		refs.add(&attr.NameIndex)
+		for n := range attr.Table {
+			refs.add(&attr.Table[n].NameIndex, &attr.Table[n].SignatureIndex)
+		}
+
+	case *Deprecated:
+		refs.add(&attr.NameIndex)
+
+	case *RuntimeVisibleAnnotations:
+		refs.add(&attr.NameIndex)
+		refs.annotations(attr.Annotations)
+
+	case *RuntimeInvisibleAnnotations:
+		refs.add(&attr.NameIndex)
+		refs.annotations(attr.Annotations)
+
+	case *RuntimeVisibleParameterAnnotations:
+		refs.add(&attr.NameIndex)
+		for _, annotations := range attr.Parameters {
+			refs.annotations(annotations)
+		}
+
+	case *RuntimeInvisibleParameterAnnotations:
+		refs.add(&attr.NameIndex)
+		for _, annotations := range attr.Parameters {
+			refs.annotations(annotations)
+		}
+
+	case *AnnotationDefault:
+		refs.add(&attr.NameIndex)
+		refs.elementValue(attr.DefaultValue)
+
+	case *BootstrapMethods:
+		refs.add(&attr.NameIndex)
+		for n := range attr.Methods {
+			refs.add(&attr.Methods[n].MethodRef)
+			refs.addAll(attr.Methods[n].Args)
+		}
+
+	case *RuntimeVisibleTypeAnnotations:
+		refs.add(&attr.NameIndex)
+		for _, annotation := range attr.Annotations {
+			refs.annotation(&annotation.Annotation)
+		}
+
+	case *RuntimeInvisibleTypeAnnotations:
+		refs.add(&attr.NameIndex)
+		for _, annotation := range attr.Annotations {
+			refs.annotation(&annotation.Annotation)
+		}
+
+	case *Module:
+		refs.add(&attr.baseAttribute.NameIndex, &attr.NameIndex, &attr.VersionIndex)
+		for n := range attr.Requires {
+			refs.add(&attr.Requires[n].RequiresIndex, &attr.Requires[n].VersionIndex)
+		}
+		for n := range attr.Exports {
+			refs.add(&attr.Exports[n].ExportsIndex)
+			refs.addAll(attr.Exports[n].ExportsTo)
+		}
+		for n := range attr.Opens {
+			refs.add(&attr.Opens[n].OpensIndex)
+			refs.addAll(attr.Opens[n].OpensTo)
+		}
+		refs.addAll(attr.Uses)
+		for n := range attr.Provides {
+			refs.add(&attr.Provides[n].ProvidesIndex)
+			refs.addAll(attr.Provides[n].ProvidesWith)
+		}
+
+	case *ModulePackages:
+		refs.add(&attr.NameIndex)
+		refs.addAll(attr.Packages)
+
+	case *ModuleMainClass:
+		refs.add(&attr.NameIndex, &attr.MainClassIndex)
+
+	case *NestHost:
+		refs.add(&attr.NameIndex, &attr.HostClassIndex)
+
+	case *NestMembers:
+		refs.add(&attr.NameIndex)
+		refs.addAll(attr.Classes)
+
+	case *Record:
+		refs.add(&attr.NameIndex)
+		for _, component := range attr.Components {
+			refs.add(&component.NameIndex, &component.DescriptorIndex)
+
+			err := refs.attributes(component.Attributes, constPool)
+			if err != nil {
+				return err
+			}
+		}
+
+	case *PermittedSubclasses:
+		refs.add(&attr.NameIndex)
+		refs.addAll(attr.Classes)
+
+	case *MethodParameters:
+		refs.add(&attr.NameIndex)
+		for n := range attr.Parameters {
+			refs.add(&attr.Parameters[n].NameIndex)
+		}
+
+	default:
+		return errors.New("jclass: unsupported attribute")
+	}
+
+	return nil
+}
+
+func (refs *indexRefs) annotations(annotations []*Annotation) {
+	for _, annotation := range annotations {
+		refs.annotation(annotation)
+	}
+}
+
+func (refs *indexRefs) annotation(annotation *Annotation) {
+	refs.add(&annotation.TypeIndex)
+
+	for n := range annotation.Pairs {
+		refs.add(&annotation.Pairs[n].NameIndex)
+		refs.elementValue(annotation.Pairs[n].Value)
+	}
+}
+
+func (refs *indexRefs) elementValue(value ElementValue) {
+	switch value := value.(type) {
+	case *ConstElementValue:
+		refs.add(&value.ConstValueIndex)
+	case *EnumElementValue:
+		refs.add(&value.TypeNameIndex, &value.ConstNameIndex)
+	case *ClassElementValue:
+		refs.add(&value.ClassInfoIndex)
+	case *AnnotationElementValue:
+		refs.annotation(&value.Annotation)
+	case *ArrayElementValue:
+		for _, v := range value.Values {
+			refs.elementValue(v)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package class
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// deadConstantsClass returns HelloWorld with dead constants added
+// to its constant pool, before and between constants referenced
+// from bytecode, a BootstrapMethods, StackMapTable and
+// LocalVariableTable attribute. It returns the number of
+// constant pool slots taken up by the dead constants.
+func deadConstantsClass(t *testing.T) (*ClassFile, int) {
+	_, c := readHelloWorld(t)
+	b := c.ConstPoolBuilder()
+
+	b.AddUTF8("dead")
+	b.AddLong(1 << 40)
+	b.AddClass("Dead")
+
+	object := b.AddClass("java/lang/StringBuilder")
+	str := b.AddString("live")
+	b.AddMethodType("(Ldead;)V")
+	indy := b.AddInvokeDynamic(0, "run", "()V")
+	bsm := b.AddMethodHandle(6, b.AddMethodRef("Boot", "bsm", "()V"))
+	arg := b.AddInteger(7)
+	b.AddDouble(2.5)
+
+	bootstrapName := b.AddUTF8("BootstrapMethods")
+	stackMapName := b.AddUTF8("StackMapTable")
+	localsName := b.AddUTF8("LocalVariableTable")
+	argsName, argsDesc := b.AddUTF8("args"), b.AddUTF8("[Ljava/lang/String;")
+	b.AddUTF8("dead again")
+
+	if b.Err() != nil {
+		t.Fatal(b.Err())
+	}
+
+	code := c.Methods[1].Code()
+	code.ByteCode = []uint8{
+		uint8(GETSTATIC), 0, 5,
+		uint8(LDC_W), uint8(str >> 8), uint8(str),
+		uint8(INVOKEVIRTUAL), 0, 7,
+		uint8(INVOKEDYNAMIC), uint8(indy >> 8), uint8(indy), 0, 0,
+		uint8(LDC), 6,
+		uint8(GOTO), 0, 3,
+		uint8(RETURN),
+	}
+	code.Attributes = Attributes{
+		code.Attributes[0],
+		&StackMapTable{baseAttribute{NameIndex: stackMapName}, []StackMapFrame{
+			&SameLocals1StackItemFrame{baseFrame{SAME_LOCALS_1_STACK_ITEM_FRAME + 19},
+				&ObjectVariable{baseVerificationType{ITEM_Object}, object}},
+		}},
+		&LocalVariableTable{baseAttribute{NameIndex: localsName}, []LocalVariable{
+			{0, 20, argsName, argsDesc, 0},
+		}},
+	}
+
+	c.Attributes = append(c.Attributes, &BootstrapMethods{baseAttribute{NameIndex: bootstrapName}, []BootstrapMethod{
+		{bsm, []ConstPoolIndex{arg}},
+	}})
+
+	parsed, err := Parse(bytes.NewReader(dump(t, c)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// "dead", the Long, "Dead" and its Class, the MethodType
+	// and its descriptor, the Double and "dead again"
+	return parsed, 10
+}
+
+// resolve renders the constant at index with all
+// the constants it refers to.
+func resolve(c *ClassFile, index ConstPoolIndex) string {
+	if index == 0 {
+		return "none"
+	}
+
+	if int(index) > len(c.ConstantPool) || c.ConstantPool[index-1] == nil {
+		return fmt.Sprintf("invalid #%d", index)
+	}
+
+	constant := c.ConstantPool[index-1]
+
+	children := constantIndexes(constant)
+	if len(children) == 0 {
+		return fmt.Sprintf("%v", constant)
+	}
+
+	resolved := constantNames[constant.GetTag()]
+	for _, child := range children {
+		resolved += " " + resolve(c, *child)
+	}
+
+	return "(" + resolved + ")"
+}
+
+// resolvedOperands returns all references into the constant pool,
+// that are relevant to deadConstantsClass, resolved.
+func resolvedOperands(t *testing.T, c *ClassFile) []string {
+	resolved := []string{resolve(c, c.ThisClass), resolve(c, c.SuperClass)}
+
+	var attributes func(attrs Attributes)
+	attributes = func(attrs Attributes) {
+		for _, attr := range attrs {
+			resolved = append(resolved, resolve(c, ConstPoolIndex(byteOrder.Uint16(dump(t, attr)))))
+
+			switch attr := attr.(type) {
+			case *Code:
+				insts, err := attr.Instructions()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, inst := range insts {
+					switch opcodes[inst.Opcode].format {
+					case operandConstPoolByte, operandConstPool, operandInvokeInterface,
+						operandInvokeDynamic, operandMultiANewArray:
+						resolved = append(resolved, inst.Mnemonic()+" "+resolve(c, inst.Index))
+					}
+				}
+
+				attributes(attr.Attributes)
+
+			case *StackMapTable:
+				frame := attr.Entries[0].(*SameLocals1StackItemFrame)
+				resolved = append(resolved, resolve(c, frame.Stack.(*ObjectVariable).ClassIndex))
+
+			case *LocalVariableTable:
+				for _, v := range attr.Table {
+					resolved = append(resolved, resolve(c, v.NameIndex), resolve(c, v.DescriptorIndex))
+				}
+
+			case *BootstrapMethods:
+				for _, method := range attr.Methods {
+					resolved = append(resolved, resolve(c, method.MethodRef))
+					for _, arg := range method.Args {
+						resolved = append(resolved, resolve(c, arg))
+					}
+				}
+
+			case *ConstantValue:
+				resolved = append(resolved, resolve(c, attr.Index))
+
+			case *Signature:
+				resolved = append(resolved, resolve(c, attr.SignatureIndex))
+
+			case *SourceFile:
+				resolved = append(resolved, resolve(c, attr.SourceFileIndex))
+			}
+		}
+	}
+
+	for _, field := range c.Fields {
+		resolved = append(resolved, resolve(c, field.NameIndex), resolve(c, field.DescriptorIndex))
+		attributes(field.Attributes)
+	}
+
+	for _, method := range c.Methods {
+		resolved = append(resolved, resolve(c, method.NameIndex), resolve(c, method.DescriptorIndex))
+		attributes(method.Attributes)
+	}
+
+	attributes(c.Attributes)
+
+	return resolved
+}
+
+func TestCompactConstPool(t *testing.T) {
+	c, dead := deadConstantsClass(t)
+
+	size := c.ConstPoolSize
+	before := resolvedOperands(t, c)
+
+	if err := c.CompactConstPool(); err != nil {
+		t.Fatal(err)
+	}
+
+	compacted, err := Parse(bytes.NewReader(dump(t, c)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if int(compacted.ConstPoolSize) != int(size)-dead {
+		t.Errorf("ConstPoolSize %d, want %d", compacted.ConstPoolSize, int(size)-dead)
+	}
+
+	if after := resolvedOperands(t, compacted); !reflect.DeepEqual(after, before) {
+		for n := range before {
+			if n >= len(after) || after[n] != before[n] {
+				t.Errorf("operand %d changed from %s", n, before[n])
+			}
+		}
+	}
+
+	// Compacting again doesn't remove anything
+	if err := compacted.CompactConstPool(); err != nil {
+		t.Fatal(err)
+	}
+
+	if int(compacted.ConstPoolSize) != int(size)-dead {
+		t.Errorf("ConstPoolSize %d after compacting twice", compacted.ConstPoolSize)
+	}
+
+	if _, err := compacted.View(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCompactConstPoolInvalidIndex(t *testing.T) {
+	// #3 is the second slot of a Long, #49 is past the last constant
+	for _, index := range []ConstPoolIndex{3, 49, 9999} {
+		_, c := readHelloWorld(t)
+		before := dump(t, c)
+
+		nameIndex := c.Fields[0].NameIndex
+
+		c.Fields[0].NameIndex = index
+		if err := c.CompactConstPool(); err == nil {
+			t.Errorf("invalid index #%d not reported", index)
+		}
+
+		c.Fields[0].NameIndex = nameIndex
+		if !bytes.Equal(dump(t, c), before) {
+			t.Errorf("failed compaction of #%d changed the class", index)
+		}
+	}
+}
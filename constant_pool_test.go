@@ -0,0 +1,110 @@
+package class
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	_, c := readHelloWorld(t)
+
+	tests := []struct {
+		index    ConstPoolIndex
+		expected ConstantType
+		actual   ConstantType
+	}{
+		{0, CONSTANT_UTF8, 0},
+		{2, CONSTANT_UTF8, CONSTANT_Long},
+		{3, CONSTANT_UTF8, 0}, // second slot of the Long
+		{9999, CONSTANT_Class, 0},
+	}
+
+	for _, test := range tests {
+		var err error
+		if test.expected == CONSTANT_Class {
+			_, err = c.LookupClass(test.index)
+		} else {
+			_, err = c.LookupUTF8(test.index)
+		}
+
+		var constErr *ConstantError
+		if !errors.As(err, &constErr) || constErr.Index != test.index ||
+			constErr.Expected != test.expected || constErr.Actual != test.actual {
+			t.Errorf("lookup of #%d: got %v", test.index, err)
+		}
+	}
+
+	if name, err := c.LookupUTF8(c.Fields[0].NameIndex); err != nil || name != "myField" {
+		t.Errorf("got %q, %v", name, err)
+	}
+}
+
+// Resolution helpers return errors instead of panicking
+// for invalid constant pool indexes.
+func TestResolveInvalidIndex(t *testing.T) {
+	_, c := readHelloWorld(t)
+	const invalid = 9999
+
+	annotation := &Annotation{TypeIndex: invalid, Pairs: []ElementValuePair{{NameIndex: invalid}}}
+	if _, err := annotation.TypeName(c.ConstantPool); err == nil {
+		t.Error("TypeName: no error")
+	}
+
+	if _, err := annotation.Element(c.ConstantPool, "value"); err == nil {
+		t.Error("Element: no error")
+	}
+
+	attrs := Attributes{&RuntimeVisibleAnnotations{Annotations: []*Annotation{annotation}}}
+	if _, err := attrs.FindAnnotation(c.ConstantPool, "Ljava/lang/Deprecated;"); err == nil {
+		t.Error("FindAnnotation: no error")
+	}
+
+	value := &ConstElementValue{baseElementValue{ELEMENT_VALUE_Int}, 2}
+	if _, err := value.Value(c.ConstantPool); err == nil {
+		t.Error("Value of a Long as int: no error")
+	}
+
+	method := c.Methods[0]
+	method.DescriptorIndex = invalid
+	if _, err := method.Parameters(c.ConstantPool); err == nil {
+		t.Error("Parameters: no error")
+	}
+
+	c.Attributes = append(c.Attributes,
+		&Module{NameIndex: invalid},
+		&Record{Components: []*RecordComponent{{NameIndex: invalid}}},
+		&NestMembers{Classes: []ConstPoolIndex{invalid}},
+	)
+
+	if _, err := c.ModuleDescriptor(); err == nil {
+		t.Error("ModuleDescriptor: no error")
+	}
+
+	if _, err := c.RecordComponents(); err == nil {
+		t.Error("RecordComponents: no error")
+	}
+
+	if _, err := c.NestMemberNames(); err == nil {
+		t.Error("NestMemberNames: no error")
+	}
+}
+
+func TestAs(t *testing.T) {
+	_, c := readHelloWorld(t)
+
+	code, err := As[*Code](c.Methods[0].Attributes[0])
+	if err != nil || code != c.Methods[0].Code() {
+		t.Errorf("got %v, %v", code, err)
+	}
+
+	_, err = As[*SourceFile](c.Methods[0].Attributes[0])
+
+	var attrErr *AttributeError
+	if !errors.As(err, &attrErr) || attrErr.Expected != SourceFileTag || attrErr.Actual != CodeTag {
+		t.Errorf("got %v", err)
+	}
+
+	if _, err := As[*Code](nil); err == nil {
+		t.Error("nil attribute: no error")
+	}
+}
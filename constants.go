@@ -19,7 +19,26 @@ const (
 	CONSTANT_NameAndType                     = 12
 	CONSTANT_MethodHandle                    = 15
 	CONSTANT_MethodType                      = 16
+	CONSTANT_Dynamic                         = 17
 	CONSTANT_InvokeDynamic                   = 18
+	CONSTANT_Module                          = 19
+	CONSTANT_Package                         = 20
+)
+
+// These constants are the possible values for the
+// reference kind of a CONSTANT_MethodHandle_info, which
+// characterize the bytecode behavior of the method handle.
+// http://docs.oracle.com/javase/specs/jvms/se7/html/jvms-5.html#jvms-5.4.3.5
+const (
+	REF_getField         uint8 = 1
+	REF_getStatic              = 2
+	REF_putField               = 3
+	REF_putStatic              = 4
+	REF_invokeVirtual          = 5
+	REF_invokeStatic           = 6
+	REF_invokeSpecial          = 7
+	REF_newInvokeSpecial       = 8
+	REF_invokeInterface        = 9
 )
 
 // These constants describe access flags that can
@@ -34,6 +53,7 @@ const (
 	CLASS_ACC_SYNTHETIC              = 0x1000 // Declared synthetic; not present in the source code.
 	CLASS_ACC_ANNOTATION             = 0x2000 // Declared as an annotation type.
 	CLASS_ACC_ENUM                   = 0x4000 // Declared as an enum type.
+	CLASS_ACC_MODULE                 = 0x8000 // Is a module, not a class or interface.
 )
 
 // These constant define access flags and attributes
@@ -69,6 +89,15 @@ const (
 	METHOD_ACC_SYNTHETIC                = 0x1000 // Declared synthetic; not present in the source code.
 )
 
+// These constants describe properties of a formal parameter
+// of a method, as found in the MethodParameters attribute.
+// https://docs.oracle.com/javase/specs/jvms/se8/html/jvms-4.html#jvms-4.7.24
+const (
+	PARAMETER_ACC_FINAL     AccessFlags = 0x0010 // Declared final.
+	PARAMETER_ACC_SYNTHETIC             = 0x1000 // Not explicitly or implicitly declared in source code.
+	PARAMETER_ACC_MANDATED              = 0x8000 // Implicitly declared in source code.
+)
+
 // These constants define valid access flags and properties
 // for inner classes of a class or interface.
 // http://docs.oracle.com/javase/specs/jvms/se7/html/jvms-4.html#jvms-4.7.6-300-D.2-5
@@ -85,6 +114,34 @@ const (
 	NESTED_CLASS_ACC_ENUM                   = 0x4000 // Declared as an enum type.
 )
 
+// These constants describe flags of a module, as found
+// in the Module attribute.
+// https://docs.oracle.com/javase/specs/jvms/se9/html/jvms-4.html#jvms-4.7.25
+const (
+	MODULE_ACC_OPEN      AccessFlags = 0x0020 // Is an open module.
+	MODULE_ACC_SYNTHETIC             = 0x1000 // Not explicitly or implicitly declared.
+	MODULE_ACC_MANDATED              = 0x8000 // Implicitly declared.
+)
+
+// These constants describe flags of a dependence of a module.
+// https://docs.oracle.com/javase/specs/jvms/se9/html/jvms-4.html#jvms-4.7.25
+const (
+	REQUIRES_ACC_TRANSITIVE   AccessFlags = 0x0020 // Any module depending on this one, implicitly depends on the required module.
+	REQUIRES_ACC_STATIC_PHASE             = 0x0040 // Dependence is mandatory at compile time, but optional at run time.
+	REQUIRES_ACC_SYNTHETIC                = 0x1000 // Not explicitly or implicitly declared.
+	REQUIRES_ACC_MANDATED                 = 0x8000 // Implicitly declared.
+)
+
+// These constants describe flags of a package exported
+// or opened by a module.
+// https://docs.oracle.com/javase/specs/jvms/se9/html/jvms-4.html#jvms-4.7.25
+const (
+	EXPORTS_ACC_SYNTHETIC AccessFlags = 0x1000 // Not explicitly or implicitly declared.
+	EXPORTS_ACC_MANDATED              = 0x8000 // Implicitly declared.
+	OPENS_ACC_SYNTHETIC               = 0x1000 // Not explicitly or implicitly declared.
+	OPENS_ACC_MANDATED                = 0x8000 // Implicitly declared.
+)
+
 // These tags describe types of attributes, and can be
 // used to determine what type to cast a generic Attribute
 // to. They have the same use case as the ConstantType tags.
@@ -111,4 +168,14 @@ const (
 	RuntimeInvisibleParameterAnnotationsTag
 	AnnotationDefaultTag
 	BootstrapMethodsTag
+	RuntimeVisibleTypeAnnotationsTag
+	RuntimeInvisibleTypeAnnotationsTag
+	ModuleTag
+	ModulePackagesTag
+	ModuleMainClassTag
+	NestHostTag
+	NestMembersTag
+	RecordTag
+	PermittedSubclassesTag
+	MethodParametersTag
 )
@@ -0,0 +1,253 @@
+// Package descriptor parses field and method descriptors, as
+// found in Java class files, into a typed model.
+// http://docs.oracle.com/javase/specs/jvms/se7/html/jvms-4.html#jvms-4.3
+package descriptor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The maximum number of array dimensions in a descriptor.
+const MaxDimensions = 255
+
+// A Type is the type of a field, parameter or return value.
+// It's one of Primitive, Object or Array.
+type Type interface {
+	// String returns the type's descriptor, e.g. "[I".
+	String() string
+
+	// Java returns the type as it's written in Java
+	// source, e.g. "int[]".
+	Java() string
+
+	// Slots returns the number of local variable slots
+	// taken up by a value of the type: two for long and
+	// double, zero for void and one for all others.
+	Slots() int
+}
+
+// A Primitive is a primitive type (or void), represented
+// by its descriptor character.
+type Primitive byte
+
+// Every constant is typed, so they can be used as a Type.
+const (
+	Byte    Primitive = 'B'
+	Char    Primitive = 'C'
+	Double  Primitive = 'D'
+	Float   Primitive = 'F'
+	Int     Primitive = 'I'
+	Long    Primitive = 'J'
+	Short   Primitive = 'S'
+	Boolean Primitive = 'Z'
+
+	// Only valid as return type of a method.
+	Void Primitive = 'V'
+)
+
+var primitiveNames = map[Primitive]string{
+	Byte:    "byte",
+	Char:    "char",
+	Double:  "double",
+	Float:   "float",
+	Int:     "int",
+	Long:    "long",
+	Short:   "short",
+	Boolean: "boolean",
+	Void:    "void",
+}
+
+func (p Primitive) String() string { return string(p) }
+func (p Primitive) Java() string   { return primitiveNames[p] }
+
+func (p Primitive) Slots() int {
+	switch p {
+	case Long, Double:
+		return 2
+	case Void:
+		return 0
+	}
+
+	return 1
+}
+
+// An Object is a class or interface type.
+type Object struct {
+	// The internal name of the class, e.g. java/lang/String.
+	ClassName string
+}
+
+func (o Object) String() string { return "L" + o.ClassName + ";" }
+func (o Object) Java() string   { return strings.Replace(o.ClassName, "/", ".", -1) }
+func (o Object) Slots() int     { return 1 }
+
+// An Array is an array type with Dimensions dimensions
+// of the Element type, which is never an Array.
+type Array struct {
+	Dimensions int
+	Element    Type
+}
+
+func (a Array) String() string { return strings.Repeat("[", a.Dimensions) + a.Element.String() }
+func (a Array) Java() string   { return a.Element.Java() + strings.Repeat("[]", a.Dimensions) }
+func (a Array) Slots() int     { return 1 }
+
+// A Method is the parsed descriptor of a method.
+type Method struct {
+	Params []Type
+
+	// Return type, Void if the method doesn't return a value.
+	Return Type
+}
+
+// String returns the method's descriptor,
+// e.g. "(I[Ljava/lang/String;)V".
+func (m *Method) String() string {
+	var desc strings.Builder
+
+	desc.WriteByte('(')
+	for _, param := range m.Params {
+		desc.WriteString(param.String())
+	}
+	desc.WriteByte(')')
+	desc.WriteString(m.Return.String())
+
+	return desc.String()
+}
+
+// Java returns the declaration of a method with the
+// descriptor and name as it's written in Java source,
+// without parameter names, e.g. "void main(java.lang.String[])".
+func (m *Method) Java(name string) string {
+	params := make([]string, 0, len(m.Params))
+	for _, param := range m.Params {
+		params = append(params, param.Java())
+	}
+
+	return m.Return.Java() + " " + name + "(" + strings.Join(params, ", ") + ")"
+}
+
+// ParamSlots returns the number of local variable slots taken
+// up by the parameters. For instance methods the this reference
+// takes up another slot, which isn't included.
+func (m *Method) ParamSlots() int {
+	slots := 0
+	for _, param := range m.Params {
+		slots += param.Slots()
+	}
+
+	return slots
+}
+
+// ParseField parses a field descriptor, e.g. "[Ljava/lang/String;".
+func ParseField(desc string) (Type, error) {
+	t, n, err := parseType(desc, false)
+	if err != nil || n != len(desc) {
+		return nil, fmt.Errorf("jclass: invalid field descriptor %q", desc)
+	}
+
+	return t, nil
+}
+
+// ParseMethod parses a method descriptor, e.g. "(I[Ljava/lang/String;)V".
+func ParseMethod(desc string) (*Method, error) {
+	errInvalid := fmt.Errorf("jclass: invalid method descriptor %q", desc)
+
+	if len(desc) == 0 || desc[0] != '(' {
+		return nil, errInvalid
+	}
+
+	m := &Method{Params: []Type{}}
+
+	i := 1
+	for i < len(desc) && desc[i] != ')' {
+		param, n, err := parseType(desc[i:], false)
+		if err != nil {
+			return nil, errInvalid
+		}
+
+		m.Params = append(m.Params, param)
+		i += n
+	}
+
+	if i >= len(desc) {
+		return nil, errInvalid
+	}
+
+	result, n, err := parseType(desc[i+1:], true)
+	if err != nil || i+1+n != len(desc) {
+		return nil, errInvalid
+	}
+
+	m.Return = result
+
+	return m, nil
+}
+
+// parseType parses the type at the start of desc
+// and returns it along with its length in bytes.
+func parseType(desc string, allowVoid bool) (Type, int, error) {
+	dims := 0
+	for dims < len(desc) && desc[dims] == '[' {
+		dims++
+	}
+
+	if dims > MaxDimensions {
+		return nil, 0, fmt.Errorf("jclass: array with %d dimensions", dims)
+	}
+
+	if dims >= len(desc) {
+		return nil, 0, fmt.Errorf("jclass: missing type")
+	}
+
+	var t Type
+	n := dims + 1
+
+	switch c := Primitive(desc[dims]); c {
+	case Byte, Char, Double, Float, Int, Long, Short, Boolean:
+		t = c
+
+	case Void:
+		if !allowVoid || dims > 0 {
+			return nil, 0, fmt.Errorf("jclass: invalid use of void")
+		}
+		t = c
+
+	case 'L':
+		end := strings.IndexByte(desc[dims:], ';')
+		if end < 0 {
+			return nil, 0, fmt.Errorf("jclass: unterminated class name")
+		}
+
+		name := desc[dims+1 : dims+end]
+		if !validClassName(name) {
+			return nil, 0, fmt.Errorf("jclass: invalid class name %q", name)
+		}
+
+		t = Object{ClassName: name}
+		n = dims + end + 1
+
+	default:
+		return nil, 0, fmt.Errorf("jclass: invalid type %q", desc[dims])
+	}
+
+	if dims > 0 {
+		t = Array{Dimensions: dims, Element: t}
+	}
+
+	return t, n, nil
+}
+
+// validClassName reports whether name is a valid internal
+// class name, i.e. it consists of non-empty identifiers
+// separated by '/'.
+func validClassName(name string) bool {
+	for _, identifier := range strings.Split(name, "/") {
+		if identifier == "" || strings.ContainsAny(identifier, ".;[") {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,123 @@
+package descriptor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMethod(t *testing.T) {
+	tests := []struct {
+		desc  string
+		valid bool
+	}{
+		{"()V", true},
+		{"(I[Ljava/lang/String;)V", true},
+		{"(JD)J", true},
+		{"([[Ljava/util/List;Z)[I", true},
+		{"()", false},
+		{"(V)V", false},
+		{"([V)V", false},
+		{"()[V", false},
+		{"(L;)V", false},
+		{"(Ljava//a;)V", false},
+		{"(Ljava/lang/String)V", false},
+		{"(I", false},
+		{"I)V", false},
+		{"()VV", false},
+		{"(" + strings.Repeat("[", MaxDimensions) + "I)V", true},
+		{"(" + strings.Repeat("[", MaxDimensions+1) + "I)V", false},
+	}
+
+	for _, test := range tests {
+		m, err := ParseMethod(test.desc)
+		if (err == nil) != test.valid {
+			t.Errorf("%q: got error %v", test.desc, err)
+			continue
+		}
+
+		if err == nil && m.String() != test.desc {
+			t.Errorf("%q: got %q", test.desc, m.String())
+		}
+	}
+}
+
+func TestParseField(t *testing.T) {
+	tests := []struct {
+		desc  string
+		valid bool
+	}{
+		{"I", true},
+		{"Ljava/lang/Object;", true},
+		{"[[D", true},
+		{"V", false},
+		{"[V", false},
+		{"L;", false},
+		{"Ljava//a;", false},
+		{"La.b;", false},
+		{"II", false},
+		{"", false},
+		{"[", false},
+		{strings.Repeat("[", MaxDimensions) + "I", true},
+		{strings.Repeat("[", MaxDimensions+1) + "I", false},
+	}
+
+	for _, test := range tests {
+		typ, err := ParseField(test.desc)
+		if (err == nil) != test.valid {
+			t.Errorf("%q: got error %v", test.desc, err)
+			continue
+		}
+
+		if err == nil && typ.String() != test.desc {
+			t.Errorf("%q: got %q", test.desc, typ.String())
+		}
+	}
+}
+
+func TestParamSlots(t *testing.T) {
+	tests := []struct {
+		desc  string
+		slots int
+	}{
+		{"()V", 0},
+		{"(I)V", 1},
+		{"(J)V", 2},
+		{"(D)V", 2},
+		{"(JID)J", 5},
+		{"([J[D)V", 2},
+		{"(Ljava/lang/String;J)D", 3},
+	}
+
+	for _, test := range tests {
+		m, err := ParseMethod(test.desc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if slots := m.ParamSlots(); slots != test.slots {
+			t.Errorf("%q: got %d slots, expected %d", test.desc, slots, test.slots)
+		}
+	}
+}
+
+func TestJava(t *testing.T) {
+	tests := []struct {
+		desc string
+		java string
+	}{
+		{"([Ljava/lang/String;)V", "void main(java.lang.String[])"},
+		{"(IJ[[D)Ljava/lang/Object;", "java.lang.Object main(int, long, double[][])"},
+		{"()Z", "boolean main()"},
+	}
+
+	for _, test := range tests {
+		m, err := ParseMethod(test.desc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if java := m.Java("main"); java != test.java {
+			t.Errorf("%q: got %q, expected %q", test.desc, java, test.java)
+		}
+	}
+}
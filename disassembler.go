@@ -0,0 +1,1403 @@
+package class
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jcla1/jclass/descriptor"
+)
+
+// Disassemble writes a textual listing of the class file to w:
+// the header, the constant pool, all fields and methods (with
+// their disassembled code) and all attributes. The layout follows
+// the one of `javap -c -v` closely, so that both can be diffed.
+// Malformed constant pool references are printed as <invalid #n>,
+// instead of causing a panic.
+func (c *ClassFile) Disassemble(w io.Writer) error {
+	d := &disassembler{w: w, c: c}
+
+	d.header()
+	d.constantPool()
+
+	d.printf("{\n")
+	for n, field := range c.Fields {
+		if n > 0 {
+			d.printf("\n")
+		}
+		d.field(field)
+	}
+	for n, method := range c.Methods {
+		if n > 0 || len(c.Fields) > 0 {
+			d.printf("\n")
+		}
+		d.method(method)
+	}
+	d.printf("}\n")
+
+	d.attributes(c.Attributes, "")
+
+	return d.err
+}
+
+// Absolute columns at which the // comments start, in the
+// constant pool / attributes and in the code listing.
+const (
+	commentColumn     = 42
+	codeCommentColumn = 46
+)
+
+type disassembler struct {
+	w   io.Writer
+	c   *ClassFile
+	err error
+
+	// the method whose attributes are printed, or nil
+	current *Method
+}
+
+func (d *disassembler) printf(format string, args ...interface{}) {
+	if d.err != nil {
+		return
+	}
+
+	_, d.err = fmt.Fprintf(d.w, format, args...)
+}
+
+// commented prints line, followed by comment at column,
+// if there is a comment.
+func (d *disassembler) commented(line string, column int, comment string) {
+	if comment != "" {
+		if len(line) < column {
+			line += strings.Repeat(" ", column-len(line))
+		} else {
+			line += " "
+		}
+
+		line += "// " + comment
+	}
+
+	d.printf("%s\n", line)
+}
+
+func (d *disassembler) constant(index ConstPoolIndex) Constant {
+	if index == 0 || int(index) > len(d.c.ConstantPool) {
+		return nil
+	}
+
+	return d.c.ConstantPool[index-1]
+}
+
+func (d *disassembler) utf8(index ConstPoolIndex) string {
+	if constant, ok := d.constant(index).(*UTF8Ref); ok {
+		return constant.Value
+	}
+
+	return fmt.Sprintf("<invalid #%d>", index)
+}
+
+func (d *disassembler) className(index ConstPoolIndex) string {
+	if constant, ok := d.constant(index).(*ClassRef); ok {
+		return d.utf8(constant.NameIndex)
+	}
+
+	return fmt.Sprintf("<invalid #%d>", index)
+}
+
+// javaClassName returns the name of the class at index,
+// as it's written in Java source (e.g. "java.lang.Object").
+func (d *disassembler) javaClassName(index ConstPoolIndex) string {
+	name := d.className(index)
+	if strings.HasPrefix(name, "[") {
+		return javaTypeName(name)
+	}
+
+	return strings.Replace(name, "/", ".", -1)
+}
+
+func (d *disassembler) nameAndType(index ConstPoolIndex) string {
+	if constant, ok := d.constant(index).(*NameAndTypeRef); ok {
+		return quoteName(d.utf8(constant.NameIndex)) + ":" + d.utf8(constant.DescriptorIndex)
+	}
+
+	return fmt.Sprintf("<invalid #%d>", index)
+}
+
+// memberRef describes a field or method reference, the class
+// is left out, if omitThis is set and it's the current class.
+func (d *disassembler) memberRef(ref *fieldMethodInterfaceRef, omitThis bool) string {
+	nameAndType := d.nameAndType(ref.NameAndTypeIndex)
+	if omitThis && ref.ClassIndex == d.c.ThisClass {
+		return nameAndType
+	}
+
+	return quoteName(d.className(ref.ClassIndex)) + "." + nameAndType
+}
+
+func (d *disassembler) header() {
+	c := d.c
+
+	for _, attr := range c.Attributes {
+		if sourceFile, ok := attr.(*SourceFile); ok {
+			d.printf("  Compiled from \"%s\"\n", escapeString(d.utf8(sourceFile.SourceFileIndex)))
+		}
+	}
+
+	decl := modifiers(c.AccessFlags, classFlags)
+
+	switch {
+	case c.AccessFlags&CLASS_ACC_MODULE != 0:
+		decl = append(decl, "module")
+	case c.AccessFlags&CLASS_ACC_ANNOTATION != 0:
+		decl = append(decl, "@interface")
+	case c.AccessFlags&CLASS_ACC_INTERFACE != 0:
+		decl = append(decl, "interface")
+	default:
+		decl = append(decl, "class")
+	}
+
+	if c.AccessFlags&CLASS_ACC_MODULE != 0 {
+		if module := d.moduleAttr(); module != nil {
+			decl = append(decl, d.moduleName(module.NameIndex))
+		}
+	} else {
+		decl = append(decl, d.javaClassName(c.ThisClass))
+	}
+
+	var interfaces []string
+	for _, index := range c.Interfaces {
+		interfaces = append(interfaces, d.javaClassName(index))
+	}
+
+	if c.AccessFlags&CLASS_ACC_INTERFACE != 0 {
+		if len(interfaces) > 0 {
+			decl = append(decl, "extends", strings.Join(interfaces, ", "))
+		}
+	} else {
+		if c.SuperClass != 0 && d.className(c.SuperClass) != "java/lang/Object" {
+			decl = append(decl, "extends", d.javaClassName(c.SuperClass))
+		}
+
+		if len(interfaces) > 0 {
+			decl = append(decl, "implements", strings.Join(interfaces, ", "))
+		}
+	}
+
+	d.printf("%s\n", strings.Join(decl, " "))
+	d.printf("  minor version: %d\n", c.MinorVersion)
+	d.printf("  major version: %d\n", c.MajorVersion)
+	d.printf("  flags: %s\n", flagNames(c.AccessFlags, classFlags))
+	d.commented(fmt.Sprintf("  this_class: #%d", c.ThisClass), commentColumn, d.className(c.ThisClass))
+	if c.SuperClass != 0 {
+		d.commented(fmt.Sprintf("  super_class: #%d", c.SuperClass), commentColumn, d.className(c.SuperClass))
+	} else {
+		d.printf("  super_class: #0\n")
+	}
+	d.printf("  interfaces: %d, fields: %d, methods: %d, attributes: %d\n",
+		len(c.Interfaces), len(c.Fields), len(c.Methods), len(c.Attributes))
+}
+
+var constantNames = map[ConstantType]string{
+	CONSTANT_UTF8:               "Utf8",
+	CONSTANT_Integer:            "Integer",
+	CONSTANT_Float:              "Float",
+	CONSTANT_Long:               "Long",
+	CONSTANT_Double:             "Double",
+	CONSTANT_Class:              "Class",
+	CONSTANT_String:             "String",
+	CONSTANT_FieldRef:           "Fieldref",
+	CONSTANT_MethodRef:          "Methodref",
+	CONSTANT_InterfaceMethodRef: "InterfaceMethodref",
+	CONSTANT_NameAndType:        "NameAndType",
+	CONSTANT_MethodHandle:       "MethodHandle",
+	CONSTANT_MethodType:         "MethodType",
+	CONSTANT_Dynamic:            "Dynamic",
+	CONSTANT_InvokeDynamic:      "InvokeDynamic",
+	CONSTANT_Module:             "Module",
+	CONSTANT_Package:            "Package",
+}
+
+var referenceKindNames = map[uint8]string{
+	REF_getField:         "REF_getField",
+	REF_getStatic:        "REF_getStatic",
+	REF_putField:         "REF_putField",
+	REF_putStatic:        "REF_putStatic",
+	REF_invokeVirtual:    "REF_invokeVirtual",
+	REF_invokeStatic:     "REF_invokeStatic",
+	REF_invokeSpecial:    "REF_invokeSpecial",
+	REF_newInvokeSpecial: "REF_newInvokeSpecial",
+	REF_invokeInterface:  "REF_invokeInterface",
+}
+
+func (d *disassembler) constantPool() {
+	d.printf("Constant pool:\n")
+
+	for n, constant := range d.c.ConstantPool {
+		// the second slot of a long or double
+		if constant == nil {
+			continue
+		}
+
+		line := fmt.Sprintf("%5s = %-18s %s", fmt.Sprintf("#%d", n+1),
+			constantNames[constant.GetTag()], d.constantOperands(constant))
+
+		d.commented(line, commentColumn, d.constantComment(constant))
+	}
+}
+
+// constantOperands returns the raw contents of a constant,
+// e.g. "#6.#15" for a method reference.
+func (d *disassembler) constantOperands(constant Constant) string {
+	switch constant := constant.(type) {
+	case *ClassRef:
+		return fmt.Sprintf("#%d", constant.NameIndex)
+	case *FieldRef:
+		return fmt.Sprintf("#%d.#%d", constant.ClassIndex, constant.NameAndTypeIndex)
+	case *MethodRef:
+		return fmt.Sprintf("#%d.#%d", constant.ClassIndex, constant.NameAndTypeIndex)
+	case *InterfaceMethodRef:
+		return fmt.Sprintf("#%d.#%d", constant.ClassIndex, constant.NameAndTypeIndex)
+	case *StringRef:
+		return fmt.Sprintf("#%d", constant.Index)
+	case *IntegerRef:
+		return strconv.Itoa(int(constant.Value))
+	case *FloatRef:
+		return javaFloat(float64(constant.Value), 32) + "f"
+	case *LongRef:
+		return strconv.FormatInt(constant.Value, 10) + "l"
+	case *DoubleRef:
+		return javaFloat(constant.Value, 64) + "d"
+	case *NameAndTypeRef:
+		return fmt.Sprintf("#%d:#%d", constant.NameIndex, constant.DescriptorIndex)
+	case *UTF8Ref:
+		return escapeString(constant.Value)
+	case *MethodHandleRef:
+		return fmt.Sprintf("%d:#%d", constant.ReferenceKind, constant.ReferenceIndex)
+	case *MethodTypeRef:
+		return fmt.Sprintf("#%d", constant.DescriptorIndex)
+	case *DynamicRef:
+		return fmt.Sprintf("#%d:#%d", constant.BootstrapMethodAttrIndex, constant.NameAndTypeIndex)
+	case *InvokeDynamicRef:
+		return fmt.Sprintf("#%d:#%d", constant.BootstrapMethodAttrIndex, constant.NameAndTypeIndex)
+	case *ModuleRef:
+		return fmt.Sprintf("#%d", constant.NameIndex)
+	case *PackageRef:
+		return fmt.Sprintf("#%d", constant.NameIndex)
+	}
+
+	return ""
+}
+
+// constantComment returns the resolved form of a constant,
+// or "" if the constant doesn't refer to other ones.
+func (d *disassembler) constantComment(constant Constant) string {
+	switch constant := constant.(type) {
+	case *ClassRef:
+		return quoteName(d.utf8(constant.NameIndex))
+	case *FieldRef:
+		return d.memberRef(&constant.fieldMethodInterfaceRef, false)
+	case *MethodRef:
+		return d.memberRef(&constant.fieldMethodInterfaceRef, false)
+	case *InterfaceMethodRef:
+		return d.memberRef(&constant.fieldMethodInterfaceRef, false)
+	case *StringRef:
+		return escapeString(d.utf8(constant.Index))
+	case *NameAndTypeRef:
+		return quoteName(d.utf8(constant.NameIndex)) + ":" + d.utf8(constant.DescriptorIndex)
+	case *MethodHandleRef:
+		return d.methodHandle(constant)
+	case *MethodTypeRef:
+		return d.utf8(constant.DescriptorIndex)
+	case *DynamicRef:
+		return fmt.Sprintf("#%d:%s", constant.BootstrapMethodAttrIndex, d.nameAndType(constant.NameAndTypeIndex))
+	case *InvokeDynamicRef:
+		return fmt.Sprintf("#%d:%s", constant.BootstrapMethodAttrIndex, d.nameAndType(constant.NameAndTypeIndex))
+	case *ModuleRef:
+		return quoteName(d.utf8(constant.NameIndex))
+	case *PackageRef:
+		return d.utf8(constant.NameIndex)
+	}
+
+	return ""
+}
+
+func (d *disassembler) methodHandle(handle *MethodHandleRef) string {
+	kind, ok := referenceKindNames[handle.ReferenceKind]
+	if !ok {
+		kind = fmt.Sprintf("<invalid kind %d>", handle.ReferenceKind)
+	}
+
+	switch ref := d.constant(handle.ReferenceIndex).(type) {
+	case *FieldRef:
+		return kind + " " + d.memberRef(&ref.fieldMethodInterfaceRef, false)
+	case *MethodRef:
+		return kind + " " + d.memberRef(&ref.fieldMethodInterfaceRef, false)
+	case *InterfaceMethodRef:
+		return kind + " " + d.memberRef(&ref.fieldMethodInterfaceRef, false)
+	}
+
+	return fmt.Sprintf("%s <invalid #%d>", kind, handle.ReferenceIndex)
+}
+
+// constantValue describes the constant at index the way javap
+// does in the comments of instructions, e.g. "String Hello"
+// or "Method java/lang/Object.\"<init>\":()V".
+func (d *disassembler) constantValue(index ConstPoolIndex) string {
+	switch constant := d.constant(index).(type) {
+	case *ClassRef:
+		return "class " + d.constantComment(constant)
+	case *FieldRef:
+		return "Field " + d.memberRef(&constant.fieldMethodInterfaceRef, true)
+	case *MethodRef:
+		return "Method " + d.memberRef(&constant.fieldMethodInterfaceRef, true)
+	case *InterfaceMethodRef:
+		return "InterfaceMethod " + d.memberRef(&constant.fieldMethodInterfaceRef, true)
+	case *StringRef:
+		return "String " + d.constantComment(constant)
+	case *IntegerRef:
+		return "int " + d.constantOperands(constant)
+	case *FloatRef:
+		return "float " + d.constantOperands(constant)
+	case *LongRef:
+		return "long " + d.constantOperands(constant)
+	case *DoubleRef:
+		return "double " + d.constantOperands(constant)
+	case *MethodHandleRef:
+		return "MethodHandle " + d.constantComment(constant)
+	case *MethodTypeRef:
+		return "MethodType " + d.constantComment(constant)
+	case *DynamicRef:
+		return "Dynamic " + d.constantComment(constant)
+	case *InvokeDynamicRef:
+		return "InvokeDynamic " + d.constantComment(constant)
+	case nil:
+		return fmt.Sprintf("<invalid #%d>", index)
+	default:
+		return d.constantComment(constant)
+	}
+}
+
+func (d *disassembler) field(field *Field) {
+	decl := modifiers(field.AccessFlags, fieldFlags)
+	decl = append(decl, javaTypeName(d.utf8(field.DescriptorIndex)), d.utf8(field.NameIndex))
+
+	d.printf("  %s;\n", strings.Join(decl, " "))
+	d.printf("    descriptor: %s\n", d.utf8(field.DescriptorIndex))
+	d.printf("    flags: %s\n", flagNames(field.AccessFlags, fieldFlags))
+	d.attributes(field.Attributes, "    ")
+}
+
+func (d *disassembler) method(method *Method) {
+	d.current = method
+	defer func() { d.current = nil }()
+
+	name := d.utf8(method.NameIndex)
+	desc := d.utf8(method.DescriptorIndex)
+
+	decl := modifiers(method.AccessFlags, methodFlags)
+
+	if name == "<clinit>" {
+		decl = append(decl, "{}")
+	} else {
+		var types []string
+		result := "void"
+
+		if m, err := descriptor.ParseMethod(desc); err == nil {
+			for _, param := range m.Params {
+				types = append(types, param.Java())
+			}
+
+			result = m.Return.Java()
+		}
+
+		if method.AccessFlags&METHOD_ACC_VARARGS != 0 && len(types) > 0 {
+			last := types[len(types)-1]
+			if strings.HasSuffix(last, "[]") {
+				types[len(types)-1] = last[:len(last)-2] + "..."
+			}
+		}
+
+		signature := "(" + strings.Join(types, ", ") + ")"
+		if name == "<init>" {
+			decl = append(decl, d.javaClassName(d.c.ThisClass)+signature)
+		} else {
+			decl = append(decl, result, name+signature)
+		}
+
+		for _, attr := range method.Attributes {
+			if exceptions, ok := attr.(*Exceptions); ok {
+				var names []string
+				for _, index := range exceptions.ExceptionsTable {
+					names = append(names, d.javaClassName(index))
+				}
+
+				decl = append(decl, "throws", strings.Join(names, ", "))
+			}
+		}
+	}
+
+	d.printf("  %s;\n", strings.Join(decl, " "))
+	d.printf("    descriptor: %s\n", desc)
+	d.printf("    flags: %s\n", flagNames(method.AccessFlags, methodFlags))
+	d.attributes(method.Attributes, "    ")
+}
+
+// attributes prints attrs, every line starting with indent.
+func (d *disassembler) attributes(attrs Attributes, indent string) {
+	for _, attr := range attrs {
+		d.attribute(attr, indent)
+	}
+}
+
+func (d *disassembler) attribute(attr Attribute, indent string) {
+	switch attr := attr.(type) {
+	case *UnknownAttr:
+		d.printf("%s%s: length = 0x%x (unknown attribute)\n", indent, d.utf8(attr.NameIndex), len(attr.Data))
+		for n := 0; n < len(attr.Data); n += 16 {
+			end := n + 16
+			if end > len(attr.Data) {
+				end = len(attr.Data)
+			}
+
+			d.printf("%s  % x\n", indent, attr.Data[n:end])
+		}
+
+	case *ConstantValue:
+		d.printf("%sConstantValue: %s\n", indent, d.constantValue(attr.Index))
+
+	case *Code:
+		d.code(attr, indent)
+
+	case *StackMapTable:
+		d.stackMapTable(attr, indent)
+
+	case *Exceptions:
+		var names []string
+		for _, index := range attr.ExceptionsTable {
+			names = append(names, d.javaClassName(index))
+		}
+
+		d.printf("%sExceptions:\n", indent)
+		d.printf("%s  throws %s\n", indent, strings.Join(names, ", "))
+
+	case *InnerClasses:
+		d.printf("%sInnerClasses:\n", indent)
+		for _, class := range attr.Classes {
+			d.innerClass(class, indent+"  ")
+		}
+
+	case *EnclosingMethod:
+		line := fmt.Sprintf("%sEnclosingMethod: #%d.#%d", indent, attr.ClassIndex, attr.MethodIndex)
+		comment := d.className(attr.ClassIndex)
+		if constant, ok := d.constant(attr.MethodIndex).(*NameAndTypeRef); ok {
+			comment += "." + quoteName(d.utf8(constant.NameIndex))
+		}
+
+		d.commented(line, commentColumn, comment)
+
+	case *Synthetic:
+		d.printf("%sSynthetic: true\n", indent)
+
+	case *Signature:
+		line := fmt.Sprintf("%sSignature: #%d", indent, attr.SignatureIndex)
+		d.commented(line, commentColumn, d.utf8(attr.SignatureIndex))
+
+	case *SourceFile:
+		d.printf("%sSourceFile: \"%s\"\n", indent, escapeString(d.utf8(attr.SourceFileIndex)))
+
+	case *SourceDebugExtension:
+		d.printf("%sSourceDebugExtension:\n", indent)
+		for _, line := range strings.Split(strings.TrimRight(attr.DebugExtension, "\n"), "\n") {
+			d.printf("%s  %s\n", indent, line)
+		}
+
+	case *LineNumberTable:
+		d.printf("%sLineNumberTable:\n", indent)
+		for _, line := range attr.Table {
+			d.printf("%s  line %d: %d\n", indent, line.LineNumber, line.StartPC)
+		}
+
+	case *LocalVariableTable:
+		d.printf("%sLocalVariableTable:\n", indent)
+		d.printf("%s  Start  Length  Slot  Name   Signature\n", indent)
+		for _, v := range attr.Table {
+			d.printf("%s  %5d %7d %5d %5s   %s\n", indent,
+				v.StartPC, v.Length, v.Index, d.utf8(v.NameIndex), d.utf8(v.DescriptorIndex))
+		}
+
+	case *LocalVariableTypeTable:
+		d.printf("%sLocalVariableTypeTable:\n", indent)
+		d.printf("%s  Start  Length  Slot  Name   Signature\n", indent)
+		for _, v := range attr.Table {
+			d.printf("%s  %5d %7d %5d %5s   %s\n", indent,
+				v.StartPC, v.Length, v.Index, d.utf8(v.NameIndex), d.utf8(v.SignatureIndex))
+		}
+
+	case *Deprecated:
+		d.printf("%sDeprecated: true\n", indent)
+
+	case *RuntimeVisibleAnnotations:
+		d.printf("%sRuntimeVisibleAnnotations:\n", indent)
+		d.annotations(attr.Annotations, indent+"  ")
+
+	case *RuntimeInvisibleAnnotations:
+		d.printf("%sRuntimeInvisibleAnnotations:\n", indent)
+		d.annotations(attr.Annotations, indent+"  ")
+
+	case *RuntimeVisibleParameterAnnotations:
+		d.printf("%sRuntimeVisibleParameterAnnotations:\n", indent)
+		d.parameterAnnotations(attr.Parameters, indent+"  ")
+
+	case *RuntimeInvisibleParameterAnnotations:
+		d.printf("%sRuntimeInvisibleParameterAnnotations:\n", indent)
+		d.parameterAnnotations(attr.Parameters, indent+"  ")
+
+	case *AnnotationDefault:
+		d.printf("%sAnnotationDefault:\n", indent)
+		d.printf("%s  default_value: %s\n", indent, rawElementValue(attr.DefaultValue))
+		d.printf("%s    %s\n", indent, d.elementValue(attr.DefaultValue))
+
+	case *BootstrapMethods:
+		d.printf("%sBootstrapMethods:\n", indent)
+		for n, method := range attr.Methods {
+			comment := fmt.Sprintf("<invalid #%d>", method.MethodRef)
+			if handle, ok := d.constant(method.MethodRef).(*MethodHandleRef); ok {
+				comment = d.methodHandle(handle)
+			}
+
+			d.printf("%s  %d: #%d %s\n", indent, n, method.MethodRef, comment)
+			d.printf("%s    Method arguments:\n", indent)
+			for _, arg := range method.Args {
+				d.printf("%s      #%d %s\n", indent, arg, d.bootstrapArgument(arg))
+			}
+		}
+
+	case *RuntimeVisibleTypeAnnotations:
+		d.printf("%sRuntimeVisibleTypeAnnotations:\n", indent)
+		d.typeAnnotations(attr.Annotations, indent+"  ")
+
+	case *RuntimeInvisibleTypeAnnotations:
+		d.printf("%sRuntimeInvisibleTypeAnnotations:\n", indent)
+		d.typeAnnotations(attr.Annotations, indent+"  ")
+
+	case *Module:
+		d.module(attr, indent)
+
+	case *ModulePackages:
+		d.printf("%sModulePackages:\n", indent)
+		for _, index := range attr.Packages {
+			d.commented(fmt.Sprintf("%s  #%d", indent, index), commentColumn, d.packageName(index))
+		}
+
+	case *ModuleMainClass:
+		line := fmt.Sprintf("%sModuleMainClass: #%d", indent, attr.MainClassIndex)
+		d.commented(line, commentColumn, d.className(attr.MainClassIndex))
+
+	case *NestHost:
+		d.printf("%sNestHost: class %s\n", indent, d.className(attr.HostClassIndex))
+
+	case *NestMembers:
+		d.printf("%sNestMembers:\n", indent)
+		for _, index := range attr.Classes {
+			d.printf("%s  %s\n", indent, d.className(index))
+		}
+
+	case *Record:
+		d.printf("%sRecord:\n", indent)
+		for _, component := range attr.Components {
+			desc := d.utf8(component.DescriptorIndex)
+			d.printf("%s  %s %s;\n", indent, javaTypeName(desc), d.utf8(component.NameIndex))
+			d.printf("%s    descriptor: %s\n", indent, desc)
+			d.attributes(component.Attributes, indent+"    ")
+			d.printf("\n")
+		}
+
+	case *PermittedSubclasses:
+		d.printf("%sPermittedSubclasses:\n", indent)
+		for _, index := range attr.Classes {
+			d.printf("%s  %s\n", indent, d.className(index))
+		}
+
+	case *MethodParameters:
+		d.printf("%sMethodParameters:\n", indent)
+		d.printf("%s  %-30s %s\n", indent, "Name", "Flags")
+		for _, param := range attr.Parameters {
+			name := "<no name>"
+			if param.NameIndex != 0 {
+				name = d.utf8(param.NameIndex)
+			}
+
+			d.printf("%s  %-30s %s\n", indent, name, strings.Join(modifiers(param.AccessFlags, parameterFlags), " "))
+		}
+	}
+}
+
+func (d *disassembler) innerClass(class InnerClass, indent string) {
+	decl := modifiers(class.InnerAccessFlags, nestedClassFlags)
+	if class.InnerAccessFlags&NESTED_CLASS_ACC_INTERFACE != 0 {
+		decl = append(decl, "interface")
+	}
+
+	line := indent
+	if len(decl) > 0 {
+		line += strings.Join(decl, " ") + " "
+	}
+
+	comment := ""
+	if class.InnerName != 0 {
+		line += fmt.Sprintf("#%d= ", class.InnerName)
+		comment = d.utf8(class.InnerName) + "="
+	}
+
+	line += fmt.Sprintf("#%d", class.InnerClassIndex)
+	comment += "class " + quoteName(d.className(class.InnerClassIndex))
+
+	if class.OuterClassIndex != 0 {
+		line += fmt.Sprintf(" of #%d", class.OuterClassIndex)
+		comment += " of class " + quoteName(d.className(class.OuterClassIndex))
+	}
+
+	d.commented(line+";", commentColumn, comment)
+}
+
+func (d *disassembler) bootstrapArgument(index ConstPoolIndex) string {
+	switch constant := d.constant(index).(type) {
+	case *IntegerRef, *FloatRef, *LongRef, *DoubleRef:
+		return d.constantOperands(constant)
+	case nil:
+		return fmt.Sprintf("<invalid #%d>", index)
+	default:
+		return d.constantComment(constant)
+	}
+}
+
+func (d *disassembler) code(code *Code, indent string) {
+	argsSize := 0
+	if d.current != nil {
+		if m, err := descriptor.ParseMethod(d.utf8(d.current.DescriptorIndex)); err == nil {
+			argsSize += m.ParamSlots()
+		}
+
+		if d.current.AccessFlags&METHOD_ACC_STATIC == 0 {
+			argsSize++
+		}
+	}
+
+	d.printf("%sCode:\n", indent)
+	d.printf("%s  stack=%d, locals=%d, args_size=%d\n", indent, code.MaxStackSize, code.MaxLocalsCount, argsSize)
+
+	insts, err := code.Instructions()
+	if err != nil {
+		d.printf("%s  // invalid bytecode: %s\n", indent, err)
+	}
+
+	for _, inst := range insts {
+		d.instruction(inst, indent+"  ")
+	}
+
+	if len(code.ExceptionsTable) > 0 {
+		d.printf("%s  Exception table:\n", indent)
+		d.printf("%s     from    to  target type\n", indent)
+		for _, e := range code.ExceptionsTable {
+			catchType := "any"
+			if e.CatchType != 0 {
+				catchType = "Class " + quoteName(d.className(e.CatchType))
+			}
+
+			d.printf("%s    %5d %5d %5d   %s\n", indent, e.StartPC, e.EndPC, e.HandlerPC, catchType)
+		}
+	}
+
+	d.attributes(code.Attributes, indent+"  ")
+}
+
+var arrayTypeNames = map[int32]string{
+	T_BOOLEAN: "boolean",
+	T_CHAR:    "char",
+	T_FLOAT:   "float",
+	T_DOUBLE:  "double",
+	T_BYTE:    "byte",
+	T_SHORT:   "short",
+	T_INT:     "int",
+	T_LONG:    "long",
+}
+
+func (d *disassembler) instruction(inst *Instruction, indent string) {
+	mnemonic := inst.Mnemonic()
+	if inst.Wide {
+		mnemonic += "_w"
+	}
+
+	var operands, comment string
+
+	switch opcodes[inst.Opcode].format {
+	case operandByte, operandShort:
+		operands = strconv.Itoa(int(inst.Const))
+	case operandConstPoolByte, operandConstPool:
+		operands = fmt.Sprintf("#%d", inst.Index)
+		comment = d.constantValue(inst.Index)
+	case operandLocal:
+		operands = strconv.Itoa(int(inst.Local))
+	case operandIinc:
+		operands = fmt.Sprintf("%d, %d", inst.Local, inst.Const)
+	case operandBranch, operandBranchWide:
+		operands = strconv.Itoa(inst.Target())
+	case operandTableSwitch:
+		high := inst.Low + int32(len(inst.Cases)) - 1
+		operands = fmt.Sprintf("{ // %d to %d", inst.Low, high)
+	case operandLookupSwitch:
+		operands = fmt.Sprintf("{ // %d", len(inst.Cases))
+	case operandInvokeInterface:
+		operands = fmt.Sprintf("#%d,  %d", inst.Index, inst.Const)
+		comment = d.constantValue(inst.Index)
+	case operandInvokeDynamic:
+		operands = fmt.Sprintf("#%d,  0", inst.Index)
+		comment = d.constantValue(inst.Index)
+	case operandNewArray:
+		operands = arrayTypeNames[inst.Const]
+		if operands == "" {
+			operands = fmt.Sprintf("<invalid type %d>", inst.Const)
+		}
+	case operandMultiANewArray:
+		operands = fmt.Sprintf("#%d,  %d", inst.Index, inst.Const)
+		comment = d.constantValue(inst.Index)
+	}
+
+	line := fmt.Sprintf("%s%4d: %s", indent, inst.PC, mnemonic)
+	if operands != "" {
+		line = fmt.Sprintf("%s%4d: %-13s %s", indent, inst.PC, mnemonic, operands)
+	}
+
+	d.commented(line, codeCommentColumn, comment)
+
+	format := opcodes[inst.Opcode].format
+	if format == operandTableSwitch || format == operandLookupSwitch {
+		for _, c := range inst.Cases {
+			d.printf("%s%18d: %d\n", indent, c.Match, inst.PC+int(c.Offset))
+		}
+
+		d.printf("%s%18s: %d\n", indent, "default", inst.Target())
+		d.printf("%s      }\n", indent)
+	}
+}
+
+func (d *disassembler) stackMapTable(table *StackMapTable, indent string) {
+	d.printf("%sStackMapTable: number_of_entries = %d\n", indent, len(table.Entries))
+
+	for _, frame := range table.Entries {
+		var name string
+		var locals, stack []VerificationTypeInfo
+		explicitDelta := true
+
+		switch frame := frame.(type) {
+		case *SameFrame:
+			name, explicitDelta = "same", false
+		case *SameLocals1StackItemFrame:
+			name, explicitDelta = "same_locals_1_stack_item", false
+			stack = []VerificationTypeInfo{frame.Stack}
+		case *SameLocals1StackItemFrameExtended:
+			name = "same_locals_1_stack_item_frame_extended"
+			stack = []VerificationTypeInfo{frame.Stack}
+		case *ChopFrame:
+			name = "chop"
+		case *SameFrameExtended:
+			name = "same_frame_extended"
+		case *AppendFrame:
+			name = "append"
+			locals = frame.Locals
+		case *FullFrame:
+			name = "full_frame"
+			locals, stack = frame.Locals, frame.Stack
+		}
+
+		d.printf("%s  frame_type = %d /* %s */\n", indent, frame.GetFrameType(), name)
+
+		if explicitDelta {
+			d.printf("%s    offset_delta = %d\n", indent, frame.OffsetDelta())
+		}
+
+		if locals != nil {
+			d.printf("%s    locals = [ %s ]\n", indent, d.verificationTypes(locals))
+		}
+
+		if stack != nil {
+			d.printf("%s    stack = [ %s ]\n", indent, d.verificationTypes(stack))
+		}
+	}
+}
+
+func (d *disassembler) verificationTypes(infos []VerificationTypeInfo) string {
+	var types []string
+
+	for _, info := range infos {
+		switch info := info.(type) {
+		case *TopVariable:
+			types = append(types, "top")
+		case *IntegerVariable:
+			types = append(types, "int")
+		case *FloatVariable:
+			types = append(types, "float")
+		case *DoubleVariable:
+			types = append(types, "double")
+		case *LongVariable:
+			types = append(types, "long")
+		case *NullVariable:
+			types = append(types, "null")
+		case *UninitializedThisVariable:
+			types = append(types, "this")
+		case *ObjectVariable:
+			types = append(types, "class "+quoteName(d.className(info.ClassIndex)))
+		case *UninitializedVariable:
+			types = append(types, fmt.Sprintf("uninitialized %d", info.Offset))
+		}
+	}
+
+	return strings.Join(types, ", ")
+}
+
+func (d *disassembler) annotations(annotations []*Annotation, indent string) {
+	for n, annotation := range annotations {
+		d.printf("%s%d: %s\n", indent, n, rawAnnotation(annotation))
+		d.printf("%s  %s\n", indent, d.annotation(annotation))
+	}
+}
+
+func (d *disassembler) parameterAnnotations(parameters [][]*Annotation, indent string) {
+	for n, annotations := range parameters {
+		d.printf("%sparameter %d:\n", indent, n)
+		d.annotations(annotations, indent+"  ")
+	}
+}
+
+var targetTypeNames = map[TargetType]string{
+	TARGET_CLASS_TYPE_PARAMETER:                 "CLASS_TYPE_PARAMETER",
+	TARGET_METHOD_TYPE_PARAMETER:                "METHOD_TYPE_PARAMETER",
+	TARGET_CLASS_EXTENDS:                        "CLASS_EXTENDS",
+	TARGET_CLASS_TYPE_PARAMETER_BOUND:           "CLASS_TYPE_PARAMETER_BOUND",
+	TARGET_METHOD_TYPE_PARAMETER_BOUND:          "METHOD_TYPE_PARAMETER_BOUND",
+	TARGET_FIELD:                                "FIELD",
+	TARGET_METHOD_RETURN:                        "METHOD_RETURN",
+	TARGET_METHOD_RECEIVER:                      "METHOD_RECEIVER",
+	TARGET_METHOD_FORMAL_PARAMETER:              "METHOD_FORMAL_PARAMETER",
+	TARGET_THROWS:                               "THROWS",
+	TARGET_LOCAL_VARIABLE:                       "LOCAL_VARIABLE",
+	TARGET_RESOURCE_VARIABLE:                    "RESOURCE_VARIABLE",
+	TARGET_EXCEPTION_PARAMETER:                  "EXCEPTION_PARAMETER",
+	TARGET_INSTANCEOF:                           "INSTANCEOF",
+	TARGET_NEW:                                  "NEW",
+	TARGET_CONSTRUCTOR_REFERENCE:                "CONSTRUCTOR_REFERENCE",
+	TARGET_METHOD_REFERENCE:                     "METHOD_REFERENCE",
+	TARGET_CAST:                                 "CAST",
+	TARGET_CONSTRUCTOR_INVOCATION_TYPE_ARGUMENT: "CONSTRUCTOR_INVOCATION_TYPE_ARGUMENT",
+	TARGET_METHOD_INVOCATION_TYPE_ARGUMENT:      "METHOD_INVOCATION_TYPE_ARGUMENT",
+	TARGET_CONSTRUCTOR_REFERENCE_TYPE_ARGUMENT:  "CONSTRUCTOR_REFERENCE_TYPE_ARGUMENT",
+	TARGET_METHOD_REFERENCE_TYPE_ARGUMENT:       "METHOD_REFERENCE_TYPE_ARGUMENT",
+}
+
+var typePathKindNames = map[TypePathKind]string{
+	TYPE_PATH_ARRAY:         "ARRAY",
+	TYPE_PATH_INNER_TYPE:    "INNER_TYPE",
+	TYPE_PATH_WILDCARD:      "WILDCARD",
+	TYPE_PATH_TYPE_ARGUMENT: "TYPE_ARGUMENT",
+}
+
+func (d *disassembler) typeAnnotations(annotations []*TypeAnnotation, indent string) {
+	for n, annotation := range annotations {
+		target := []string{targetTypeNames[annotation.TargetType]}
+
+		switch info := annotation.TargetInfo.(type) {
+		case *TypeParameterTarget:
+			target = append(target, fmt.Sprintf("param_index=%d", info.TypeParameterIndex))
+		case *SupertypeTarget:
+			target = append(target, fmt.Sprintf("type_index=%d", int16(info.SupertypeIndex)))
+		case *TypeParameterBoundTarget:
+			target = append(target, fmt.Sprintf("param_index=%d", info.TypeParameterIndex),
+				fmt.Sprintf("bound_index=%d", info.BoundIndex))
+		case *FormalParameterTarget:
+			target = append(target, fmt.Sprintf("param_index=%d", info.FormalParameterIndex))
+		case *ThrowsTarget:
+			target = append(target, fmt.Sprintf("type_index=%d", info.ThrowsTypeIndex))
+		case *LocalVarTarget:
+			for _, r := range info.Table {
+				target = append(target, fmt.Sprintf("{start_pc=%d, length=%d, index=%d}", r.StartPC, r.Length, r.Index))
+			}
+		case *CatchTarget:
+			target = append(target, fmt.Sprintf("exception_index=%d", info.ExceptionTableIndex))
+		case *OffsetTarget:
+			target = append(target, fmt.Sprintf("offset=%d", info.Offset))
+		case *TypeArgumentTarget:
+			target = append(target, fmt.Sprintf("offset=%d", info.Offset),
+				fmt.Sprintf("type_index=%d", info.TypeArgumentIndex))
+		}
+
+		if len(annotation.TargetPath) > 0 {
+			var path []string
+			for _, entry := range annotation.TargetPath {
+				if entry.TypePathKind == TYPE_PATH_TYPE_ARGUMENT {
+					path = append(path, fmt.Sprintf("TYPE_ARGUMENT(%d)", entry.TypeArgumentIndex))
+				} else {
+					path = append(path, typePathKindNames[entry.TypePathKind])
+				}
+			}
+
+			target = append(target, "location=["+strings.Join(path, ", ")+"]")
+		}
+
+		d.printf("%s%d: %s: %s\n", indent, n, rawAnnotation(&annotation.Annotation), strings.Join(target, ", "))
+		d.printf("%s  %s\n", indent, d.annotation(&annotation.Annotation))
+	}
+}
+
+// rawAnnotation formats an annotation with constant pool
+// indexes only, e.g. "#18(#19=s#20)".
+func rawAnnotation(annotation *Annotation) string {
+	var pairs []string
+	for _, pair := range annotation.Pairs {
+		pairs = append(pairs, fmt.Sprintf("#%d=%s", pair.NameIndex, rawElementValue(pair.Value)))
+	}
+
+	return fmt.Sprintf("#%d(%s)", annotation.TypeIndex, strings.Join(pairs, ","))
+}
+
+func rawElementValue(value ElementValue) string {
+	switch value := value.(type) {
+	case *ConstElementValue:
+		return fmt.Sprintf("%c#%d", value.Tag, value.ConstValueIndex)
+	case *EnumElementValue:
+		return fmt.Sprintf("e#%d.#%d", value.TypeNameIndex, value.ConstNameIndex)
+	case *ClassElementValue:
+		return fmt.Sprintf("c#%d", value.ClassInfoIndex)
+	case *AnnotationElementValue:
+		return "@" + rawAnnotation(&value.Annotation)
+	case *ArrayElementValue:
+		var values []string
+		for _, v := range value.Values {
+			values = append(values, rawElementValue(v))
+		}
+
+		return "[" + strings.Join(values, ",") + "]"
+	}
+
+	return ""
+}
+
+// annotation formats an annotation as it would be written
+// in Java source, e.g. "java.lang.Deprecated(since=\"9\")".
+func (d *disassembler) annotation(annotation *Annotation) string {
+	name := javaTypeName(d.utf8(annotation.TypeIndex))
+	if len(annotation.Pairs) == 0 {
+		return name
+	}
+
+	var pairs []string
+	for _, pair := range annotation.Pairs {
+		pairs = append(pairs, d.utf8(pair.NameIndex)+"="+d.elementValue(pair.Value))
+	}
+
+	return name + "(" + strings.Join(pairs, ", ") + ")"
+}
+
+func (d *disassembler) elementValue(value ElementValue) string {
+	switch value := value.(type) {
+	case *ConstElementValue:
+		constant := d.constant(value.ConstValueIndex)
+		if constant == nil {
+			return fmt.Sprintf("<invalid #%d>", value.ConstValueIndex)
+		}
+
+		switch value.Tag {
+		case ELEMENT_VALUE_String:
+			return "\"" + escapeString(d.utf8(value.ConstValueIndex)) + "\""
+		case ELEMENT_VALUE_Char:
+			if integer, ok := constant.(*IntegerRef); ok {
+				return "'" + escapeString(string(rune(integer.Value))) + "'"
+			}
+		case ELEMENT_VALUE_Boolean:
+			if integer, ok := constant.(*IntegerRef); ok {
+				return strconv.FormatBool(integer.Value != 0)
+			}
+		}
+
+		return d.constantOperands(constant)
+
+	case *EnumElementValue:
+		return javaTypeName(d.utf8(value.TypeNameIndex)) + "." + d.utf8(value.ConstNameIndex)
+
+	case *ClassElementValue:
+		return javaTypeName(d.utf8(value.ClassInfoIndex)) + ".class"
+
+	case *AnnotationElementValue:
+		return "@" + d.annotation(&value.Annotation)
+
+	case *ArrayElementValue:
+		var values []string
+		for _, v := range value.Values {
+			values = append(values, d.elementValue(v))
+		}
+
+		return "[" + strings.Join(values, ",") + "]"
+	}
+
+	return ""
+}
+
+func (d *disassembler) moduleAttr() *Module {
+	for _, attr := range d.c.Attributes {
+		if module, ok := attr.(*Module); ok {
+			return module
+		}
+	}
+
+	return nil
+}
+
+func (d *disassembler) moduleName(index ConstPoolIndex) string {
+	if constant, ok := d.constant(index).(*ModuleRef); ok {
+		return d.utf8(constant.NameIndex)
+	}
+
+	return fmt.Sprintf("<invalid #%d>", index)
+}
+
+func (d *disassembler) packageName(index ConstPoolIndex) string {
+	if constant, ok := d.constant(index).(*PackageRef); ok {
+		return d.utf8(constant.NameIndex)
+	}
+
+	return fmt.Sprintf("<invalid #%d>", index)
+}
+
+func (d *disassembler) module(module *Module, indent string) {
+	entry := func(indent, line, comment string) {
+		d.commented(indent+line, commentColumn, comment)
+	}
+
+	version := func(indent string, index ConstPoolIndex) {
+		if index == 0 {
+			d.printf("%s#0\n", indent)
+		} else {
+			entry(indent, fmt.Sprintf("#%d", index), d.utf8(index))
+		}
+	}
+
+	grant := func(indent string, index ConstPoolIndex, flags AccessFlags, to []ConstPoolIndex) {
+		comment := d.packageName(index)
+		if names := flagList(flags, moduleGrantFlags); names != "" {
+			comment += " " + names
+		}
+
+		entry(indent, fmt.Sprintf("#%d,%x", index, uint16(flags)), comment)
+		if len(to) > 0 {
+			entry(indent, strconv.Itoa(len(to)), "to ... ")
+			for _, module := range to {
+				entry(indent+"  ", fmt.Sprintf("#%d", module), quoteName(d.moduleName(module)))
+			}
+		}
+	}
+
+	d.printf("%sModule:\n", indent)
+	indent += "  "
+
+	comment := quoteName(d.moduleName(module.NameIndex))
+	if names := flagList(module.Flags, moduleFlags); names != "" {
+		comment += " " + names
+	}
+	entry(indent, fmt.Sprintf("#%d,%x", module.NameIndex, uint16(module.Flags)), comment)
+	version(indent, module.VersionIndex)
+
+	entry(indent, strconv.Itoa(len(module.Requires)), "requires")
+	for _, requires := range module.Requires {
+		comment := quoteName(d.moduleName(requires.RequiresIndex))
+		if names := flagList(requires.Flags, requiresFlags); names != "" {
+			comment += " " + names
+		}
+
+		entry(indent+"  ", fmt.Sprintf("#%d,%x", requires.RequiresIndex, uint16(requires.Flags)), comment)
+		version(indent+"  ", requires.VersionIndex)
+	}
+
+	entry(indent, strconv.Itoa(len(module.Exports)), "exports")
+	for _, exports := range module.Exports {
+		grant(indent+"  ", exports.ExportsIndex, exports.Flags, exports.ExportsTo)
+	}
+
+	entry(indent, strconv.Itoa(len(module.Opens)), "opens")
+	for _, opens := range module.Opens {
+		grant(indent+"  ", opens.OpensIndex, opens.Flags, opens.OpensTo)
+	}
+
+	entry(indent, strconv.Itoa(len(module.Uses)), "uses")
+	for _, uses := range module.Uses {
+		entry(indent+"  ", fmt.Sprintf("#%d", uses), d.className(uses))
+	}
+
+	entry(indent, strconv.Itoa(len(module.Provides)), "provides")
+	for _, provides := range module.Provides {
+		entry(indent+"  ", fmt.Sprintf("#%d", provides.ProvidesIndex), d.className(provides.ProvidesIndex))
+		entry(indent+"  ", strconv.Itoa(len(provides.ProvidesWith)), "with ... ")
+		for _, with := range provides.ProvidesWith {
+			entry(indent+"    ", fmt.Sprintf("#%d", with), d.className(with))
+		}
+	}
+}
+
+// A flagName describes a single access flag: the name used
+// by javap (e.g. "ACC_PUBLIC") and the Java modifier keyword
+// (e.g. "public"), if there is one.
+type flagName struct {
+	flag     AccessFlags
+	name     string
+	modifier string
+}
+
+var classFlags = []flagName{
+	{CLASS_ACC_PUBLIC, "ACC_PUBLIC", "public"},
+	{CLASS_ACC_FINAL, "ACC_FINAL", "final"},
+	{CLASS_ACC_SUPER, "ACC_SUPER", ""},
+	{CLASS_ACC_INTERFACE, "ACC_INTERFACE", ""},
+	{CLASS_ACC_ABSTRACT, "ACC_ABSTRACT", "abstract"},
+	{CLASS_ACC_SYNTHETIC, "ACC_SYNTHETIC", ""},
+	{CLASS_ACC_ANNOTATION, "ACC_ANNOTATION", ""},
+	{CLASS_ACC_ENUM, "ACC_ENUM", ""},
+	{CLASS_ACC_MODULE, "ACC_MODULE", ""},
+}
+
+var fieldFlags = []flagName{
+	{FIELD_ACC_PUBLIC, "ACC_PUBLIC", "public"},
+	{FIELD_ACC_PRIVATE, "ACC_PRIVATE", "private"},
+	{FIELD_ACC_PROTECTED, "ACC_PROTECTED", "protected"},
+	{FIELD_ACC_STATIC, "ACC_STATIC", "static"},
+	{FIELD_ACC_FINAL, "ACC_FINAL", "final"},
+	{FIELD_ACC_VOLATILE, "ACC_VOLATILE", "volatile"},
+	{FIELD_ACC_TRANSIENT, "ACC_TRANSIENT", "transient"},
+	{FIELD_ACC_SYNTHETIC, "ACC_SYNTHETIC", ""},
+	{FIELD_ACC_ENUM, "ACC_ENUM", ""},
+}
+
+var methodFlags = []flagName{
+	{METHOD_ACC_PUBLIC, "ACC_PUBLIC", "public"},
+	{METHOD_ACC_PRIVATE, "ACC_PRIVATE", "private"},
+	{METHOD_ACC_PROTECTED, "ACC_PROTECTED", "protected"},
+	{METHOD_ACC_STATIC, "ACC_STATIC", "static"},
+	{METHOD_ACC_FINAL, "ACC_FINAL", "final"},
+	{METHOD_ACC_SYNCHRONIZED, "ACC_SYNCHRONIZED", "synchronized"},
+	{METHOD_ACC_BRIDGE, "ACC_BRIDGE", ""},
+	{METHOD_ACC_VARARGS, "ACC_VARARGS", ""},
+	{METHOD_ACC_NATIVE, "ACC_NATIVE", "native"},
+	{METHOD_ACC_ABSTRACT, "ACC_ABSTRACT", "abstract"},
+	{METHOD_ACC_STRICT, "ACC_STRICT", "strictfp"},
+	{METHOD_ACC_SYNTHETIC, "ACC_SYNTHETIC", ""},
+}
+
+var nestedClassFlags = []flagName{
+	{NESTED_CLASS_ACC_PUBLIC, "ACC_PUBLIC", "public"},
+	{NESTED_CLASS_ACC_PRIVATE, "ACC_PRIVATE", "private"},
+	{NESTED_CLASS_ACC_PROTECTED, "ACC_PROTECTED", "protected"},
+	{NESTED_CLASS_ACC_STATIC, "ACC_STATIC", "static"},
+	{NESTED_CLASS_ACC_FINAL, "ACC_FINAL", "final"},
+	{NESTED_CLASS_ACC_INTERFACE, "ACC_INTERFACE", ""},
+	{NESTED_CLASS_ACC_ABSTRACT, "ACC_ABSTRACT", "abstract"},
+	{NESTED_CLASS_ACC_SYNTHETIC, "ACC_SYNTHETIC", ""},
+	{NESTED_CLASS_ACC_ANNOTATION, "ACC_ANNOTATION", ""},
+	{NESTED_CLASS_ACC_ENUM, "ACC_ENUM", ""},
+}
+
+var parameterFlags = []flagName{
+	{PARAMETER_ACC_FINAL, "ACC_FINAL", "final"},
+	{PARAMETER_ACC_SYNTHETIC, "ACC_SYNTHETIC", "synthetic"},
+	{PARAMETER_ACC_MANDATED, "ACC_MANDATED", "mandated"},
+}
+
+var moduleFlags = []flagName{
+	{MODULE_ACC_OPEN, "ACC_OPEN", "open"},
+	{MODULE_ACC_SYNTHETIC, "ACC_SYNTHETIC", ""},
+	{MODULE_ACC_MANDATED, "ACC_MANDATED", ""},
+}
+
+var requiresFlags = []flagName{
+	{REQUIRES_ACC_TRANSITIVE, "ACC_TRANSITIVE", "transitive"},
+	{REQUIRES_ACC_STATIC_PHASE, "ACC_STATIC_PHASE", "static"},
+	{REQUIRES_ACC_SYNTHETIC, "ACC_SYNTHETIC", ""},
+	{REQUIRES_ACC_MANDATED, "ACC_MANDATED", ""},
+}
+
+var moduleGrantFlags = []flagName{
+	{EXPORTS_ACC_SYNTHETIC, "ACC_SYNTHETIC", ""},
+	{EXPORTS_ACC_MANDATED, "ACC_MANDATED", ""},
+}
+
+// flagList returns the names of all flags set in flags,
+// separated by commas. Unknown flags are given in hex.
+func flagList(flags AccessFlags, names []flagName) string {
+	var set []string
+	rest := flags
+
+	for _, name := range names {
+		if flags&name.flag != 0 {
+			set = append(set, name.name)
+			rest &^= name.flag
+		}
+	}
+
+	if rest != 0 {
+		set = append(set, fmt.Sprintf("0x%04x", uint16(rest)))
+	}
+
+	return strings.Join(set, ", ")
+}
+
+// flagNames formats flags like javap does, e.g.
+// "(0x0021) ACC_PUBLIC, ACC_SUPER".
+func flagNames(flags AccessFlags, names []flagName) string {
+	list := flagList(flags, names)
+	if list == "" {
+		return fmt.Sprintf("(0x%04x)", uint16(flags))
+	}
+
+	return fmt.Sprintf("(0x%04x) %s", uint16(flags), list)
+}
+
+// modifiers returns the Java modifier keywords of the flags.
+func modifiers(flags AccessFlags, names []flagName) []string {
+	var keywords []string
+	for _, name := range names {
+		if flags&name.flag != 0 && name.modifier != "" {
+			keywords = append(keywords, name.modifier)
+		}
+	}
+
+	return keywords
+}
+
+// javaTypeName converts a field descriptor into the type as it's
+// written in Java source, e.g. "[Ljava/lang/String;" into
+// "java.lang.String[]". "V" is converted to "void".
+func javaTypeName(desc string) string {
+	dims := 0
+	for dims < len(desc) && desc[dims] == '[' {
+		dims++
+	}
+
+	name := desc[dims:]
+
+	switch name {
+	case "B":
+		name = "byte"
+	case "C":
+		name = "char"
+	case "D":
+		name = "double"
+	case "F":
+		name = "float"
+	case "I":
+		name = "int"
+	case "J":
+		name = "long"
+	case "S":
+		name = "short"
+	case "Z":
+		name = "boolean"
+	case "V":
+		name = "void"
+	default:
+		if strings.HasPrefix(name, "L") && strings.HasSuffix(name, ";") {
+			name = strings.Replace(name[1:len(name)-1], "/", ".", -1)
+		}
+	}
+
+	return name + strings.Repeat("[]", dims)
+}
+
+// quoteName quotes a (binary) class or member name, if it isn't
+// a sequence of Java identifiers separated by slashes, just like
+// javap does (e.g. for "<init>" or array classes).
+func quoteName(name string) string {
+	prev := '/'
+
+	for _, r := range name {
+		start := unicode.IsLetter(r) || r == '_' || r == '$'
+		part := start || unicode.IsDigit(r)
+
+		if (prev == '/' && !start) || (r != '/' && !part) {
+			return "\"" + escapeString(name) + "\""
+		}
+
+		prev = r
+	}
+
+	if name == "" {
+		return "\"\""
+	}
+
+	return name
+}
+
+// escapeString escapes control characters, quotes and
+// backslashes in s, like they would be in a Java literal.
+func escapeString(s string) string {
+	var b strings.Builder
+
+	for _, r := range s {
+		switch r {
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\'':
+			b.WriteString(`\'`)
+		case '\\':
+			b.WriteString(`\\`)
+		default:
+			if unicode.IsPrint(r) {
+				b.WriteRune(r)
+			} else {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// javaFloat formats v like Java's Float.toString (bitSize 32)
+// or Double.toString (bitSize 64) do.
+func javaFloat(v float64, bitSize int) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	}
+
+	abs := math.Abs(v)
+	if abs != 0 && (abs < 1e-3 || abs >= 1e7) {
+		s := strconv.FormatFloat(v, 'E', -1, bitSize)
+		mantissa, exponent := s[:strings.IndexByte(s, 'E')], s[strings.IndexByte(s, 'E')+1:]
+		if !strings.Contains(mantissa, ".") {
+			mantissa += ".0"
+		}
+
+		exp, _ := strconv.Atoi(exponent)
+		return mantissa + "E" + strconv.Itoa(exp)
+	}
+
+	s := strconv.FormatFloat(v, 'f', -1, bitSize)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+
+	return s
+}
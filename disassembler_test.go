@@ -0,0 +1,137 @@
+package class
+
+import (
+	"bytes"
+	"testing"
+)
+
+// helloWorldDisassembly is the expected output of Disassemble for
+// HelloWorld.class, laid out like javap -v.
+const helloWorldDisassembly = `  Compiled from "HelloWorld.java"
+public final class HelloWorld
+  minor version: 0
+  major version: 50
+  flags: (0x0031) ACC_PUBLIC, ACC_FINAL, ACC_SUPER
+  this_class: #9                          // HelloWorld
+  super_class: #10                        // java/lang/Object
+  interfaces: 0, fields: 3, methods: 3, attributes: 1
+Constant pool:
+   #1 = Methodref          #10.#33        // java/lang/Object."<init>":()V
+   #2 = Long               2l
+   #4 = Fieldref           #9.#34         // HelloWorld.myField:J
+   #5 = Fieldref           #35.#36        // java/lang/System.out:Ljava/io/PrintStream;
+   #6 = String             #37            // Hello World!
+   #7 = Methodref          #38.#39        // java/io/PrintStream.println:(Ljava/lang/String;)V
+   #8 = String             #40            // This is quite a long string, and it will be put in the constant pool...
+   #9 = Class              #41            // HelloWorld
+  #10 = Class              #42            // java/lang/Object
+  #11 = Utf8               myField
+  #12 = Utf8               J
+  #13 = Utf8               myOtherField
+  #14 = Utf8               Ljava/lang/String;
+  #15 = Utf8               ConstantValue
+  #16 = Utf8               myList
+  #17 = Utf8               Ljava/util/List;
+  #18 = Utf8               Signature
+  #19 = Utf8               Ljava/util/List<Ljava/lang/Integer;>;
+  #20 = Utf8               <init>
+  #21 = Utf8               ()V
+  #22 = Utf8               Code
+  #23 = Utf8               LineNumberTable
+  #24 = Utf8               main
+  #25 = Utf8               ([Ljava/lang/String;)V
+  #26 = Utf8               giveItToMe
+  #27 = Utf8               ()Ljava/lang/String;
+  #28 = Utf8               Deprecated
+  #29 = Utf8               RuntimeVisibleAnnotations
+  #30 = Utf8               Ljava/lang/Deprecated;
+  #31 = Utf8               SourceFile
+  #32 = Utf8               HelloWorld.java
+  #33 = NameAndType        #20:#21        // "<init>":()V
+  #34 = NameAndType        #11:#12        // myField:J
+  #35 = Class              #43            // java/lang/System
+  #36 = NameAndType        #44:#45        // out:Ljava/io/PrintStream;
+  #37 = Utf8               Hello World!
+  #38 = Class              #46            // java/io/PrintStream
+  #39 = NameAndType        #47:#48        // println:(Ljava/lang/String;)V
+  #40 = Utf8               This is quite a long string, and it will be put in the constant pool...
+  #41 = Utf8               HelloWorld
+  #42 = Utf8               java/lang/Object
+  #43 = Utf8               java/lang/System
+  #44 = Utf8               out
+  #45 = Utf8               Ljava/io/PrintStream;
+  #46 = Utf8               java/io/PrintStream
+  #47 = Utf8               println
+  #48 = Utf8               (Ljava/lang/String;)V
+{
+  public long myField;
+    descriptor: J
+    flags: (0x0001) ACC_PUBLIC
+
+  public static final java.lang.String myOtherField;
+    descriptor: Ljava/lang/String;
+    flags: (0x0019) ACC_PUBLIC, ACC_STATIC, ACC_FINAL
+    ConstantValue: String This is quite a long string, and it will be put in the constant pool...
+
+  private volatile java.util.List myList;
+    descriptor: Ljava/util/List;
+    flags: (0x0042) ACC_PRIVATE, ACC_VOLATILE
+    Signature: #19                        // Ljava/util/List<Ljava/lang/Integer;>;
+
+  public HelloWorld();
+    descriptor: ()V
+    flags: (0x0001) ACC_PUBLIC
+    Code:
+      stack=3, locals=1, args_size=1
+         0: aload_0
+         1: invokespecial #1                  // Method java/lang/Object."<init>":()V
+         4: aload_0
+         5: ldc2_w        #2                  // long 2l
+         8: putfield      #4                  // Field myField:J
+        11: return
+      LineNumberTable:
+        line 3: 0
+        line 4: 4
+
+  public static void main(java.lang.String[]);
+    descriptor: ([Ljava/lang/String;)V
+    flags: (0x0009) ACC_PUBLIC, ACC_STATIC
+    Code:
+      stack=2, locals=1, args_size=1
+         0: getstatic     #5                  // Field java/lang/System.out:Ljava/io/PrintStream;
+         3: ldc           #6                  // String Hello World!
+         5: invokevirtual #7                  // Method java/io/PrintStream.println:(Ljava/lang/String;)V
+         8: return
+      LineNumberTable:
+        line 9: 0
+        line 10: 8
+
+  protected final java.lang.String giveItToMe();
+    descriptor: ()Ljava/lang/String;
+    flags: (0x0014) ACC_PROTECTED, ACC_FINAL
+    Code:
+      stack=1, locals=1, args_size=1
+         0: ldc           #8                  // String This is quite a long string, and it will be put in the constant pool...
+         2: areturn
+      LineNumberTable:
+        line 14: 0
+    Deprecated: true
+    RuntimeVisibleAnnotations:
+      0: #30()
+        java.lang.Deprecated
+}
+SourceFile: "HelloWorld.java"
+`
+
+func TestDisassemble(t *testing.T) {
+	_, c := readHelloWorld(t)
+
+	var buf bytes.Buffer
+	if err := c.Disassemble(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); got != helloWorldDisassembly {
+		t.Errorf("got:\n%s", got)
+	}
+}
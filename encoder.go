@@ -0,0 +1,739 @@
+package class
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// EncodeInstructions encodes insts into bytecode, exactly as they
+// are given: opcodes aren't changed and branch Offsets are written
+// verbatim (TargetLabel is ignored). The PC of every instruction
+// is updated to its position in the returned code, which also
+// determines the padding of tableswitch and lookupswitch.
+func EncodeInstructions(insts []*Instruction) ([]uint8, error) {
+	var buf bytes.Buffer
+
+	for _, inst := range insts {
+		inst.PC = buf.Len()
+
+		err := inst.write(&buf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if buf.Len() > maxCodeLength {
+		return nil, errors.New("jclass: code exceeds 65535 bytes")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Size returns the number of bytes the encoded instruction
+// takes up at its current PC.
+func (i *Instruction) Size() int {
+	size := 1
+	if i.Wide {
+		size++
+	}
+
+	switch opcodes[i.Opcode].format {
+	case operandByte, operandConstPoolByte, operandNewArray:
+		size += 1
+	case operandShort, operandConstPool, operandBranch:
+		size += 2
+	case operandLocal:
+		if i.Wide {
+			size += 2
+		} else {
+			size += 1
+		}
+	case operandIinc:
+		if i.Wide {
+			size += 4
+		} else {
+			size += 2
+		}
+	case operandBranchWide, operandInvokeInterface, operandInvokeDynamic:
+		size += 4
+	case operandMultiANewArray:
+		size += 3
+	case operandTableSwitch:
+		size += switchPadding(i.PC) + 12 + 4*len(i.Cases)
+	case operandLookupSwitch:
+		size += switchPadding(i.PC) + 8 + 8*len(i.Cases)
+	}
+
+	return size
+}
+
+func (i *Instruction) write(w io.Writer) error {
+	errRange := errors.New("jclass: operand of " + i.Mnemonic() + " out of range")
+
+	if i.Wide {
+		format := opcodes[i.Opcode].format
+		if format != operandLocal && format != operandIinc {
+			return errors.New("jclass: invalid instruction modified by wide")
+		}
+
+		err := binary.Write(w, byteOrder, WIDE)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := binary.Write(w, byteOrder, i.Opcode)
+	if err != nil {
+		return err
+	}
+
+	switch opcodes[i.Opcode].format {
+	case operandNone:
+		if !i.Opcode.IsValid() {
+			return errors.New("jclass: unknown opcode")
+		}
+
+		return nil
+
+	case operandByte:
+		if i.Const < math.MinInt8 || i.Const > math.MaxInt8 {
+			return errRange
+		}
+		return binary.Write(w, byteOrder, int8(i.Const))
+
+	case operandShort:
+		if i.Const < math.MinInt16 || i.Const > math.MaxInt16 {
+			return errRange
+		}
+		return binary.Write(w, byteOrder, int16(i.Const))
+
+	case operandConstPoolByte:
+		if i.Index > math.MaxUint8 {
+			return errRange
+		}
+		return binary.Write(w, byteOrder, uint8(i.Index))
+
+	case operandConstPool:
+		return binary.Write(w, byteOrder, i.Index)
+
+	case operandLocal:
+		return i.writeLocal(w)
+
+	case operandIinc:
+		err := i.writeLocal(w)
+		if err != nil {
+			return err
+		}
+
+		if i.Wide {
+			if i.Const < math.MinInt16 || i.Const > math.MaxInt16 {
+				return errRange
+			}
+			return binary.Write(w, byteOrder, int16(i.Const))
+		}
+
+		if i.Const < math.MinInt8 || i.Const > math.MaxInt8 {
+			return errRange
+		}
+		return binary.Write(w, byteOrder, int8(i.Const))
+
+	case operandBranch:
+		if i.Offset < math.MinInt16 || i.Offset > math.MaxInt16 {
+			return errRange
+		}
+		return binary.Write(w, byteOrder, int16(i.Offset))
+
+	case operandBranchWide:
+		return binary.Write(w, byteOrder, i.Offset)
+
+	case operandTableSwitch:
+		for n, c := range i.Cases {
+			if c.Match != i.Low+int32(n) {
+				return errors.New("jclass: tableswitch cases are not consecutive")
+			}
+		}
+
+		if len(i.Cases) == 0 {
+			return errors.New("jclass: tableswitch without cases")
+		}
+
+		err := multiError([]error{
+			binary.Write(w, byteOrder, make([]uint8, switchPadding(i.PC))),
+			binary.Write(w, byteOrder, i.Offset),
+			binary.Write(w, byteOrder, i.Low),
+			binary.Write(w, byteOrder, i.Low+int32(len(i.Cases))-1),
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, c := range i.Cases {
+			err := binary.Write(w, byteOrder, c.Offset)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	case operandLookupSwitch:
+		err := multiError([]error{
+			binary.Write(w, byteOrder, make([]uint8, switchPadding(i.PC))),
+			binary.Write(w, byteOrder, i.Offset),
+			binary.Write(w, byteOrder, int32(len(i.Cases))),
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, c := range i.Cases {
+			err := multiError([]error{
+				binary.Write(w, byteOrder, c.Match),
+				binary.Write(w, byteOrder, c.Offset),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	case operandInvokeInterface:
+		if i.Const < 0 || i.Const > math.MaxUint8 {
+			return errRange
+		}
+		return multiError([]error{
+			binary.Write(w, byteOrder, i.Index),
+			binary.Write(w, byteOrder, uint8(i.Const)),
+			binary.Write(w, byteOrder, uint8(0)),
+		})
+
+	case operandInvokeDynamic:
+		return multiError([]error{
+			binary.Write(w, byteOrder, i.Index),
+			binary.Write(w, byteOrder, uint16(0)),
+		})
+
+	case operandNewArray:
+		if i.Const < 0 || i.Const > math.MaxUint8 {
+			return errRange
+		}
+		return binary.Write(w, byteOrder, uint8(i.Const))
+
+	case operandMultiANewArray:
+		if i.Const < 0 || i.Const > math.MaxUint8 {
+			return errRange
+		}
+		return multiError([]error{
+			binary.Write(w, byteOrder, i.Index),
+			binary.Write(w, byteOrder, uint8(i.Const)),
+		})
+
+	case operandWide:
+		return errors.New("jclass: wide has to be expressed through Instruction.Wide")
+	}
+
+	return nil
+}
+
+func (i *Instruction) writeLocal(w io.Writer) error {
+	if i.Wide {
+		return binary.Write(w, byteOrder, i.Local)
+	}
+
+	if i.Local > math.MaxUint8 {
+		return errors.New("jclass: local variable index of " + i.Mnemonic() + " needs wide")
+	}
+
+	return binary.Write(w, byteOrder, uint8(i.Local))
+}
+
+// A Label is a symbolic position in the code of an Assembly. It is
+// bound to the position in Assembly.Code it is placed at, i.e. the
+// instruction following it (or the end of the code).
+type Label struct {
+	// PC of the position the label is bound to. Set when the
+	// Assembly is created and updated by Code.Assemble.
+	PC int
+}
+
+// An Assembly is an editable, symbolic form of the bytecode of a
+// Code attribute. All positions in the code (branch targets,
+// exception handler ranges, line numbers, local variable ranges,
+// stack map frames and type annotation offsets) are expressed
+// through labels, so instructions can be added, removed or
+// changed freely, before writing the result back with Code.Assemble.
+type Assembly struct {
+	// Instructions (*Instruction) and labels (*Label) in code order.
+	Code []CodeElement
+
+	ExceptionsTable []ExceptionHandler
+
+	// Labels of the PCs the attributes of the Code refer to.
+	labels map[int]*Label
+}
+
+// A CodeElement is either an *Instruction or a *Label.
+type CodeElement interface {
+	codeElement()
+}
+
+func (i *Instruction) codeElement() {}
+func (l *Label) codeElement()       {}
+
+// The symbolic form of a CodeException.
+type ExceptionHandler struct {
+	Start   *Label
+	End     *Label
+	Handler *Label
+	// may be zero, then used for finally
+	CatchType ConstPoolIndex
+}
+
+// Assembly decodes the bytecode of the Code attribute into its
+// symbolic form. Labels are created for all positions referenced
+// by instructions, the exceptions table and the attributes of the Code.
+func (a *Code) Assembly() (*Assembly, error) {
+	insts, err := a.Instructions()
+	if err != nil {
+		return nil, err
+	}
+
+	validPCs := map[int]bool{len(a.ByteCode): true}
+	for _, inst := range insts {
+		validPCs[inst.PC] = true
+	}
+
+	asm := &Assembly{labels: map[int]*Label{}}
+
+	var errInvalid error
+	label := func(pc int) *Label {
+		if !validPCs[pc] && errInvalid == nil {
+			errInvalid = errors.New("jclass: code position is not an instruction boundary")
+		}
+
+		l, ok := asm.labels[pc]
+		if !ok {
+			l = &Label{PC: pc}
+			asm.labels[pc] = l
+		}
+
+		return l
+	}
+
+	for _, inst := range insts {
+		switch opcodes[inst.Opcode].format {
+		case operandBranch, operandBranchWide:
+			inst.TargetLabel = label(inst.Target())
+		case operandTableSwitch, operandLookupSwitch:
+			inst.TargetLabel = label(inst.Target())
+			for n := range inst.Cases {
+				inst.Cases[n].TargetLabel = label(inst.PC + int(inst.Cases[n].Offset))
+			}
+		}
+	}
+
+	for _, e := range a.ExceptionsTable {
+		asm.ExceptionsTable = append(asm.ExceptionsTable, ExceptionHandler{
+			Start:     label(int(e.StartPC)),
+			End:       label(int(e.EndPC)),
+			Handler:   label(int(e.HandlerPC)),
+			CatchType: e.CatchType,
+		})
+	}
+
+	// Only create the labels, the attributes stay as they are.
+	_, err = relocateCodeAttributes(a.Attributes, func(pc int) (int, error) {
+		return label(pc).PC, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if errInvalid != nil {
+		return nil, errInvalid
+	}
+
+	for _, inst := range insts {
+		if l, ok := asm.labels[inst.PC]; ok {
+			asm.Code = append(asm.Code, l)
+		}
+
+		asm.Code = append(asm.Code, inst)
+	}
+
+	if l, ok := asm.labels[len(a.ByteCode)]; ok {
+		asm.Code = append(asm.Code, l)
+	}
+
+	return asm, nil
+}
+
+// Assemble encodes asm into the bytecode of the Code attribute,
+// and relocates the exceptions table and the attributes of the
+// Code to the new positions. If an error is returned, the Code
+// is left unchanged.
+// goto and goto_w, jsr and jsr_w as well as ldc and ldc_w are
+// chosen automatically, depending on the branch offset or the
+// constant pool index. Conditional branches can't be widened,
+// so an error is returned, if their target is too far away.
+func (a *Code) Assemble(asm *Assembly) error {
+	var insts []*Instruction
+	placed := map[*Label]bool{}
+
+	for _, elem := range asm.Code {
+		switch elem := elem.(type) {
+		case *Instruction:
+			insts = append(insts, elem)
+
+			switch elem.Opcode {
+			case LDC, LDC_W:
+				if elem.Index > math.MaxUint8 {
+					elem.Opcode = LDC_W
+				} else {
+					elem.Opcode = LDC
+				}
+			case GOTO_W:
+				elem.Opcode = GOTO
+			case JSR_W:
+				elem.Opcode = JSR
+			}
+		case *Label:
+			placed[elem] = true
+		}
+	}
+
+	for _, inst := range insts {
+		for _, target := range inst.targets() {
+			if !placed[target] {
+				return errors.New("jclass: branch target label is not placed in the code")
+			}
+		}
+	}
+
+	// Widening a branch moves all following code, which may in
+	// turn require more branches to be widened, so repeat until
+	// all offsets fit.
+	for {
+		pc := 0
+		for _, elem := range asm.Code {
+			switch elem := elem.(type) {
+			case *Instruction:
+				elem.PC = pc
+				pc += elem.Size()
+			case *Label:
+				elem.PC = pc
+			}
+		}
+
+		widened := false
+		for _, inst := range insts {
+			if opcodes[inst.Opcode].format != operandBranch || inst.TargetLabel == nil {
+				continue
+			}
+
+			offset := inst.TargetLabel.PC - inst.PC
+			if offset >= math.MinInt16 && offset <= math.MaxInt16 {
+				continue
+			}
+
+			switch inst.Opcode {
+			case GOTO:
+				inst.Opcode = GOTO_W
+			case JSR:
+				inst.Opcode = JSR_W
+			default:
+				return errors.New("jclass: branch offset of " + inst.Mnemonic() + " out of range")
+			}
+
+			widened = true
+		}
+
+		if !widened {
+			break
+		}
+	}
+
+	for _, inst := range insts {
+		if inst.TargetLabel != nil {
+			inst.Offset = int32(inst.TargetLabel.PC - inst.PC)
+		}
+
+		for n := range inst.Cases {
+			if inst.Cases[n].TargetLabel != nil {
+				inst.Cases[n].Offset = int32(inst.Cases[n].TargetLabel.PC - inst.PC)
+			}
+		}
+	}
+
+	code, err := EncodeInstructions(insts)
+	if err != nil {
+		return err
+	}
+
+	exceptions := make([]CodeException, 0, len(asm.ExceptionsTable))
+	for _, e := range asm.ExceptionsTable {
+		if !placed[e.Start] || !placed[e.End] || !placed[e.Handler] {
+			return errors.New("jclass: exception handler label is not placed in the code")
+		}
+
+		exceptions = append(exceptions, CodeException{
+			StartPC:   uint16(e.Start.PC),
+			EndPC:     uint16(e.End.PC),
+			HandlerPC: uint16(e.Handler.PC),
+			CatchType: e.CatchType,
+		})
+	}
+
+	apply, err := relocateCodeAttributes(a.Attributes, func(pc int) (int, error) {
+		l, ok := asm.labels[pc]
+		if !ok || !placed[l] {
+			return 0, errors.New("jclass: code attribute refers to a removed position")
+		}
+
+		return l.PC, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	apply()
+	a.ByteCode = code
+	a.ExceptionsTable = exceptions
+
+	labels := make(map[int]*Label, len(asm.labels))
+	for _, l := range asm.labels {
+		labels[l.PC] = l
+	}
+	asm.labels = labels
+
+	for _, attr := range a.Attributes {
+		err := updateLength(attr)
+		if err != nil {
+			return err
+		}
+	}
+
+	return updateLength(a)
+}
+
+func (i *Instruction) targets() []*Label {
+	var targets []*Label
+	if i.TargetLabel != nil {
+		targets = append(targets, i.TargetLabel)
+	}
+
+	for _, c := range i.Cases {
+		if c.TargetLabel != nil {
+			targets = append(targets, c.TargetLabel)
+		}
+	}
+
+	return targets
+}
+
+// relocateCodeAttributes maps all code positions in the attributes
+// of a Code attribute through relocate. The attributes aren't
+// changed until the returned apply func is called, so nothing is
+// changed if any position can't be relocated.
+func relocateCodeAttributes(attrs Attributes, relocate func(pc int) (int, error)) (apply func(), err error) {
+	var updates []func()
+
+	relocateRange := func(start, length uint16) (uint16, uint16, error) {
+		newStart, err := relocate(int(start))
+		if err != nil {
+			return 0, 0, err
+		}
+
+		newEnd, err := relocate(int(start) + int(length))
+		if err != nil {
+			return 0, 0, err
+		}
+
+		return uint16(newStart), uint16(newEnd - newStart), nil
+	}
+
+	relocateLocals := func(start, length *uint16) error {
+		newStart, newLength, err := relocateRange(*start, *length)
+		if err != nil {
+			return err
+		}
+
+		updates = append(updates, func() { *start, *length = newStart, newLength })
+		return nil
+	}
+
+	relocatePC := func(pc *uint16) error {
+		newPC, err := relocate(int(*pc))
+		if err != nil {
+			return err
+		}
+
+		updates = append(updates, func() { *pc = uint16(newPC) })
+		return nil
+	}
+
+	for _, attr := range attrs {
+		var err error
+
+		switch attr.GetTag() {
+		case LineNumberTableTag:
+			table := attr.LineNumberTable().Table
+			for n := range table {
+				err = relocatePC(&table[n].StartPC)
+				if err != nil {
+					break
+				}
+			}
+
+		case LocalVariableTableTag:
+			table := attr.LocalVariableTable().Table
+			for n := range table {
+				err = relocateLocals(&table[n].StartPC, &table[n].Length)
+				if err != nil {
+					break
+				}
+			}
+
+		case LocalVariableTypeTableTag:
+			table := attr.LocalVariableTypeTable().Table
+			for n := range table {
+				err = relocateLocals(&table[n].StartPC, &table[n].Length)
+				if err != nil {
+					break
+				}
+			}
+
+		case StackMapTableTag:
+			var update func()
+			update, err = relocateStackMapTable(attr.StackMapTable(), relocate)
+			updates = append(updates, update)
+
+		case RuntimeVisibleTypeAnnotationsTag, RuntimeInvisibleTypeAnnotationsTag:
+			for _, annotation := range (Attributes{attr}).TypeAnnotations() {
+				err = relocateTypeAnnotation(annotation, relocateLocals, relocatePC)
+				if err != nil {
+					break
+				}
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return func() {
+		for _, update := range updates {
+			update()
+		}
+	}, nil
+}
+
+// relocateStackMapTable computes the frames of table for the
+// relocated code, the returned func replaces the old ones.
+func relocateStackMapTable(table *StackMapTable, relocate func(pc int) (int, error)) (func(), error) {
+	entries := make([]StackMapFrame, len(table.Entries))
+	var offsets []func()
+
+	oldPrev, newPrev := -1, -1
+
+	for n, frame := range table.Entries {
+		// offset_delta + 1 for all frames, but the first one
+		oldPC := oldPrev + int(frame.OffsetDelta()) + 1
+		if n == 0 {
+			oldPC = int(frame.OffsetDelta())
+		}
+
+		newPC, err := relocate(oldPC)
+		if err != nil {
+			return nil, err
+		}
+
+		delta := newPC - newPrev - 1
+		if n == 0 {
+			delta = newPC
+		}
+
+		if delta < 0 || delta > math.MaxUint16 {
+			return nil, errors.New("jclass: stack map frames out of order")
+		}
+
+		var locals, stack []VerificationTypeInfo
+
+		switch f := frame.(type) {
+		case *SameFrame:
+			if delta < SAME_LOCALS_1_STACK_ITEM_FRAME {
+				entries[n] = &SameFrame{baseFrame{uint8(delta)}}
+			} else {
+				entries[n] = &SameFrameExtended{baseFrame{SAME_FRAME_EXTENDED}, uint16(delta)}
+			}
+		case *SameLocals1StackItemFrame:
+			stack = []VerificationTypeInfo{f.Stack}
+			if delta < SAME_LOCALS_1_STACK_ITEM_FRAME {
+				entries[n] = &SameLocals1StackItemFrame{baseFrame{SAME_LOCALS_1_STACK_ITEM_FRAME + uint8(delta)}, f.Stack}
+			} else {
+				entries[n] = &SameLocals1StackItemFrameExtended{
+					baseFrame{SAME_LOCALS_1_STACK_ITEM_FRAME_EXTENDED}, uint16(delta), f.Stack,
+				}
+			}
+		case *SameLocals1StackItemFrameExtended:
+			stack = []VerificationTypeInfo{f.Stack}
+			entries[n] = &SameLocals1StackItemFrameExtended{f.baseFrame, uint16(delta), f.Stack}
+		case *ChopFrame:
+			entries[n] = &ChopFrame{f.baseFrame, uint16(delta)}
+		case *SameFrameExtended:
+			entries[n] = &SameFrameExtended{f.baseFrame, uint16(delta)}
+		case *AppendFrame:
+			locals = f.Locals
+			entries[n] = &AppendFrame{f.baseFrame, uint16(delta), f.Locals}
+		case *FullFrame:
+			locals, stack = f.Locals, f.Stack
+			entries[n] = &FullFrame{f.baseFrame, uint16(delta), f.Locals, f.Stack}
+		}
+
+		for _, info := range append(locals, stack...) {
+			if uninitialized, ok := info.(*UninitializedVariable); ok {
+				pc, err := relocate(int(uninitialized.Offset))
+				if err != nil {
+					return nil, err
+				}
+
+				offsets = append(offsets, func() { uninitialized.Offset = uint16(pc) })
+			}
+		}
+
+		oldPrev, newPrev = oldPC, newPC
+	}
+
+	return func() {
+		table.Entries = entries
+		for _, offset := range offsets {
+			offset()
+		}
+	}, nil
+}
+
+func relocateTypeAnnotation(annotation *TypeAnnotation,
+	relocateLocals func(start, length *uint16) error,
+	relocatePC func(pc *uint16) error) error {
+
+	switch target := annotation.TargetInfo.(type) {
+	case *LocalVarTarget:
+		for n := range target.Table {
+			err := relocateLocals(&target.Table[n].StartPC, &target.Table[n].Length)
+			if err != nil {
+				return err
+			}
+		}
+	case *OffsetTarget:
+		return relocatePC(&target.Offset)
+	case *TypeArgumentTarget:
+		return relocatePC(&target.Offset)
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package class
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAssemblyRoundTrip(t *testing.T) {
+	data, c := readHelloWorld(t)
+
+	for _, method := range c.Methods {
+		code := method.Code()
+
+		asm, err := code.Assembly()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := code.Assemble(asm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := dump(t, c); !bytes.Equal(got, data) {
+		t.Error("reassembled class differs from the parsed one")
+	}
+}
+
+// frameCode returns nop, nop, nop, return with stack
+// map frames and line numbers at PC 1 and 3.
+func frameCode() *Code {
+	return &Code{
+		ByteCode: []uint8{uint8(NOP), uint8(NOP), uint8(NOP), uint8(RETURN)},
+		Attributes: Attributes{
+			&StackMapTable{Entries: []StackMapFrame{
+				&SameFrame{baseFrame{1}},
+				&SameFrame{baseFrame{1}},
+			}},
+			&LineNumberTable{Table: []LineNumber{{1, 10}, {3, 11}}},
+		},
+	}
+}
+
+func TestAssembleInsertBeforeFrame(t *testing.T) {
+	code := frameCode()
+
+	asm, err := code.Assembly()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	asm.Code = append([]CodeElement{&Instruction{Opcode: NOP}}, asm.Code...)
+
+	if err := code.Assemble(asm); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := code.Attributes[0].StackMapTable().Entries
+	if len(entries) != 2 || entries[0].OffsetDelta() != 2 || entries[1].OffsetDelta() != 1 {
+		t.Errorf("frames not moved to PC 2 and 4: %#v", entries)
+	}
+
+	lines := code.Attributes[1].LineNumberTable().Table
+	if lines[0].StartPC != 2 || lines[1].StartPC != 4 {
+		t.Errorf("line numbers not moved to PC 2 and 4: %v", lines)
+	}
+}
+
+func TestAssembleRemovedPosition(t *testing.T) {
+	// Elements of the Assembly: nop, L1, nop, nop, L3, return
+	removeL1 := func(code []CodeElement) []CodeElement {
+		return append(code[:1:1], code[3:]...)
+	}
+
+	removeL3 := func(code []CodeElement) []CodeElement {
+		return append([]CodeElement{&Instruction{Opcode: NOP}}, code[:4]...)
+	}
+
+	tests := []struct {
+		name  string
+		attrs func(*Code) Attributes
+		edit  func([]CodeElement) []CodeElement
+	}{
+		{"frame", func(c *Code) Attributes { return c.Attributes[:1] }, removeL1},
+		{"line number", func(c *Code) Attributes { return c.Attributes[1:] }, removeL1},
+		{"moved frame", func(c *Code) Attributes { return c.Attributes[:1] }, removeL3},
+		{"moved line number", func(c *Code) Attributes { return c.Attributes[1:] }, removeL3},
+	}
+
+	for _, test := range tests {
+		code := frameCode()
+		code.Attributes = test.attrs(code)
+		before := dump(t, code)
+
+		asm, err := code.Assembly()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		asm.Code = test.edit(asm.Code)
+
+		if err := code.Assemble(asm); err == nil {
+			t.Errorf("%s: removed position not reported", test.name)
+		}
+
+		if after := dump(t, code); !bytes.Equal(before, after) {
+			t.Errorf("%s: failed Assemble changed the code", test.name)
+		}
+	}
+}
+
+func TestAssembleWidenBranch(t *testing.T) {
+	target := &Label{}
+
+	newAssembly := func(branch Opcode) *Assembly {
+		asm := &Assembly{Code: []CodeElement{&Instruction{Opcode: branch, TargetLabel: target}}}
+		for i := 0; i < 40000; i++ {
+			asm.Code = append(asm.Code, &Instruction{Opcode: NOP})
+		}
+
+		return asm
+	}
+
+	asm := newAssembly(GOTO)
+	asm.Code = append(asm.Code, target, &Instruction{Opcode: RETURN})
+
+	code := &Code{}
+	if err := code.Assemble(asm); err != nil {
+		t.Fatal(err)
+	}
+
+	insts, err := code.Instructions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if insts[0].Opcode != GOTO_W || insts[0].Target() != 5+40000 {
+		t.Errorf("goto not widened: %s to %d", insts[0].Mnemonic(), insts[0].Target())
+	}
+
+	asm = newAssembly(IFEQ)
+	asm.Code = append(asm.Code, target, &Instruction{Opcode: RETURN})
+
+	if err := (&Code{}).Assemble(asm); err == nil {
+		t.Error("out of range conditional branch not reported")
+	}
+}
+
+func TestAssembleLDC(t *testing.T) {
+	tests := []struct {
+		opcode Opcode
+		index  ConstPoolIndex
+		want   Opcode
+	}{
+		{LDC, 255, LDC},
+		{LDC, 256, LDC_W},
+		{LDC_W, 1, LDC},
+	}
+
+	for _, test := range tests {
+		code := &Code{}
+
+		asm := &Assembly{Code: []CodeElement{&Instruction{Opcode: test.opcode, Index: test.index}}}
+		if err := code.Assemble(asm); err != nil {
+			t.Fatal(err)
+		}
+
+		insts, err := code.Instructions()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if insts[0].Opcode != test.want || insts[0].Index != test.index {
+			t.Errorf("ldc of #%d assembled as %s #%d", test.index, insts[0].Mnemonic(), insts[0].Index)
+		}
+	}
+}
+
+func TestAssembleSwitchPadding(t *testing.T) {
+	def, one := &Label{}, &Label{}
+
+	asm := &Assembly{Code: []CodeElement{
+		&Instruction{Opcode: ICONST_0},
+		&Instruction{Opcode: TABLESWITCH, TargetLabel: def, Low: 1, Cases: []SwitchCase{{Match: 1, TargetLabel: one}}},
+		one,
+		&Instruction{Opcode: NOP},
+		def,
+		&Instruction{Opcode: RETURN},
+	}}
+
+	code := &Code{}
+	if err := code.Assemble(asm); err != nil {
+		t.Fatal(err)
+	}
+
+	// iconst_0, 2 bytes padding to PC 4, default,
+	// low, high and one offset: 1 + 1 + 2 + 16
+	if one.PC != 20 || def.PC != 21 {
+		t.Errorf("switch padding wrong: case at %d, default at %d", one.PC, def.PC)
+	}
+
+	insts, err := code.Instructions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sw := insts[1]; sw.Target() != def.PC || sw.PC+int(sw.Cases[0].Offset) != one.PC {
+		t.Error("switch offsets wrong")
+	}
+
+	if got, err := EncodeInstructions(insts); err != nil || !bytes.Equal(got, code.ByteCode) {
+		t.Error("decoded switch doesn't encode to the same bytes", err)
+	}
+}
@@ -2,7 +2,10 @@ package class
 
 import (
 	"encoding/binary"
+	"errors"
 	"io"
+
+	"github.com/jcla1/jclass/descriptor"
 )
 
 type Field struct {
@@ -49,3 +52,172 @@ func (fom fieldMethod) Dump(w io.Writer) error {
 		writeAttributes(w, fom.Attributes),
 	})
 }
+
+// Parameter describes a single formal parameter of a method,
+// as far as it can be derived from the method's descriptor
+// and attributes.
+type Parameter struct {
+	// Field descriptor of the parameter type (e.g. "I" or
+	// "Ljava/lang/String;").
+	Descriptor string
+
+	// Name of the parameter, from the MethodParameters attribute
+	// or, as a fallback, the LocalVariableTable. May be "".
+	Name string
+
+	// Flags from the MethodParameters attribute, see PARAMETER_ACC_*.
+	AccessFlags
+
+	// Annotations from the RuntimeVisibleParameterAnnotations
+	// and RuntimeInvisibleParameterAnnotations attributes.
+	Annotations []*Annotation
+}
+
+// FindAnnotation returns the parameter annotation whose type
+// has the field descriptor typeName, or nil.
+func (p *Parameter) FindAnnotation(constPool ConstantPool, typeName string) (*Annotation, error) {
+	return findAnnotation(p.Annotations, constPool, typeName)
+}
+
+// Parameters returns the formal parameters of the method, in
+// declaration order, with their names and annotations lined up.
+// The parameter annotation attributes may describe fewer
+// parameters than the descriptor does (e.g. javac omits the
+// synthetic outer instance of inner class constructors), in
+// that case the annotations are aligned with the last parameters.
+func (m *Method) Parameters(constPool ConstantPool) ([]*Parameter, error) {
+	desc, err := constPool.LookupUTF8(m.DescriptorIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	method, err := descriptor.ParseMethod(desc)
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]*Parameter, 0, len(method.Params))
+	for _, param := range method.Params {
+		params = append(params, &Parameter{Descriptor: param.String()})
+	}
+
+	for _, attr := range m.Attributes {
+		var annotations [][]*Annotation
+
+		switch attr.GetTag() {
+		case RuntimeVisibleParameterAnnotationsTag:
+			annotations = attr.RuntimeVisibleParameterAnnotations().Parameters
+		case RuntimeInvisibleParameterAnnotationsTag:
+			annotations = attr.RuntimeInvisibleParameterAnnotations().Parameters
+		default:
+			continue
+		}
+
+		if len(annotations) > len(params) {
+			return nil, errors.New("jclass: more parameter annotations than parameters")
+		}
+
+		offset := len(params) - len(annotations)
+		for i, paramAnnotations := range annotations {
+			params[offset+i].Annotations = append(params[offset+i].Annotations, paramAnnotations...)
+		}
+	}
+
+	err = m.fillParameterNames(constPool, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
+// ParameterNames returns the names of the formal parameters of the
+// method. They are taken from the MethodParameters attribute, or, if
+// the method has none (it wasn't compiled with -parameters), from the
+// LocalVariableTable of its Code. Names that can't be found are "".
+func (m *Method) ParameterNames(constPool ConstantPool) ([]string, error) {
+	params, err := m.Parameters(constPool)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(params))
+	for _, param := range params {
+		names = append(names, param.Name)
+	}
+
+	return names, nil
+}
+
+func (m *Method) fillParameterNames(constPool ConstantPool, params []*Parameter) error {
+	for _, attr := range m.Attributes {
+		if attr.GetTag() != MethodParametersTag {
+			continue
+		}
+
+		methodParams := attr.MethodParameters().Parameters
+		if len(methodParams) > len(params) {
+			return errors.New("jclass: more method parameters than parameters")
+		}
+
+		offset := len(params) - len(methodParams)
+		for i, methodParam := range methodParams {
+			params[offset+i].AccessFlags = methodParam.AccessFlags
+			if methodParam.NameIndex != 0 {
+				name, err := constPool.LookupUTF8(methodParam.NameIndex)
+				if err != nil {
+					return err
+				}
+
+				params[offset+i].Name = name
+			}
+		}
+
+		return nil
+	}
+
+	// Without MethodParameters, the parameters are the first
+	// local variables, that are live from the very start.
+	slots := make([]uint16, len(params))
+
+	slot := uint16(0)
+	if m.AccessFlags&METHOD_ACC_STATIC == 0 {
+		slot++
+	}
+
+	for i, param := range params {
+		slots[i] = slot
+
+		// longs and doubles take up two slots
+		if param.Descriptor == "J" || param.Descriptor == "D" {
+			slot++
+		}
+		slot++
+	}
+
+	code := m.Code()
+	if code == nil {
+		return nil
+	}
+
+	for _, attr := range code.All(LocalVariableTableTag) {
+		for _, variable := range attr.LocalVariableTable().Table {
+			if variable.StartPC != 0 {
+				continue
+			}
+
+			for i := range params {
+				if slots[i] == variable.Index && params[i].Name == "" {
+					name, err := constPool.LookupUTF8(variable.NameIndex)
+					if err != nil {
+						return err
+					}
+
+					params[i].Name = name
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package class
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParameterAnnotationsReadDump(t *testing.T) {
+	body := []byte{
+		0x02,
+		0x00, 0x01, 0x00, 0x02, 0x00, 0x00,
+		0x00, 0x02, 0x00, 0x02, 0x00, 0x00, 0x00, 0x03, 0x00, 0x01, 0x00, 0x04, 'I', 0x00, 0x05,
+	}
+
+	for _, name := range []string{"RuntimeVisibleParameterAnnotations", "RuntimeInvisibleParameterAnnotations"} {
+		attr := readAttributeBody(t, testConstPool(name), body)
+
+		var params [][]*Annotation
+		if name == "RuntimeVisibleParameterAnnotations" {
+			params = attr.RuntimeVisibleParameterAnnotations().Parameters
+		} else {
+			params = attr.RuntimeInvisibleParameterAnnotations().Parameters
+		}
+
+		if len(params) != 2 || len(params[0]) != 1 || len(params[1]) != 2 || len(params[1][1].Pairs) != 1 {
+			t.Errorf("%s: got %v", name, params)
+		}
+	}
+
+	// No parameters at all
+	readAttributeBody(t, testConstPool("RuntimeVisibleParameterAnnotations"), []byte{0x00})
+}
+
+func TestParameterAnnotationAlignment(t *testing.T) {
+	constPool := testConstPool(
+		"RuntimeVisibleParameterAnnotations", // #1
+		"Lfoo/NotNull;",                      // #2
+		"(Lfoo/Outer;ILjava/lang/String;)V",  // #3
+		"Lfoo/Hidden;",                       // #4
+	)
+
+	// javac leaves out the synthetic outer instance
+	visible := readAttributeBody(t, constPool, []byte{
+		0x02,
+		0x00, 0x01, 0x00, 0x02, 0x00, 0x00,
+		0x00, 0x00,
+	})
+
+	method := &Method{fieldMethod{DescriptorIndex: 3, Attributes: Attributes{
+		visible,
+		&RuntimeInvisibleParameterAnnotations{Parameters: [][]*Annotation{{{TypeIndex: 4}}}},
+	}}}
+
+	params, err := method.Parameters(constPool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(params) != 3 || params[0].Descriptor != "Lfoo/Outer;" || params[1].Descriptor != "I" ||
+		params[2].Descriptor != "Ljava/lang/String;" {
+		t.Fatalf("got %v", params)
+	}
+
+	if len(params[0].Annotations) != 0 || len(params[1].Annotations) != 1 || len(params[2].Annotations) != 1 {
+		t.Errorf("annotations not aligned with the last parameters: %d, %d, %d",
+			len(params[0].Annotations), len(params[1].Annotations), len(params[2].Annotations))
+	}
+
+	if a, err := params[1].FindAnnotation(constPool, "Lfoo/NotNull;"); err != nil || a == nil {
+		t.Errorf("got %v, %v", a, err)
+	}
+
+	if a, err := params[2].FindAnnotation(constPool, "Lfoo/Hidden;"); err != nil || a == nil {
+		t.Errorf("got %v, %v", a, err)
+	}
+
+	if a, err := params[2].FindAnnotation(constPool, "Lfoo/NotNull;"); err != nil || a != nil {
+		t.Errorf("got %v, %v", a, err)
+	}
+
+	// More annotated parameters than the descriptor has
+	method.Attributes[1] = &RuntimeInvisibleParameterAnnotations{Parameters: make([][]*Annotation, 4)}
+	if _, err := method.Parameters(constPool); err == nil {
+		t.Error("too many parameter annotations not reported")
+	}
+}
+
+func TestMethodParametersReadDump(t *testing.T) {
+	attr := readAttributeBody(t, testConstPool("MethodParameters"), []byte{
+		0x03,
+		0x00, 0x02, 0x00, 0x10,
+		0x00, 0x00, 0x10, 0x00,
+		0x00, 0x03, 0x80, 0x00,
+	})
+
+	expected := []MethodParameter{
+		{2, PARAMETER_ACC_FINAL},
+		{0, PARAMETER_ACC_SYNTHETIC},
+		{3, PARAMETER_ACC_MANDATED},
+	}
+
+	if params := attr.MethodParameters().Parameters; !reflect.DeepEqual(params, expected) {
+		t.Errorf("got %v", params)
+	}
+
+	readAttributeBody(t, testConstPool("MethodParameters"), []byte{0x00})
+}
+
+func TestParameterNames(t *testing.T) {
+	constPool := testConstPool(
+		"MethodParameters",         // #1
+		"(JILjava/lang/String;D)V", // #2
+		"count",                    // #3
+		"this",                     // #4
+		"id",                       // #5
+		"scale",                    // #6
+		"tmp",                      // #7
+	)
+
+	methodParams := &MethodParameters{Parameters: []MethodParameter{
+		{5, PARAMETER_ACC_FINAL},
+		{3, 0},
+		{0, PARAMETER_ACC_SYNTHETIC},
+		{6, 0},
+	}}
+
+	// Slots of an instance method: this 0, J 1-2, I 3, String 4, D 5
+	localVariables := &LocalVariableTable{Table: []LocalVariable{
+		{0, 10, 4, 0, 0},
+		{0, 10, 5, 0, 1},
+		{0, 10, 3, 0, 3},
+		{2, 8, 7, 0, 4}, // not live from the start
+		{0, 10, 6, 0, 5},
+	}}
+	code := &Code{Attributes: Attributes{localVariables}}
+
+	tests := []struct {
+		name     string
+		flags    AccessFlags
+		attrs    Attributes
+		expected []string
+	}{
+		{"MethodParameters", 0, Attributes{code, methodParams}, []string{"id", "count", "", "scale"}},
+		{"instance method", 0, Attributes{code}, []string{"id", "count", "", "scale"}},
+		// this, J 0-1, I 2, String 3, D 4
+		{"static method", METHOD_ACC_STATIC, Attributes{code}, []string{"this", "", "count", ""}},
+		{"no Code", 0, Attributes{}, []string{"", "", "", ""}},
+	}
+
+	for _, test := range tests {
+		method := &Method{fieldMethod{AccessFlags: test.flags, DescriptorIndex: 2, Attributes: test.attrs}}
+
+		names, err := method.ParameterNames(constPool)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("%s: got %q, expected %q", test.name, names, test.expected)
+		}
+	}
+
+	method := &Method{fieldMethod{DescriptorIndex: 2, Attributes: Attributes{methodParams}}}
+	params, err := method.Parameters(constPool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if params[0].AccessFlags != PARAMETER_ACC_FINAL || params[2].AccessFlags != PARAMETER_ACC_SYNTHETIC {
+		t.Errorf("got flags %v and %v", params[0].AccessFlags, params[2].AccessFlags)
+	}
+
+	// More method parameters than the descriptor has
+	methodParams.Parameters = append(methodParams.Parameters, MethodParameter{})
+	if _, err := method.ParameterNames(constPool); err == nil {
+		t.Error("too many method parameters not reported")
+	}
+
+	// A name that isn't a Utf8 constant
+	methodParams.Parameters = []MethodParameter{{8, 0}}
+	if _, err := method.ParameterNames(constPool); err == nil {
+		t.Error("invalid name index not reported")
+	}
+}
@@ -0,0 +1,310 @@
+package class
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// The code array of a Code attribute has to be shorter
+// than 65536 bytes.
+const maxCodeLength = 65535
+
+// An Instruction is a single decoded instruction of a method's
+// bytecode. Only the operand fields used by the Opcode are set,
+// all others are zero.
+type Instruction struct {
+	// Offset of the (first) opcode byte in Code.ByteCode.
+	PC int
+
+	Opcode Opcode
+
+	// The instruction is prefixed by wide, so its local
+	// variable index (and iinc increment) are 2 bytes wide.
+	Wide bool
+
+	// Constant pool operand of ldc, ldc_w, ldc2_w, get/putstatic,
+	// get/putfield, invoke*, new, anewarray, checkcast,
+	// instanceof and multianewarray.
+	Index ConstPoolIndex
+
+	// Local variable operand of *load, *store, iinc and ret.
+	Local uint16
+
+	// Immediate operand: value of bipush and sipush, increment
+	// of iinc, atype of newarray, count of invokeinterface
+	// and dimensions of multianewarray.
+	Const int32
+
+	// Branch offset relative to PC, for if*, goto, goto_w, jsr,
+	// jsr_w and the default of tableswitch and lookupswitch.
+	Offset int32
+
+	// Symbolic branch target, used instead of Offset when the
+	// instruction is part of an Assembly.
+	TargetLabel *Label
+
+	// Lowest match of a tableswitch, the highest one
+	// is Low + len(Cases) - 1.
+	Low int32
+
+	// Cases of a tableswitch or lookupswitch. For tableswitch
+	// the Match of the i'th case is always Low + i.
+	Cases []SwitchCase
+}
+
+// A single case of a tableswitch or lookupswitch, Offset
+// is relative to the PC of the switch instruction.
+type SwitchCase struct {
+	Match  int32
+	Offset int32
+
+	// Symbolic jump target, see Instruction.TargetLabel.
+	TargetLabel *Label
+}
+
+// Mnemonic returns the mnemonic of the instruction's opcode
+// (without a wide prefix).
+func (i *Instruction) Mnemonic() string {
+	return i.Opcode.String()
+}
+
+// Target returns the absolute PC, that the branch offset of the
+// instruction points to.
+func (i *Instruction) Target() int {
+	return i.PC + int(i.Offset)
+}
+
+// Instructions decodes the bytecode of the Code attribute.
+func (a *Code) Instructions() ([]*Instruction, error) {
+	return DecodeInstructions(a.ByteCode)
+}
+
+// DecodeInstructions decodes raw bytecode into instructions,
+// in the order they appear. The first byte of code has to
+// be the first byte of the method's code, since the padding
+// of tableswitch and lookupswitch depends on it.
+func DecodeInstructions(code []uint8) ([]*Instruction, error) {
+	r := bytes.NewReader(code)
+	var insts []*Instruction
+
+	for r.Len() > 0 {
+		inst := &Instruction{PC: len(code) - r.Len()}
+
+		err := inst.read(r)
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+
+		insts = append(insts, inst)
+	}
+
+	return insts, nil
+}
+
+func (i *Instruction) read(r io.Reader) error {
+	var err error
+
+	err = binary.Read(r, byteOrder, &i.Opcode)
+	if err != nil {
+		return err
+	}
+
+	if i.Opcode == WIDE {
+		i.Wide = true
+
+		err = binary.Read(r, byteOrder, &i.Opcode)
+		if err != nil {
+			return err
+		}
+
+		format := opcodes[i.Opcode].format
+		if format != operandLocal && format != operandIinc {
+			return errors.New("jclass: invalid instruction modified by wide")
+		}
+	}
+
+	switch opcodes[i.Opcode].format {
+	case operandNone:
+		if !i.Opcode.IsValid() {
+			return errors.New("jclass: unknown opcode")
+		}
+
+		return nil
+
+	case operandByte:
+		var value int8
+		err = binary.Read(r, byteOrder, &value)
+		i.Const = int32(value)
+
+	case operandShort:
+		var value int16
+		err = binary.Read(r, byteOrder, &value)
+		i.Const = int32(value)
+
+	case operandConstPoolByte:
+		var index uint8
+		err = binary.Read(r, byteOrder, &index)
+		i.Index = ConstPoolIndex(index)
+
+	case operandConstPool:
+		err = binary.Read(r, byteOrder, &i.Index)
+
+	case operandLocal:
+		err = i.readLocal(r)
+
+	case operandIinc:
+		err = i.readLocal(r)
+		if err != nil {
+			return err
+		}
+
+		if i.Wide {
+			var value int16
+			err = binary.Read(r, byteOrder, &value)
+			i.Const = int32(value)
+		} else {
+			var value int8
+			err = binary.Read(r, byteOrder, &value)
+			i.Const = int32(value)
+		}
+
+	case operandBranch:
+		var offset int16
+		err = binary.Read(r, byteOrder, &offset)
+		i.Offset = int32(offset)
+
+	case operandBranchWide:
+		err = binary.Read(r, byteOrder, &i.Offset)
+
+	case operandTableSwitch:
+		err = i.readTableSwitch(r)
+
+	case operandLookupSwitch:
+		err = i.readLookupSwitch(r)
+
+	case operandInvokeInterface:
+		var count, zero uint8
+		err = multiError([]error{
+			binary.Read(r, byteOrder, &i.Index),
+			binary.Read(r, byteOrder, &count),
+			binary.Read(r, byteOrder, &zero),
+		})
+		i.Const = int32(count)
+
+	case operandInvokeDynamic:
+		var zero uint16
+		err = multiError([]error{
+			binary.Read(r, byteOrder, &i.Index),
+			binary.Read(r, byteOrder, &zero),
+		})
+
+	case operandNewArray:
+		var atype uint8
+		err = binary.Read(r, byteOrder, &atype)
+		i.Const = int32(atype)
+
+	case operandMultiANewArray:
+		var dimensions uint8
+		err = multiError([]error{
+			binary.Read(r, byteOrder, &i.Index),
+			binary.Read(r, byteOrder, &dimensions),
+		})
+		i.Const = int32(dimensions)
+
+	case operandWide:
+		return errors.New("jclass: wide modifying wide")
+	}
+
+	return err
+}
+
+func (i *Instruction) readLocal(r io.Reader) error {
+	if i.Wide {
+		return binary.Read(r, byteOrder, &i.Local)
+	}
+
+	var local uint8
+	err := binary.Read(r, byteOrder, &local)
+	i.Local = uint16(local)
+	return err
+}
+
+// switchPadding returns the number of padding bytes between
+// the opcode of a switch at pc and its first operand, which
+// has to start at a multiple of 4 from the start of the code.
+func switchPadding(pc int) int {
+	return (4 - (pc+1)%4) % 4
+}
+
+func (i *Instruction) readTableSwitch(r io.Reader) error {
+	var err error
+
+	var high int32
+	err = multiError([]error{
+		binary.Read(r, byteOrder, make([]uint8, switchPadding(i.PC))),
+		binary.Read(r, byteOrder, &i.Offset),
+		binary.Read(r, byteOrder, &i.Low),
+		binary.Read(r, byteOrder, &high),
+	})
+	if err != nil {
+		return err
+	}
+
+	if high < i.Low {
+		return errors.New("jclass: tableswitch high is lower than low")
+	}
+
+	count := int64(high) - int64(i.Low) + 1
+	if count > maxCodeLength/4 {
+		return errors.New("jclass: tableswitch exceeds code length")
+	}
+
+	offsets := make([]int32, count)
+	err = binary.Read(r, byteOrder, offsets)
+	if err != nil {
+		return err
+	}
+
+	i.Cases = make([]SwitchCase, 0, len(offsets))
+	for n, offset := range offsets {
+		i.Cases = append(i.Cases, SwitchCase{Match: i.Low + int32(n), Offset: offset})
+	}
+
+	return nil
+}
+
+func (i *Instruction) readLookupSwitch(r io.Reader) error {
+	var err error
+
+	var pairsCount int32
+	err = multiError([]error{
+		binary.Read(r, byteOrder, make([]uint8, switchPadding(i.PC))),
+		binary.Read(r, byteOrder, &i.Offset),
+		binary.Read(r, byteOrder, &pairsCount),
+	})
+	if err != nil {
+		return err
+	}
+
+	if pairsCount < 0 || pairsCount > maxCodeLength/8 {
+		return errors.New("jclass: invalid lookupswitch npairs")
+	}
+
+	i.Cases = make([]SwitchCase, pairsCount)
+	for n := range i.Cases {
+		err := multiError([]error{
+			binary.Read(r, byteOrder, &i.Cases[n].Match),
+			binary.Read(r, byteOrder, &i.Cases[n].Offset),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package class
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// switchCode returns code with a switch at pc, preceded by nops
+// and followed by its padding and the int32 operands.
+func switchCode(pc int, opcode Opcode, operands ...int32) []uint8 {
+	code := append(make([]uint8, pc), uint8(opcode))
+	code = append(code, make([]uint8, switchPadding(pc))...)
+
+	for _, operand := range operands {
+		code = byteOrder.AppendUint32(code, uint32(operand))
+	}
+
+	return code
+}
+
+// nops returns the n nop instructions in front of a switchCode.
+func nops(n int) []*Instruction {
+	insts := make([]*Instruction, 0, n+1)
+	for pc := 0; pc < n; pc++ {
+		insts = append(insts, &Instruction{PC: pc, Opcode: NOP})
+	}
+
+	return insts
+}
+
+func TestDecodeInstructions(t *testing.T) {
+	type decodeTest struct {
+		name     string
+		code     []uint8
+		expected []*Instruction
+	}
+
+	tests := []decodeTest{
+		{
+			"wide iload",
+			[]uint8{uint8(ILOAD), 0xff, uint8(WIDE), uint8(ILOAD), 0x01, 0x00, uint8(RET), 0x02},
+			[]*Instruction{
+				{PC: 0, Opcode: ILOAD, Local: 255},
+				{PC: 2, Opcode: ILOAD, Wide: true, Local: 256},
+				{PC: 6, Opcode: RET, Local: 2},
+			},
+		},
+		{
+			"wide iinc",
+			[]uint8{uint8(IINC), 0x03, 0xff, uint8(WIDE), uint8(IINC), 0x01, 0x03, 0x80, 0x00},
+			[]*Instruction{
+				{PC: 0, Opcode: IINC, Local: 3, Const: -1},
+				{PC: 3, Opcode: IINC, Wide: true, Local: 259, Const: -32768},
+			},
+		},
+		{
+			"immediates",
+			[]uint8{uint8(BIPUSH), 0x80, uint8(SIPUSH), 0x01, 0x00, uint8(NEWARRAY), 0x0a, uint8(LDC), 0xff},
+			[]*Instruction{
+				{PC: 0, Opcode: BIPUSH, Const: -128},
+				{PC: 2, Opcode: SIPUSH, Const: 256},
+				{PC: 5, Opcode: NEWARRAY, Const: 10},
+				{PC: 7, Opcode: LDC, Index: 255},
+			},
+		},
+		{
+			"invocations",
+			[]uint8{uint8(INVOKEINTERFACE), 0x00, 0x07, 0x02, 0x00, uint8(INVOKEDYNAMIC), 0x01, 0x02, 0x00, 0x00,
+				uint8(MULTIANEWARRAY), 0x00, 0x03, 0x02},
+			[]*Instruction{
+				{PC: 0, Opcode: INVOKEINTERFACE, Index: 7, Const: 2},
+				{PC: 5, Opcode: INVOKEDYNAMIC, Index: 258},
+				{PC: 10, Opcode: MULTIANEWARRAY, Index: 3, Const: 2},
+			},
+		},
+		{
+			"branches",
+			[]uint8{uint8(IFEQ), 0xff, 0xfd, uint8(GOTO_W), 0x00, 0x01, 0x00, 0x00},
+			[]*Instruction{
+				{PC: 0, Opcode: IFEQ, Offset: -3},
+				{PC: 3, Opcode: GOTO_W, Offset: 65536},
+			},
+		},
+	}
+
+	for pc := 0; pc < 4; pc++ {
+		tests = append(tests, decodeTest{
+			fmt.Sprintf("tableswitch at pc %d", pc),
+			switchCode(pc, TABLESWITCH, 20, -1, 0, 8, 12),
+			append(nops(pc), &Instruction{PC: pc, Opcode: TABLESWITCH, Offset: 20, Low: -1,
+				Cases: []SwitchCase{{Match: -1, Offset: 8}, {Match: 0, Offset: 12}}}),
+		}, decodeTest{
+			fmt.Sprintf("lookupswitch at pc %d", pc),
+			switchCode(pc, LOOKUPSWITCH, 16, 2, 1, 8, 100, -12),
+			append(nops(pc), &Instruction{PC: pc, Opcode: LOOKUPSWITCH, Offset: 16,
+				Cases: []SwitchCase{{Match: 1, Offset: 8}, {Match: 100, Offset: -12}}}),
+		})
+	}
+
+	for _, test := range tests {
+		insts, err := DecodeInstructions(test.code)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(insts, test.expected) {
+			t.Errorf("%s: got", test.name)
+			for _, inst := range insts {
+				t.Errorf("\t%#v", inst)
+			}
+		}
+	}
+}
+
+func TestDecodeInstructionsTruncated(t *testing.T) {
+	tests := []struct {
+		name string
+		code []uint8
+	}{
+		{"bipush", []uint8{uint8(NOP), uint8(BIPUSH)}},
+		{"sipush", []uint8{uint8(SIPUSH), 0x01}},
+		{"wide", []uint8{uint8(WIDE)}},
+		{"wide iload", []uint8{uint8(WIDE), uint8(ILOAD), 0x01}},
+		{"wide iinc", []uint8{uint8(WIDE), uint8(IINC), 0x00, 0x01, 0x00}},
+		{"invokeinterface", []uint8{uint8(INVOKEINTERFACE), 0x00, 0x07, 0x02}},
+		{"goto_w", []uint8{uint8(GOTO_W), 0x00, 0x00, 0x01}},
+		{"switch padding", []uint8{uint8(NOP), uint8(TABLESWITCH), 0x00}},
+		{"tableswitch offsets", switchCode(1, TABLESWITCH, 20, 0, 1, 8)},
+		{"lookupswitch pairs", switchCode(2, LOOKUPSWITCH, 16, 2, 1, 8, 100)},
+	}
+
+	for _, test := range tests {
+		insts, err := DecodeInstructions(test.code)
+		if !errors.Is(err, io.ErrUnexpectedEOF) || insts != nil {
+			t.Errorf("%s: got %v, %v", test.name, insts, err)
+		}
+	}
+
+	invalid := []struct {
+		name string
+		code []uint8
+	}{
+		{"unknown opcode", []uint8{0xcb}},
+		{"wide nop", []uint8{uint8(WIDE), uint8(NOP)}},
+		{"wide wide", []uint8{uint8(WIDE), uint8(WIDE), uint8(ILOAD), 0x00, 0x01}},
+		{"tableswitch high below low", switchCode(0, TABLESWITCH, 0, 1, 0)},
+		{"negative npairs", switchCode(0, LOOKUPSWITCH, 0, -1)},
+	}
+
+	for _, test := range invalid {
+		if _, err := DecodeInstructions(test.code); err == nil || errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%s: got %v", test.name, err)
+		}
+	}
+}
@@ -2,7 +2,11 @@ package class
 
 import (
 	"encoding/binary"
+	"errors"
+	"fmt"
 	"io"
+	"math"
+	"strings"
 )
 
 var byteOrder = binary.BigEndian
@@ -20,6 +24,21 @@ var initFuncs = []func(*ClassFile, io.Reader) error{
 	(*ClassFile).readAttributes,
 }
 
+// Names of the structures read by initFuncs,
+// used as the path of a ParseError.
+var initNames = []string{
+	"magic",
+	"version",
+	"constant_pool",
+	"access_flags",
+	"this_class",
+	"super_class",
+	"interfaces",
+	"fields",
+	"methods",
+	"attributes",
+}
+
 var dumpFuncs = []func(*ClassFile, io.Writer) error{
 	(*ClassFile).writeMagic,
 	(*ClassFile).writeVersion,
@@ -35,14 +54,26 @@ var dumpFuncs = []func(*ClassFile, io.Writer) error{
 
 // Parse reads a Java class file from r and, on success,
 // returns the parsed struct. Otherwise nil and the error.
+// Errors caused by malformed class files are returned
+// as *ParseError. That includes attribute tables with
+// more than one attribute of a kind, which may occur only
+// once (e.g. two SourceFile attributes). Such class files
+// can still be created with Assemble.
 func Parse(r io.Reader) (*ClassFile, error) {
 	c := &ClassFile{}
+	r = &countingReader{r: r}
 
 	var err error
 
-	for _, f := range initFuncs {
+	for n, f := range initFuncs {
+		offset := bytesRead(r)
+
 		err = f(c, r)
 		if err != nil {
+			if _, ok := err.(*ParseError); !ok {
+				err = newParseError(err, initNames[n], offset)
+			}
+
 			return nil, err
 		}
 	}
@@ -55,7 +86,25 @@ func Parse(r io.Reader) (*ClassFile, error) {
 // When a class file is parsed and then dumped
 // (unmodified), both (files) should be exactly
 // the same.
+// Before writing, ConstPoolSize and the lengths of
+// all attributes are recomputed (and updated in c),
+// so modifications result in a valid class file.
+// Use DumpOriginal to write the stored values instead.
 func (c *ClassFile) Dump(w io.Writer) error {
+	err := c.UpdateLengths()
+	if err != nil {
+		return err
+	}
+
+	return c.DumpOriginal(w)
+}
+
+// DumpOriginal writes the binary representation of the
+// ClassFile struct like Dump, but writes ConstPoolSize
+// and the attribute lengths as they are stored, even if
+// they don't match the contents. This is useful to
+// reproduce (possibly malformed) class files byte by byte.
+func (c *ClassFile) DumpOriginal(w io.Writer) error {
 	var err error
 
 	for _, f := range dumpFuncs {
@@ -68,6 +117,29 @@ func (c *ClassFile) Dump(w io.Writer) error {
 	return nil
 }
 
+// UpdateLengths recomputes ConstPoolSize and the Length
+// of every attribute (including the ones nested in Code
+// attributes and record components) from their contents.
+func (c *ClassFile) UpdateLengths() error {
+	size := constPoolSize(c.ConstantPool)
+	if size > math.MaxUint16 {
+		return errors.New("jclass: constant pool too large")
+	}
+
+	c.ConstPoolSize = uint16(size)
+
+	var errs []error
+	for _, field := range c.Fields {
+		errs = append(errs, updateLengths(field.Attributes))
+	}
+	for _, method := range c.Methods {
+		errs = append(errs, updateLengths(method.Attributes))
+	}
+	errs = append(errs, updateLengths(c.Attributes))
+
+	return multiError(errs)
+}
+
 func (c *ClassFile) readMagic(r io.Reader) error {
 	return binary.Read(r, byteOrder, &c.Magic)
 }
@@ -145,9 +217,11 @@ func (c *ClassFile) readFields(r io.Reader) error {
 	c.Fields = make([]*Field, 0, count)
 
 	for i := uint16(0); i < count; i++ {
+		offset := bytesRead(r)
+
 		fieldMethod, err := readFieldMethod(r, c.ConstantPool)
 		if err != nil {
-			return err
+			return newParseError(err, fmt.Sprintf("fields[%d]", i), offset)
 		}
 
 		field := &Field{*fieldMethod}
@@ -183,9 +257,11 @@ func (c *ClassFile) readMethods(r io.Reader) error {
 	c.Methods = make([]*Method, 0, count)
 
 	for i := uint16(0); i < count; i++ {
+		offset := bytesRead(r)
+
 		fieldMethod, err := readFieldMethod(r, c.ConstantPool)
 		if err != nil {
-			return err
+			return newParseError(err, fmt.Sprintf("methods[%d]", i), offset)
 		}
 
 		method := &Method{*fieldMethod}
@@ -221,6 +297,86 @@ func (c *ClassFile) writeAttributes(w io.Writer) error {
 	return writeAttributes(w, c.Attributes)
 }
 
+// A ParseError describes where and why a class file
+// couldn't be parsed.
+type ParseError struct {
+	// Byte offset of the structure, that couldn't be
+	// parsed, from the start of the class file.
+	Offset int64
+
+	// Path of the structure, e.g. constant_pool[37] or
+	// methods[3].attributes[Code].attributes[LineNumberTable].
+	// Attributes are named, if their name could be read.
+	Path string
+
+	// The offending tag, if the error is caused by an
+	// unknown tag, which is indicated by HasTag.
+	Tag    uint8
+	HasTag bool
+
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	msg := fmt.Sprintf("jclass: %s at offset %d: %s", e.Path, e.Offset, strings.TrimPrefix(e.Err.Error(), "jclass: "))
+	if e.HasTag {
+		msg += fmt.Sprintf(" %d", e.Tag)
+	}
+
+	return msg
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// newParseError returns err as a *ParseError with the given
+// path and offset. If err already is a *ParseError (of a
+// nested structure), path is prepended to its path instead.
+func newParseError(err error, path string, offset int64) error {
+	parseErr, ok := err.(*ParseError)
+	if !ok {
+		// The class file mustn't end before its last structure
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		parseErr = &ParseError{Err: err}
+	}
+
+	if parseErr.Path == "" {
+		parseErr.Path = path
+		parseErr.Offset = offset
+	} else {
+		parseErr.Path = path + "." + parseErr.Path
+	}
+
+	return parseErr
+}
+
+// countingReader keeps track of the number of bytes read,
+// to report offsets in a ParseError.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// bytesRead returns the number of bytes read from r,
+// if it's a countingReader, otherwise -1.
+func bytesRead(r io.Reader) int64 {
+	if r, ok := r.(*countingReader); ok {
+		return r.n
+	}
+
+	return -1
+}
+
 // Useful when reading from data stream multiple times
 func multiError(errs []error) error {
 	for _, err := range errs {
@@ -0,0 +1,234 @@
+package class
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+const helloWorldPath = "examples/res/HelloWorld.class"
+
+func readHelloWorld(t *testing.T) ([]byte, *ClassFile) {
+	data, err := ioutil.ReadFile(helloWorldPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data, c
+}
+
+func dump(t *testing.T, d Dumper) []byte {
+	var buf bytes.Buffer
+	if err := d.Dump(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// testConstPool builds a constant pool of the values, strings
+// become Utf8 constants, int32s Integer constants, and constants
+// are used as they are (nil for the second slot of a Long).
+func testConstPool(values ...interface{}) ConstantPool {
+	constPool := make(ConstantPool, 0, len(values))
+
+	for _, value := range values {
+		switch value := value.(type) {
+		case string:
+			constPool = append(constPool, &UTF8Ref{baseConstant{CONSTANT_UTF8}, value, nil})
+		case int32:
+			constPool = append(constPool, &IntegerRef{baseConstant{CONSTANT_Integer}, value})
+		case Constant:
+			constPool = append(constPool, value)
+		default:
+			constPool = append(constPool, nil)
+		}
+	}
+
+	return constPool
+}
+
+// readAttributeBody reads an attribute, named by #1 of constPool,
+// with the given body and checks, that it dumps to the same bytes.
+func readAttributeBody(t *testing.T, constPool ConstantPool, body []byte) Attribute {
+	t.Helper()
+
+	data := append([]byte{0x00, 0x01, 0, 0, 0, 0}, body...)
+	byteOrder.PutUint32(data[2:], uint32(len(body)))
+
+	r := bytes.NewReader(data)
+	attr, err := readAttribute(r, constPool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Len() != 0 {
+		t.Errorf("%d bytes of %T left", r.Len(), attr)
+	}
+
+	if got := dump(t, attr); !bytes.Equal(got, data) {
+		t.Errorf("%T dumped as % x, want % x", attr, got, data)
+	}
+
+	return attr
+}
+
+func TestParseDump(t *testing.T) {
+	data, c := readHelloWorld(t)
+
+	if got := dump(t, c); !bytes.Equal(got, data) {
+		t.Error("dumped class differs from the parsed one")
+	}
+
+	var buf bytes.Buffer
+	if err := c.DumpOriginal(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Error("original dump differs from the parsed class")
+	}
+}
+
+// modifyHelloWorld appends a line number to giveItToMe and
+// a constant to the constant pool, without updating lengths.
+func modifyHelloWorld(t *testing.T) *ClassFile {
+	_, c := readHelloWorld(t)
+
+	code := c.Methods[2].Code()
+	lines := code.Attributes[0].LineNumberTable()
+	lines.Table = append(lines.Table, LineNumber{2, 15})
+
+	// #49 is the last (always empty) slot
+	c.ConstantPool[48] = &UTF8Ref{baseConstant{CONSTANT_UTF8}, "appended", nil}
+	c.ConstantPool = append(c.ConstantPool, nil)
+
+	return c
+}
+
+func TestDumpUpdatesLengths(t *testing.T) {
+	_, original := readHelloWorld(t)
+	originalCode := original.Methods[2].Code()
+
+	c := modifyHelloWorld(t)
+
+	parsed, err := Parse(bytes.NewReader(dump(t, c)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.ConstPoolSize != original.ConstPoolSize+1 {
+		t.Errorf("ConstPoolSize %d, want %d", parsed.ConstPoolSize, original.ConstPoolSize+1)
+	}
+
+	if s, err := parsed.LookupUTF8(49); err != nil || s != "appended" {
+		t.Errorf("got %q, %v", s, err)
+	}
+
+	code := parsed.Methods[2].Code()
+	if code.Length != originalCode.Length+4 {
+		t.Errorf("Code length %d, want %d", code.Length, originalCode.Length+4)
+	}
+
+	lines := code.Attributes[0].LineNumberTable()
+	if lines.Length != originalCode.Attributes[0].LineNumberTable().Length+4 || len(lines.Table) != 2 {
+		t.Errorf("LineNumberTable length %d with %d lines", lines.Length, len(lines.Table))
+	}
+
+	// Dump stores the updated values
+	if c.ConstPoolSize != parsed.ConstPoolSize || c.Methods[2].Code().Length != code.Length {
+		t.Error("lengths not updated in the dumped class")
+	}
+}
+
+func TestDumpOriginalKeepsLengths(t *testing.T) {
+	data, original := readHelloWorld(t)
+	c := modifyHelloWorld(t)
+
+	var buf bytes.Buffer
+	if err := c.DumpOriginal(&buf); err != nil {
+		t.Fatal(err)
+	}
+	dumped := buf.Bytes()
+
+	if c.ConstPoolSize != original.ConstPoolSize {
+		t.Errorf("ConstPoolSize changed to %d", c.ConstPoolSize)
+	}
+
+	// constant_pool_count follows magic and version
+	if count := byteOrder.Uint16(dumped[8:]); count != original.ConstPoolSize {
+		t.Errorf("constant_pool_count %d, want %d", count, original.ConstPoolSize)
+	}
+
+	// The LineNumberTable of giveItToMe with the stale
+	// attribute_length of 6, but two lines.
+	stale := []byte{0x00, 23, 0x00, 0x00, 0x00, 0x06, 0x00, 0x02, 0x00, 0x00, 0x00, 14, 0x00, 0x02, 0x00, 15}
+	if !bytes.Contains(dumped, stale) {
+		t.Error("LineNumberTable not written with its stored length")
+	}
+
+	code := c.Methods[2].Code()
+	if code.Length != original.Methods[2].Code().Length {
+		t.Errorf("Code length changed to %d", code.Length)
+	}
+
+	// Only the constants up to the stored ConstPoolSize
+	// are written, so just the line number was added.
+	if len(dumped) != len(data)+4 {
+		t.Errorf("dumped %d bytes, expected %d", len(dumped), len(data)+4)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	data, _ := readHelloWorld(t)
+
+	tests := []struct {
+		name   string
+		data   []byte
+		path   string
+		offset int64
+		hasTag bool
+		err    error
+	}{
+		// The tag of the first constant is at offset 10
+		{"tag 0", append(append(data[:10:10], 0), data[11:]...), "constant_pool[1]", 10, true, errUnknownConstantTag},
+		{"tag 2", append(append(data[:10:10], 2), data[11:]...), "constant_pool[1]", 10, true, errUnknownConstantTag},
+		{"truncated", data[:12], "constant_pool[1]", 10, false, io.ErrUnexpectedEOF},
+		// The LineNumberTable of giveItToMe starts at offset 840
+		{
+			"truncated attribute header", data[:842],
+			"methods[2].attributes[Code].attributes[0]", 840, false, io.ErrUnexpectedEOF,
+		},
+		{
+			"truncated attribute body", data[:848],
+			"methods[2].attributes[Code].attributes[LineNumberTable]", 840, false, io.ErrUnexpectedEOF,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(bytes.NewReader(test.data))
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("%s: got %v", test.name, err)
+		}
+
+		if parseErr.Path != test.path || parseErr.Offset != test.offset || parseErr.HasTag != test.hasTag ||
+			parseErr.Tag != test.data[10] && test.hasTag || !errors.Is(err, test.err) {
+			t.Errorf("%s: got %#v", test.name, parseErr)
+		}
+
+		if test.hasTag && !strings.HasSuffix(err.Error(), fmt.Sprintf(" %d", test.data[10])) {
+			t.Errorf("%s: tag missing from %q", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package class
+
+import (
+	"errors"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Strings in the constant pool are encoded in Modified UTF-8
+// (JVMS 4.4.7), which differs from standard UTF-8 in two ways:
+// The null character is encoded with two bytes (0xC0 0x80), and
+// supplementary characters are encoded as surrogate pairs, each
+// surrogate taking up three bytes.
+
+// decodeModifiedUTF8 decodes Modified UTF-8 into a (valid UTF-8)
+// Go string. Invalid byte sequences and unpaired surrogates are
+// replaced by U+FFFD.
+func decodeModifiedUTF8(b []byte) string {
+	units := make([]uint16, 0, len(b))
+
+	for i := 0; i < len(b); {
+		c := b[i]
+
+		switch {
+		case c < 0x80:
+			units = append(units, uint16(c))
+			i++
+
+		case c&0xE0 == 0xC0 && i+1 < len(b) && b[i+1]&0xC0 == 0x80:
+			units = append(units, uint16(c&0x1F)<<6|uint16(b[i+1]&0x3F))
+			i += 2
+
+		case c&0xF0 == 0xE0 && i+2 < len(b) && b[i+1]&0xC0 == 0x80 && b[i+2]&0xC0 == 0x80:
+			units = append(units, uint16(c&0x0F)<<12|uint16(b[i+1]&0x3F)<<6|uint16(b[i+2]&0x3F))
+			i += 3
+
+		default:
+			units = append(units, utf8.RuneError)
+			i++
+		}
+	}
+
+	return string(utf16.Decode(units))
+}
+
+// encodeModifiedUTF8 encodes s in Modified UTF-8.
+func encodeModifiedUTF8(s string) []byte {
+	b := make([]byte, 0, len(s))
+
+	for _, r := range s {
+		switch {
+		case r != 0 && r < 0x80:
+			b = append(b, byte(r))
+
+		case r < 0x800:
+			b = append(b, 0xC0|byte(r>>6), 0x80|byte(r&0x3F))
+
+		case r < 0x10000:
+			b = appendModifiedUTF8Unit(b, uint16(r))
+
+		default:
+			high, low := utf16.EncodeRune(r)
+			b = appendModifiedUTF8Unit(b, uint16(high))
+			b = appendModifiedUTF8Unit(b, uint16(low))
+		}
+	}
+
+	return b
+}
+
+func appendModifiedUTF8Unit(b []byte, unit uint16) []byte {
+	return append(b, 0xE0|byte(unit>>12), 0x80|byte(unit>>6&0x3F), 0x80|byte(unit&0x3F))
+}
+
+var errStringTooLong = errors.New("jclass: string exceeds 65535 bytes in Modified UTF-8")
@@ -0,0 +1,68 @@
+package class
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeModifiedUTF8(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want string
+		// in is the Modified UTF-8 encoding of want
+		roundTrip bool
+	}{
+		{"ascii", []byte("abc"), "abc", true},
+		{"null", []byte{0xC0, 0x80}, "\x00", true},
+		{"two bytes", []byte{0xC3, 0xA4}, "ä", true},
+		{"three bytes", []byte{0xE2, 0x82, 0xAC}, "€", true},
+		{"surrogate pair", []byte{0xED, 0xA0, 0xBD, 0xED, 0xB8, 0x80}, "\U0001F600", true},
+		{"lone surrogate", []byte{0xED, 0xA0, 0xBD}, "�", false},
+		{"invalid byte", []byte{0xFF}, "�", false},
+		{"four bytes", []byte{0xF0, 0x9F, 0x98, 0x80}, "����", false},
+		{"truncated", []byte{'a', 0xE2, 0x82}, "a��", false},
+	}
+
+	for _, test := range tests {
+		if got := decodeModifiedUTF8(test.in); got != test.want {
+			t.Errorf("%s: decoded % x as %q, want %q", test.name, test.in, got, test.want)
+		}
+
+		if !test.roundTrip {
+			continue
+		}
+
+		if got := encodeModifiedUTF8(test.want); !bytes.Equal(got, test.in) {
+			t.Errorf("%s: encoded %q as % x, want % x", test.name, test.want, got, test.in)
+		}
+	}
+}
+
+func TestUTF8RefRaw(t *testing.T) {
+	tests := [][]byte{
+		{0xFF},
+		{0xED, 0xA0, 0xBD},
+		{0xF0, 0x9F, 0x98, 0x80},
+		{0xC0, 0x80, 'a'},
+	}
+
+	for _, raw := range tests {
+		data := append([]byte{byte(CONSTANT_UTF8), 0, byte(len(raw))}, raw...)
+
+		constant, err := readConstant(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := dump(t, constant); !bytes.Equal(got, data) {
+			t.Errorf("% x dumped as % x", data, got)
+		}
+	}
+
+	// Once Value changes, it's encoded instead
+	utf8 := &UTF8Ref{baseConstant: baseConstant{CONSTANT_UTF8}, Value: "\x00", Raw: []byte{0xFF}}
+	if got := utf8.Bytes(); !bytes.Equal(got, []byte{0xC0, 0x80}) {
+		t.Errorf("changed value encoded as % x", got)
+	}
+}
@@ -0,0 +1,210 @@
+package class
+
+// ModuleDescriptor is the resolved form of the Module,
+// ModulePackages and ModuleMainClass attributes of a
+// module-info.class. All names of modules, packages and classes
+// are taken verbatim from the constant pool, so packages and classes
+// are in their internal form (e.g. "java/util" or "java/lang/Object").
+type ModuleDescriptor struct {
+	Name    string
+	Flags   AccessFlags
+	Version string
+
+	Requires []ModuleRequire
+	Exports  []ModulePackageGrant
+	Opens    []ModulePackageGrant
+	Uses     []string
+	Provides []ModuleService
+
+	// Packages from the ModulePackages attribute, or nil.
+	Packages []string
+
+	// MainClass from the ModuleMainClass attribute, or "".
+	MainClass string
+}
+
+// A module the described module depends on.
+type ModuleRequire struct {
+	Name    string
+	Flags   AccessFlags
+	Version string
+}
+
+// A package that is exported or opened, either to all modules
+// (To is empty) or only to the listed ones.
+type ModulePackageGrant struct {
+	Package string
+	Flags   AccessFlags
+	To      []string
+}
+
+// A service interface and its implementations.
+type ModuleService struct {
+	Service string
+	With    []string
+}
+
+// IsModule reports whether the class file describes a module,
+// rather than a class or interface.
+func (c *ClassFile) IsModule() bool {
+	return c.AccessFlags&CLASS_ACC_MODULE != 0
+}
+
+// ModuleDescriptor resolves the module attributes of the class
+// file through its constant pool. It returns nil, if the class
+// file has no Module attribute.
+func (c *ClassFile) ModuleDescriptor() (*ModuleDescriptor, error) {
+	module := c.Attributes.First(ModuleTag)
+	if module == nil {
+		return nil, nil
+	}
+
+	desc, err := c.resolveModule(module.Module())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, attr := range c.Attributes {
+		switch attr.GetTag() {
+		case ModulePackagesTag:
+			desc.Packages, err = c.packageNames(attr.ModulePackages().Packages)
+		case ModuleMainClassTag:
+			desc.MainClass, err = c.className(attr.ModuleMainClass().MainClassIndex)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return desc, nil
+}
+
+func (c *ClassFile) resolveModule(module *Module) (*ModuleDescriptor, error) {
+	r := &moduleResolver{c: c}
+
+	desc := &ModuleDescriptor{
+		Name:    r.moduleName(module.NameIndex),
+		Flags:   module.Flags,
+		Version: r.optionalUTF8(module.VersionIndex),
+	}
+
+	for _, requires := range module.Requires {
+		desc.Requires = append(desc.Requires, ModuleRequire{
+			Name:    r.moduleName(requires.RequiresIndex),
+			Flags:   requires.Flags,
+			Version: r.optionalUTF8(requires.VersionIndex),
+		})
+	}
+
+	for _, exports := range module.Exports {
+		desc.Exports = append(desc.Exports, ModulePackageGrant{
+			Package: r.packageName(exports.ExportsIndex),
+			Flags:   exports.Flags,
+			To:      r.moduleNames(exports.ExportsTo),
+		})
+	}
+
+	for _, opens := range module.Opens {
+		desc.Opens = append(desc.Opens, ModulePackageGrant{
+			Package: r.packageName(opens.OpensIndex),
+			Flags:   opens.Flags,
+			To:      r.moduleNames(opens.OpensTo),
+		})
+	}
+
+	for _, uses := range module.Uses {
+		desc.Uses = append(desc.Uses, r.className(uses))
+	}
+
+	for _, provides := range module.Provides {
+		service := ModuleService{Service: r.className(provides.ProvidesIndex)}
+		for _, with := range provides.ProvidesWith {
+			service.With = append(service.With, r.className(with))
+		}
+
+		desc.Provides = append(desc.Provides, service)
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return desc, nil
+}
+
+// moduleResolver looks up names in the constant pool,
+// keeping the first error that occurred.
+type moduleResolver struct {
+	c   *ClassFile
+	err error
+}
+
+func (r *moduleResolver) check(name string, err error) string {
+	if r.err == nil {
+		r.err = err
+	}
+
+	return name
+}
+
+func (r *moduleResolver) optionalUTF8(index ConstPoolIndex) string {
+	if index == 0 {
+		return ""
+	}
+
+	return r.check(r.c.LookupUTF8(index))
+}
+
+func (r *moduleResolver) className(index ConstPoolIndex) string {
+	return r.check(r.c.className(index))
+}
+
+func (r *moduleResolver) moduleName(index ConstPoolIndex) string {
+	return r.check(r.c.moduleName(index))
+}
+
+func (r *moduleResolver) moduleNames(indexes []ConstPoolIndex) []string {
+	var names []string
+	for _, index := range indexes {
+		names = append(names, r.moduleName(index))
+	}
+
+	return names
+}
+
+func (r *moduleResolver) packageName(index ConstPoolIndex) string {
+	return r.check(r.c.packageName(index))
+}
+
+func (c *ClassFile) moduleName(index ConstPoolIndex) (string, error) {
+	module, err := c.LookupModule(index)
+	if err != nil {
+		return "", err
+	}
+
+	return c.LookupUTF8(module.NameIndex)
+}
+
+func (c *ClassFile) packageName(index ConstPoolIndex) (string, error) {
+	pkg, err := c.LookupPackage(index)
+	if err != nil {
+		return "", err
+	}
+
+	return c.LookupUTF8(pkg.NameIndex)
+}
+
+func (c *ClassFile) packageNames(indexes []ConstPoolIndex) ([]string, error) {
+	var names []string
+	for _, index := range indexes {
+		name, err := c.packageName(index)
+		if err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
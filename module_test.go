@@ -0,0 +1,177 @@
+package class
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestModuleConstantsReadDump(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		constant Constant
+	}{
+		{[]byte{byte(CONSTANT_Module), 0x00, 0x03}, &ModuleRef{baseConstant{CONSTANT_Module}, 3}},
+		{[]byte{byte(CONSTANT_Package), 0x01, 0x00}, &PackageRef{baseConstant{CONSTANT_Package}, 256}},
+	}
+
+	for _, test := range tests {
+		constant, err := readConstant(bytes.NewReader(test.data))
+		if err != nil {
+			t.Errorf("% x: %v", test.data, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(constant, test.constant) {
+			t.Errorf("% x: read as %#v", test.data, constant)
+		}
+
+		if got := dump(t, constant); !bytes.Equal(got, test.data) {
+			t.Errorf("% x: dumped as % x", test.data, got)
+		}
+	}
+}
+
+func moduleConstPool() ConstantPool {
+	module := func(index ConstPoolIndex) Constant { return &ModuleRef{baseConstant{CONSTANT_Module}, index} }
+	pkg := func(index ConstPoolIndex) Constant { return &PackageRef{baseConstant{CONSTANT_Package}, index} }
+	class := func(index ConstPoolIndex) Constant { return &ClassRef{baseConstant{CONSTANT_Class}, index} }
+
+	return testConstPool(
+		"Module",                  // #1
+		module(3),                 // #2
+		"com.example",             // #3
+		"1.0",                     // #4
+		module(6),                 // #5
+		"java.base",               // #6
+		"17",                      // #7
+		module(9),                 // #8
+		"java.logging",            // #9
+		pkg(11),                   // #10
+		"com/example/api",         // #11
+		pkg(13),                   // #12
+		"com/example/impl",        // #13
+		class(15),                 // #14
+		"com/example/api/Service", // #15
+		class(17),                 // #16
+		"com/example/impl/A",      // #17
+		class(19),                 // #18
+		"com/example/impl/B",      // #19
+		class(21),                 // #20
+		"com/example/Main",        // #21
+	)
+}
+
+// moduleBody describes an open module com.example@1.0.
+var moduleBody = []byte{
+	0x00, 0x02, 0x00, 0x20, 0x00, 0x04,
+	// requires mandated java.base@17, transitive java.logging
+	0x00, 0x02,
+	0x00, 0x05, 0x80, 0x00, 0x00, 0x07,
+	0x00, 0x08, 0x00, 0x20, 0x00, 0x00,
+	// exports com/example/api, com/example/impl to java.logging
+	0x00, 0x02,
+	0x00, 0x0a, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x0c, 0x00, 0x00, 0x00, 0x01, 0x00, 0x08,
+	// opens com/example/impl to java.base, java.logging
+	0x00, 0x01,
+	0x00, 0x0c, 0x00, 0x00, 0x00, 0x02, 0x00, 0x05, 0x00, 0x08,
+	// uses Service
+	0x00, 0x01, 0x00, 0x0e,
+	// provides Service with A, B
+	0x00, 0x01,
+	0x00, 0x0e, 0x00, 0x02, 0x00, 0x10, 0x00, 0x12,
+}
+
+func TestModuleReadDump(t *testing.T) {
+	attr := readAttributeBody(t, moduleConstPool(), moduleBody)
+
+	expected := &Module{
+		NameIndex:    2,
+		Flags:        0x0020,
+		VersionIndex: 4,
+		Requires:     []ModuleRequires{{5, 0x8000, 7}, {8, 0x0020, 0}},
+		Exports:      []ModuleExports{{10, 0, []ConstPoolIndex{}}, {12, 0, []ConstPoolIndex{8}}},
+		Opens:        []ModuleOpens{{12, 0, []ConstPoolIndex{5, 8}}},
+		Uses:         []ConstPoolIndex{14},
+		Provides:     []ModuleProvides{{14, []ConstPoolIndex{16, 18}}},
+	}
+
+	module := attr.Module()
+	expected.baseAttribute = module.baseAttribute
+	if !reflect.DeepEqual(module, expected) {
+		t.Errorf("read as %#v", module)
+	}
+
+	packages := readAttributeBody(t, testConstPool("ModulePackages"), []byte{0x00, 0x02, 0x00, 0x0a, 0x00, 0x0c})
+	if got := packages.ModulePackages().Packages; !reflect.DeepEqual(got, []ConstPoolIndex{10, 12}) {
+		t.Errorf("got packages %v", got)
+	}
+
+	mainClass := readAttributeBody(t, testConstPool("ModuleMainClass"), []byte{0x00, 0x14})
+	if got := mainClass.ModuleMainClass().MainClassIndex; got != 20 {
+		t.Errorf("got main class #%d", got)
+	}
+
+	// A truncated exports_to table
+	data := append([]byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x22}, moduleBody[:34]...)
+	if _, err := readAttribute(bytes.NewReader(data), moduleConstPool()); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestModuleDescriptor(t *testing.T) {
+	constPool := moduleConstPool()
+	c := &ClassFile{
+		AccessFlags:  CLASS_ACC_MODULE,
+		ConstantPool: constPool,
+		Attributes: Attributes{
+			readAttributeBody(t, constPool, moduleBody),
+			readAttributeBody(t, testConstPool("ModulePackages"), []byte{0x00, 0x02, 0x00, 0x0a, 0x00, 0x0c}),
+			readAttributeBody(t, testConstPool("ModuleMainClass"), []byte{0x00, 0x14}),
+		},
+	}
+
+	if !c.IsModule() {
+		t.Error("module not reported as such")
+	}
+
+	desc, err := c.ModuleDescriptor()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &ModuleDescriptor{
+		Name:    "com.example",
+		Flags:   0x0020,
+		Version: "1.0",
+		Requires: []ModuleRequire{
+			{"java.base", 0x8000, "17"},
+			{"java.logging", 0x0020, ""},
+		},
+		Exports: []ModulePackageGrant{
+			{"com/example/api", 0, nil},
+			{"com/example/impl", 0, []string{"java.logging"}},
+		},
+		Opens:     []ModulePackageGrant{{"com/example/impl", 0, []string{"java.base", "java.logging"}}},
+		Uses:      []string{"com/example/api/Service"},
+		Provides:  []ModuleService{{"com/example/api/Service", []string{"com/example/impl/A", "com/example/impl/B"}}},
+		Packages:  []string{"com/example/api", "com/example/impl"},
+		MainClass: "com/example/Main",
+	}
+
+	if !reflect.DeepEqual(desc, expected) {
+		t.Errorf("got %#v", desc)
+	}
+
+	// A package where a module is expected
+	c.Attributes[0].Module().Requires[1].RequiresIndex = 10
+	if _, err := c.ModuleDescriptor(); err == nil {
+		t.Error("package used as module not reported")
+	}
+
+	_, hello := readHelloWorld(t)
+	if desc, err := hello.ModuleDescriptor(); desc != nil || err != nil || hello.IsModule() {
+		t.Errorf("got %v, %v for a class", desc, err)
+	}
+}
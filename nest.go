@@ -0,0 +1,77 @@
+package class
+
+// NestHostName returns the internal name of the nest host of the
+// class, which is the class named in its NestHost attribute or,
+// if there is none, the class itself.
+func (c *ClassFile) NestHostName() (string, error) {
+	for _, attr := range c.Attributes {
+		if attr.GetTag() == NestHostTag {
+			return attr.NestHost().HostClassName(c.ConstantPool)
+		}
+	}
+
+	return c.className(c.ThisClass)
+}
+
+// NestMemberNames returns the internal names of the classes
+// listed in the NestMembers attribute of the class, if any.
+func (c *ClassFile) NestMemberNames() ([]string, error) {
+	for _, attr := range c.Attributes {
+		if attr.GetTag() == NestMembersTag {
+			return attr.NestMembers().ClassNames(c.ConstantPool)
+		}
+	}
+
+	return nil, nil
+}
+
+// IsNestmateOf reports whether c and other belong to the same nest,
+// and therefore may access each other's private members.
+// Both classes have to claim the same nest host and, if one of them
+// is the host itself, it has to list the other one as a member.
+// Membership of two non-host classes can't be validated without
+// the host's class file, so it's assumed.
+func (c *ClassFile) IsNestmateOf(other *ClassFile) (bool, error) {
+	host, err := c.NestHostName()
+	if err != nil {
+		return false, err
+	}
+
+	otherHost, err := other.NestHostName()
+	if err != nil || host != otherHost {
+		return false, err
+	}
+
+	name, err := c.className(c.ThisClass)
+	if err != nil {
+		return false, err
+	}
+
+	otherName, err := other.className(other.ThisClass)
+	if err != nil || name == otherName {
+		return err == nil, err
+	}
+
+	var members []string
+
+	switch host {
+	case name:
+		members, err = c.NestMemberNames()
+		return containsString(members, otherName), err
+	case otherName:
+		members, err = other.NestMemberNames()
+		return containsString(members, name), err
+	}
+
+	return true, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, elem := range list {
+		if elem == s {
+			return true
+		}
+	}
+
+	return false
+}
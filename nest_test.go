@@ -0,0 +1,69 @@
+package class
+
+import "testing"
+
+func TestIsNestmateOf(t *testing.T) {
+	class := func(index ConstPoolIndex) Constant { return &ClassRef{baseConstant{CONSTANT_Class}, index} }
+
+	constPool := testConstPool(
+		class(2),  // #1
+		"Outer",   // #2
+		class(4),  // #3
+		"Outer$A", // #4
+		class(6),  // #5
+		"Outer$B", // #6
+		class(8),  // #7
+		"Outer$C", // #8
+		class(10), // #9
+		"Other",   // #10
+	)
+
+	nestClass := func(thisClass ConstPoolIndex, attrs ...Attribute) *ClassFile {
+		return &ClassFile{ConstantPool: constPool, ThisClass: thisClass, Attributes: attrs}
+	}
+
+	outer := nestClass(1, &NestMembers{Classes: []ConstPoolIndex{3, 5}})
+	a := nestClass(3, &NestHost{HostClassIndex: 1})
+	b := nestClass(5, &NestHost{HostClassIndex: 1})
+	// Claims Outer as its host, but isn't listed by it
+	c := nestClass(7, &NestHost{HostClassIndex: 1})
+	other := nestClass(9)
+
+	tests := []struct {
+		name     string
+		c, other *ClassFile
+		expected bool
+	}{
+		{"host and member", outer, a, true},
+		{"member and host", b, outer, true},
+		{"members of the same host", a, b, true},
+		{"unvalidated member", a, c, true},
+		{"host and unlisted member", outer, c, false},
+		{"unlisted member and host", c, outer, false},
+		{"unrelated host", outer, other, false},
+		{"unrelated member", other, a, false},
+		{"same class", other, other, true},
+	}
+
+	for _, test := range tests {
+		nestmates, err := test.c.IsNestmateOf(test.other)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if nestmates != test.expected {
+			t.Errorf("%s: got %t, expected %t", test.name, nestmates, test.expected)
+		}
+	}
+
+	// A nest host that isn't a Class constant
+	broken := nestClass(3, &NestHost{HostClassIndex: 2})
+	if _, err := broken.IsNestmateOf(outer); err == nil {
+		t.Error("invalid nest host not reported")
+	}
+
+	if _, err := outer.IsNestmateOf(broken); err == nil {
+		t.Error("invalid nest host of the other class not reported")
+	}
+}
@@ -0,0 +1,488 @@
+package class
+
+import (
+	"fmt"
+)
+
+// Opcode is the first byte of every instruction in Code.ByteCode,
+// determining the operation and which operands follow it.
+// http://docs.oracle.com/javase/specs/jvms/se7/html/jvms-6.html#jvms-6.5
+type Opcode uint8
+
+// These constants are all opcodes defined by the JVM
+// specification, named after their mnemonics.
+// http://docs.oracle.com/javase/specs/jvms/se7/html/jvms-7.html
+const (
+	NOP             Opcode = iota // 0x00
+	ACONST_NULL                   // 0x01
+	ICONST_M1                     // 0x02
+	ICONST_0                      // 0x03
+	ICONST_1                      // 0x04
+	ICONST_2                      // 0x05
+	ICONST_3                      // 0x06
+	ICONST_4                      // 0x07
+	ICONST_5                      // 0x08
+	LCONST_0                      // 0x09
+	LCONST_1                      // 0x0a
+	FCONST_0                      // 0x0b
+	FCONST_1                      // 0x0c
+	FCONST_2                      // 0x0d
+	DCONST_0                      // 0x0e
+	DCONST_1                      // 0x0f
+	BIPUSH                        // 0x10
+	SIPUSH                        // 0x11
+	LDC                           // 0x12
+	LDC_W                         // 0x13
+	LDC2_W                        // 0x14
+	ILOAD                         // 0x15
+	LLOAD                         // 0x16
+	FLOAD                         // 0x17
+	DLOAD                         // 0x18
+	ALOAD                         // 0x19
+	ILOAD_0                       // 0x1a
+	ILOAD_1                       // 0x1b
+	ILOAD_2                       // 0x1c
+	ILOAD_3                       // 0x1d
+	LLOAD_0                       // 0x1e
+	LLOAD_1                       // 0x1f
+	LLOAD_2                       // 0x20
+	LLOAD_3                       // 0x21
+	FLOAD_0                       // 0x22
+	FLOAD_1                       // 0x23
+	FLOAD_2                       // 0x24
+	FLOAD_3                       // 0x25
+	DLOAD_0                       // 0x26
+	DLOAD_1                       // 0x27
+	DLOAD_2                       // 0x28
+	DLOAD_3                       // 0x29
+	ALOAD_0                       // 0x2a
+	ALOAD_1                       // 0x2b
+	ALOAD_2                       // 0x2c
+	ALOAD_3                       // 0x2d
+	IALOAD                        // 0x2e
+	LALOAD                        // 0x2f
+	FALOAD                        // 0x30
+	DALOAD                        // 0x31
+	AALOAD                        // 0x32
+	BALOAD                        // 0x33
+	CALOAD                        // 0x34
+	SALOAD                        // 0x35
+	ISTORE                        // 0x36
+	LSTORE                        // 0x37
+	FSTORE                        // 0x38
+	DSTORE                        // 0x39
+	ASTORE                        // 0x3a
+	ISTORE_0                      // 0x3b
+	ISTORE_1                      // 0x3c
+	ISTORE_2                      // 0x3d
+	ISTORE_3                      // 0x3e
+	LSTORE_0                      // 0x3f
+	LSTORE_1                      // 0x40
+	LSTORE_2                      // 0x41
+	LSTORE_3                      // 0x42
+	FSTORE_0                      // 0x43
+	FSTORE_1                      // 0x44
+	FSTORE_2                      // 0x45
+	FSTORE_3                      // 0x46
+	DSTORE_0                      // 0x47
+	DSTORE_1                      // 0x48
+	DSTORE_2                      // 0x49
+	DSTORE_3                      // 0x4a
+	ASTORE_0                      // 0x4b
+	ASTORE_1                      // 0x4c
+	ASTORE_2                      // 0x4d
+	ASTORE_3                      // 0x4e
+	IASTORE                       // 0x4f
+	LASTORE                       // 0x50
+	FASTORE                       // 0x51
+	DASTORE                       // 0x52
+	AASTORE                       // 0x53
+	BASTORE                       // 0x54
+	CASTORE                       // 0x55
+	SASTORE                       // 0x56
+	POP                           // 0x57
+	POP2                          // 0x58
+	DUP                           // 0x59
+	DUP_X1                        // 0x5a
+	DUP_X2                        // 0x5b
+	DUP2                          // 0x5c
+	DUP2_X1                       // 0x5d
+	DUP2_X2                       // 0x5e
+	SWAP                          // 0x5f
+	IADD                          // 0x60
+	LADD                          // 0x61
+	FADD                          // 0x62
+	DADD                          // 0x63
+	ISUB                          // 0x64
+	LSUB                          // 0x65
+	FSUB                          // 0x66
+	DSUB                          // 0x67
+	IMUL                          // 0x68
+	LMUL                          // 0x69
+	FMUL                          // 0x6a
+	DMUL                          // 0x6b
+	IDIV                          // 0x6c
+	LDIV                          // 0x6d
+	FDIV                          // 0x6e
+	DDIV                          // 0x6f
+	IREM                          // 0x70
+	LREM                          // 0x71
+	FREM                          // 0x72
+	DREM                          // 0x73
+	INEG                          // 0x74
+	LNEG                          // 0x75
+	FNEG                          // 0x76
+	DNEG                          // 0x77
+	ISHL                          // 0x78
+	LSHL                          // 0x79
+	ISHR                          // 0x7a
+	LSHR                          // 0x7b
+	IUSHR                         // 0x7c
+	LUSHR                         // 0x7d
+	IAND                          // 0x7e
+	LAND                          // 0x7f
+	IOR                           // 0x80
+	LOR                           // 0x81
+	IXOR                          // 0x82
+	LXOR                          // 0x83
+	IINC                          // 0x84
+	I2L                           // 0x85
+	I2F                           // 0x86
+	I2D                           // 0x87
+	L2I                           // 0x88
+	L2F                           // 0x89
+	L2D                           // 0x8a
+	F2I                           // 0x8b
+	F2L                           // 0x8c
+	F2D                           // 0x8d
+	D2I                           // 0x8e
+	D2L                           // 0x8f
+	D2F                           // 0x90
+	I2B                           // 0x91
+	I2C                           // 0x92
+	I2S                           // 0x93
+	LCMP                          // 0x94
+	FCMPL                         // 0x95
+	FCMPG                         // 0x96
+	DCMPL                         // 0x97
+	DCMPG                         // 0x98
+	IFEQ                          // 0x99
+	IFNE                          // 0x9a
+	IFLT                          // 0x9b
+	IFGE                          // 0x9c
+	IFGT                          // 0x9d
+	IFLE                          // 0x9e
+	IF_ICMPEQ                     // 0x9f
+	IF_ICMPNE                     // 0xa0
+	IF_ICMPLT                     // 0xa1
+	IF_ICMPGE                     // 0xa2
+	IF_ICMPGT                     // 0xa3
+	IF_ICMPLE                     // 0xa4
+	IF_ACMPEQ                     // 0xa5
+	IF_ACMPNE                     // 0xa6
+	GOTO                          // 0xa7
+	JSR                           // 0xa8
+	RET                           // 0xa9
+	TABLESWITCH                   // 0xaa
+	LOOKUPSWITCH                  // 0xab
+	IRETURN                       // 0xac
+	LRETURN                       // 0xad
+	FRETURN                       // 0xae
+	DRETURN                       // 0xaf
+	ARETURN                       // 0xb0
+	RETURN                        // 0xb1
+	GETSTATIC                     // 0xb2
+	PUTSTATIC                     // 0xb3
+	GETFIELD                      // 0xb4
+	PUTFIELD                      // 0xb5
+	INVOKEVIRTUAL                 // 0xb6
+	INVOKESPECIAL                 // 0xb7
+	INVOKESTATIC                  // 0xb8
+	INVOKEINTERFACE               // 0xb9
+	INVOKEDYNAMIC                 // 0xba
+	NEW                           // 0xbb
+	NEWARRAY                      // 0xbc
+	ANEWARRAY                     // 0xbd
+	ARRAYLENGTH                   // 0xbe
+	ATHROW                        // 0xbf
+	CHECKCAST                     // 0xc0
+	INSTANCEOF                    // 0xc1
+	MONITORENTER                  // 0xc2
+	MONITOREXIT                   // 0xc3
+	WIDE                          // 0xc4
+	MULTIANEWARRAY                // 0xc5
+	IFNULL                        // 0xc6
+	IFNONNULL                     // 0xc7
+	GOTO_W                        // 0xc8
+	JSR_W                         // 0xc9
+
+	// reserved for debuggers and implementation specific use
+	BREAKPOINT        // 0xca
+	IMPDEP1    Opcode = 0xfe
+	IMPDEP2    Opcode = 0xff
+)
+
+// These constants are the possible values of the atype
+// operand of the newarray instruction.
+// http://docs.oracle.com/javase/specs/jvms/se7/html/jvms-6.html#jvms-6.5.newarray
+const (
+	T_BOOLEAN = 4
+	T_CHAR    = 5
+	T_FLOAT   = 6
+	T_DOUBLE  = 7
+	T_BYTE    = 8
+	T_SHORT   = 9
+	T_INT     = 10
+	T_LONG    = 11
+)
+
+// operandFormat describes which operands follow an opcode
+// and how they are encoded.
+type operandFormat uint8
+
+const (
+	operandNone            operandFormat = iota
+	operandByte                          // s1 immediate (bipush)
+	operandShort                         // s2 immediate (sipush)
+	operandConstPoolByte                 // u1 constant pool index (ldc)
+	operandConstPool                     // u2 constant pool index
+	operandLocal                         // u1 local variable index, u2 if wide
+	operandIinc                          // local variable index and s1 increment, both u2/s2 if wide
+	operandBranch                        // s2 branch offset
+	operandBranchWide                    // s4 branch offset
+	operandTableSwitch                   // padding, default, low, high and jump offsets
+	operandLookupSwitch                  // padding, default, npairs and match-offset pairs
+	operandInvokeInterface               // u2 constant pool index, u1 count and a zero byte
+	operandInvokeDynamic                 // u2 constant pool index and two zero bytes
+	operandNewArray                      // u1 atype
+	operandMultiANewArray                // u2 constant pool index and u1 dimensions
+	operandWide                          // prefix, modifies the following instruction
+)
+
+type opcodeInfo struct {
+	mnemonic string
+	format   operandFormat
+}
+
+var opcodes = [256]opcodeInfo{
+	NOP:             {"nop", operandNone},
+	ACONST_NULL:     {"aconst_null", operandNone},
+	ICONST_M1:       {"iconst_m1", operandNone},
+	ICONST_0:        {"iconst_0", operandNone},
+	ICONST_1:        {"iconst_1", operandNone},
+	ICONST_2:        {"iconst_2", operandNone},
+	ICONST_3:        {"iconst_3", operandNone},
+	ICONST_4:        {"iconst_4", operandNone},
+	ICONST_5:        {"iconst_5", operandNone},
+	LCONST_0:        {"lconst_0", operandNone},
+	LCONST_1:        {"lconst_1", operandNone},
+	FCONST_0:        {"fconst_0", operandNone},
+	FCONST_1:        {"fconst_1", operandNone},
+	FCONST_2:        {"fconst_2", operandNone},
+	DCONST_0:        {"dconst_0", operandNone},
+	DCONST_1:        {"dconst_1", operandNone},
+	BIPUSH:          {"bipush", operandByte},
+	SIPUSH:          {"sipush", operandShort},
+	LDC:             {"ldc", operandConstPoolByte},
+	LDC_W:           {"ldc_w", operandConstPool},
+	LDC2_W:          {"ldc2_w", operandConstPool},
+	ILOAD:           {"iload", operandLocal},
+	LLOAD:           {"lload", operandLocal},
+	FLOAD:           {"fload", operandLocal},
+	DLOAD:           {"dload", operandLocal},
+	ALOAD:           {"aload", operandLocal},
+	ILOAD_0:         {"iload_0", operandNone},
+	ILOAD_1:         {"iload_1", operandNone},
+	ILOAD_2:         {"iload_2", operandNone},
+	ILOAD_3:         {"iload_3", operandNone},
+	LLOAD_0:         {"lload_0", operandNone},
+	LLOAD_1:         {"lload_1", operandNone},
+	LLOAD_2:         {"lload_2", operandNone},
+	LLOAD_3:         {"lload_3", operandNone},
+	FLOAD_0:         {"fload_0", operandNone},
+	FLOAD_1:         {"fload_1", operandNone},
+	FLOAD_2:         {"fload_2", operandNone},
+	FLOAD_3:         {"fload_3", operandNone},
+	DLOAD_0:         {"dload_0", operandNone},
+	DLOAD_1:         {"dload_1", operandNone},
+	DLOAD_2:         {"dload_2", operandNone},
+	DLOAD_3:         {"dload_3", operandNone},
+	ALOAD_0:         {"aload_0", operandNone},
+	ALOAD_1:         {"aload_1", operandNone},
+	ALOAD_2:         {"aload_2", operandNone},
+	ALOAD_3:         {"aload_3", operandNone},
+	IALOAD:          {"iaload", operandNone},
+	LALOAD:          {"laload", operandNone},
+	FALOAD:          {"faload", operandNone},
+	DALOAD:          {"daload", operandNone},
+	AALOAD:          {"aaload", operandNone},
+	BALOAD:          {"baload", operandNone},
+	CALOAD:          {"caload", operandNone},
+	SALOAD:          {"saload", operandNone},
+	ISTORE:          {"istore", operandLocal},
+	LSTORE:          {"lstore", operandLocal},
+	FSTORE:          {"fstore", operandLocal},
+	DSTORE:          {"dstore", operandLocal},
+	ASTORE:          {"astore", operandLocal},
+	ISTORE_0:        {"istore_0", operandNone},
+	ISTORE_1:        {"istore_1", operandNone},
+	ISTORE_2:        {"istore_2", operandNone},
+	ISTORE_3:        {"istore_3", operandNone},
+	LSTORE_0:        {"lstore_0", operandNone},
+	LSTORE_1:        {"lstore_1", operandNone},
+	LSTORE_2:        {"lstore_2", operandNone},
+	LSTORE_3:        {"lstore_3", operandNone},
+	FSTORE_0:        {"fstore_0", operandNone},
+	FSTORE_1:        {"fstore_1", operandNone},
+	FSTORE_2:        {"fstore_2", operandNone},
+	FSTORE_3:        {"fstore_3", operandNone},
+	DSTORE_0:        {"dstore_0", operandNone},
+	DSTORE_1:        {"dstore_1", operandNone},
+	DSTORE_2:        {"dstore_2", operandNone},
+	DSTORE_3:        {"dstore_3", operandNone},
+	ASTORE_0:        {"astore_0", operandNone},
+	ASTORE_1:        {"astore_1", operandNone},
+	ASTORE_2:        {"astore_2", operandNone},
+	ASTORE_3:        {"astore_3", operandNone},
+	IASTORE:         {"iastore", operandNone},
+	LASTORE:         {"lastore", operandNone},
+	FASTORE:         {"fastore", operandNone},
+	DASTORE:         {"dastore", operandNone},
+	AASTORE:         {"aastore", operandNone},
+	BASTORE:         {"bastore", operandNone},
+	CASTORE:         {"castore", operandNone},
+	SASTORE:         {"sastore", operandNone},
+	POP:             {"pop", operandNone},
+	POP2:            {"pop2", operandNone},
+	DUP:             {"dup", operandNone},
+	DUP_X1:          {"dup_x1", operandNone},
+	DUP_X2:          {"dup_x2", operandNone},
+	DUP2:            {"dup2", operandNone},
+	DUP2_X1:         {"dup2_x1", operandNone},
+	DUP2_X2:         {"dup2_x2", operandNone},
+	SWAP:            {"swap", operandNone},
+	IADD:            {"iadd", operandNone},
+	LADD:            {"ladd", operandNone},
+	FADD:            {"fadd", operandNone},
+	DADD:            {"dadd", operandNone},
+	ISUB:            {"isub", operandNone},
+	LSUB:            {"lsub", operandNone},
+	FSUB:            {"fsub", operandNone},
+	DSUB:            {"dsub", operandNone},
+	IMUL:            {"imul", operandNone},
+	LMUL:            {"lmul", operandNone},
+	FMUL:            {"fmul", operandNone},
+	DMUL:            {"dmul", operandNone},
+	IDIV:            {"idiv", operandNone},
+	LDIV:            {"ldiv", operandNone},
+	FDIV:            {"fdiv", operandNone},
+	DDIV:            {"ddiv", operandNone},
+	IREM:            {"irem", operandNone},
+	LREM:            {"lrem", operandNone},
+	FREM:            {"frem", operandNone},
+	DREM:            {"drem", operandNone},
+	INEG:            {"ineg", operandNone},
+	LNEG:            {"lneg", operandNone},
+	FNEG:            {"fneg", operandNone},
+	DNEG:            {"dneg", operandNone},
+	ISHL:            {"ishl", operandNone},
+	LSHL:            {"lshl", operandNone},
+	ISHR:            {"ishr", operandNone},
+	LSHR:            {"lshr", operandNone},
+	IUSHR:           {"iushr", operandNone},
+	LUSHR:           {"lushr", operandNone},
+	IAND:            {"iand", operandNone},
+	LAND:            {"land", operandNone},
+	IOR:             {"ior", operandNone},
+	LOR:             {"lor", operandNone},
+	IXOR:            {"ixor", operandNone},
+	LXOR:            {"lxor", operandNone},
+	IINC:            {"iinc", operandIinc},
+	I2L:             {"i2l", operandNone},
+	I2F:             {"i2f", operandNone},
+	I2D:             {"i2d", operandNone},
+	L2I:             {"l2i", operandNone},
+	L2F:             {"l2f", operandNone},
+	L2D:             {"l2d", operandNone},
+	F2I:             {"f2i", operandNone},
+	F2L:             {"f2l", operandNone},
+	F2D:             {"f2d", operandNone},
+	D2I:             {"d2i", operandNone},
+	D2L:             {"d2l", operandNone},
+	D2F:             {"d2f", operandNone},
+	I2B:             {"i2b", operandNone},
+	I2C:             {"i2c", operandNone},
+	I2S:             {"i2s", operandNone},
+	LCMP:            {"lcmp", operandNone},
+	FCMPL:           {"fcmpl", operandNone},
+	FCMPG:           {"fcmpg", operandNone},
+	DCMPL:           {"dcmpl", operandNone},
+	DCMPG:           {"dcmpg", operandNone},
+	IFEQ:            {"ifeq", operandBranch},
+	IFNE:            {"ifne", operandBranch},
+	IFLT:            {"iflt", operandBranch},
+	IFGE:            {"ifge", operandBranch},
+	IFGT:            {"ifgt", operandBranch},
+	IFLE:            {"ifle", operandBranch},
+	IF_ICMPEQ:       {"if_icmpeq", operandBranch},
+	IF_ICMPNE:       {"if_icmpne", operandBranch},
+	IF_ICMPLT:       {"if_icmplt", operandBranch},
+	IF_ICMPGE:       {"if_icmpge", operandBranch},
+	IF_ICMPGT:       {"if_icmpgt", operandBranch},
+	IF_ICMPLE:       {"if_icmple", operandBranch},
+	IF_ACMPEQ:       {"if_acmpeq", operandBranch},
+	IF_ACMPNE:       {"if_acmpne", operandBranch},
+	GOTO:            {"goto", operandBranch},
+	JSR:             {"jsr", operandBranch},
+	RET:             {"ret", operandLocal},
+	TABLESWITCH:     {"tableswitch", operandTableSwitch},
+	LOOKUPSWITCH:    {"lookupswitch", operandLookupSwitch},
+	IRETURN:         {"ireturn", operandNone},
+	LRETURN:         {"lreturn", operandNone},
+	FRETURN:         {"freturn", operandNone},
+	DRETURN:         {"dreturn", operandNone},
+	ARETURN:         {"areturn", operandNone},
+	RETURN:          {"return", operandNone},
+	GETSTATIC:       {"getstatic", operandConstPool},
+	PUTSTATIC:       {"putstatic", operandConstPool},
+	GETFIELD:        {"getfield", operandConstPool},
+	PUTFIELD:        {"putfield", operandConstPool},
+	INVOKEVIRTUAL:   {"invokevirtual", operandConstPool},
+	INVOKESPECIAL:   {"invokespecial", operandConstPool},
+	INVOKESTATIC:    {"invokestatic", operandConstPool},
+	INVOKEINTERFACE: {"invokeinterface", operandInvokeInterface},
+	INVOKEDYNAMIC:   {"invokedynamic", operandInvokeDynamic},
+	NEW:             {"new", operandConstPool},
+	NEWARRAY:        {"newarray", operandNewArray},
+	ANEWARRAY:       {"anewarray", operandConstPool},
+	ARRAYLENGTH:     {"arraylength", operandNone},
+	ATHROW:          {"athrow", operandNone},
+	CHECKCAST:       {"checkcast", operandConstPool},
+	INSTANCEOF:      {"instanceof", operandConstPool},
+	MONITORENTER:    {"monitorenter", operandNone},
+	MONITOREXIT:     {"monitorexit", operandNone},
+	WIDE:            {"wide", operandWide},
+	MULTIANEWARRAY:  {"multianewarray", operandMultiANewArray},
+	IFNULL:          {"ifnull", operandBranch},
+	IFNONNULL:       {"ifnonnull", operandBranch},
+	GOTO_W:          {"goto_w", operandBranchWide},
+	JSR_W:           {"jsr_w", operandBranchWide},
+	BREAKPOINT:      {"breakpoint", operandNone},
+	IMPDEP1:         {"impdep1", operandNone},
+	IMPDEP2:         {"impdep2", operandNone},
+}
+
+// String returns the mnemonic of the opcode.
+func (op Opcode) String() string {
+	if mnemonic := opcodes[op].mnemonic; mnemonic != "" {
+		return mnemonic
+	}
+
+	return fmt.Sprintf("unknown_0x%02x", uint8(op))
+}
+
+// IsValid reports whether op is an opcode defined
+// by the JVM specification.
+func (op Opcode) IsValid() bool {
+	return opcodes[op].mnemonic != ""
+}
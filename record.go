@@ -0,0 +1,89 @@
+package class
+
+// ResolvedRecordComponent is a record component, with
+// all of its information resolved through the constant pool.
+type ResolvedRecordComponent struct {
+	Name       string
+	Descriptor string
+	// Generic signature from the Signature attribute, or "".
+	Signature string
+	// Visible and invisible annotations of the component.
+	Annotations []*Annotation
+}
+
+// IsRecord reports whether the class is a record class,
+// i.e. it has a Record attribute.
+func (c *ClassFile) IsRecord() bool {
+	return c.record() != nil
+}
+
+// IsSealed reports whether the class or interface is sealed,
+// i.e. it has a PermittedSubclasses attribute.
+func (c *ClassFile) IsSealed() bool {
+	for _, attr := range c.Attributes {
+		if attr.GetTag() == PermittedSubclassesTag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PermittedSubclassNames returns the internal names of the classes
+// listed in the PermittedSubclasses attribute, if any.
+func (c *ClassFile) PermittedSubclassNames() ([]string, error) {
+	for _, attr := range c.Attributes {
+		if attr.GetTag() == PermittedSubclassesTag {
+			return attr.PermittedSubclasses().ClassNames(c.ConstantPool)
+		}
+	}
+
+	return nil, nil
+}
+
+// RecordComponents returns the resolved components of a record
+// class in declaration order, or nil if the class isn't a record.
+func (c *ClassFile) RecordComponents() ([]*ResolvedRecordComponent, error) {
+	record := c.record()
+	if record == nil {
+		return nil, nil
+	}
+
+	components := make([]*ResolvedRecordComponent, 0, len(record.Components))
+
+	for _, component := range record.Components {
+		var err error
+		resolved := &ResolvedRecordComponent{Annotations: component.Annotations()}
+
+		resolved.Name, err = c.LookupUTF8(component.NameIndex)
+		if err != nil {
+			return nil, err
+		}
+
+		resolved.Descriptor, err = c.LookupUTF8(component.DescriptorIndex)
+		if err != nil {
+			return nil, err
+		}
+
+		if signature := Find[*Signature](component.Attributes); signature != nil {
+			resolved.Signature, err = c.LookupUTF8(signature.SignatureIndex)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		components = append(components, resolved)
+	}
+
+	return components, nil
+}
+
+func (c *ClassFile) record() *Record {
+	for _, attr := range c.Attributes {
+		if attr.GetTag() == RecordTag {
+			return attr.Record()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package class
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func recordConstPool() ConstantPool {
+	class := func(index ConstPoolIndex) Constant { return &ClassRef{baseConstant{CONSTANT_Class}, index} }
+
+	return testConstPool(
+		"Record",                               // #1
+		"x",                                    // #2
+		"I",                                    // #3
+		"list",                                 // #4
+		"Ljava/util/List;",                     // #5
+		"Signature",                            // #6
+		"Ljava/util/List<Ljava/lang/String;>;", // #7
+		"RuntimeVisibleAnnotations",            // #8
+		"LFoo;",                                // #9
+		"RuntimeInvisibleAnnotations",          // #10
+		"LBar;",                                // #11
+		class(13),                              // #12
+		"Circle",                               // #13
+		class(15),                              // #14
+		"Square",                               // #15
+	)
+}
+
+// recordBody holds the components of
+// record R(int x, @Foo @Bar List<String> list).
+var recordBody = []byte{
+	0x00, 0x02,
+	0x00, 0x02, 0x00, 0x03, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0x05, 0x00, 0x03,
+	0x00, 0x06, 0x00, 0x00, 0x00, 0x02, 0x00, 0x07,
+	0x00, 0x08, 0x00, 0x00, 0x00, 0x06, 0x00, 0x01, 0x00, 0x09, 0x00, 0x00,
+	0x00, 0x0a, 0x00, 0x00, 0x00, 0x06, 0x00, 0x01, 0x00, 0x0b, 0x00, 0x00,
+}
+
+var permittedSubclassesBody = []byte{0x00, 0x02, 0x00, 0x0c, 0x00, 0x0e}
+
+func TestRecordReadDump(t *testing.T) {
+	record := readAttributeBody(t, recordConstPool(), recordBody).Record()
+
+	if len(record.Components) != 2 {
+		t.Fatalf("got %d components", len(record.Components))
+	}
+
+	x, list := record.Components[0], record.Components[1]
+	if x.NameIndex != 2 || x.DescriptorIndex != 3 || len(x.Attributes) != 0 {
+		t.Errorf("got %#v", x)
+	}
+
+	if list.NameIndex != 4 || list.DescriptorIndex != 5 || len(list.Attributes) != 3 {
+		t.Fatalf("got %#v", list)
+	}
+
+	if signature := Find[*Signature](list.Attributes); signature == nil || signature.SignatureIndex != 7 {
+		t.Errorf("got signature %#v", signature)
+	}
+
+	// A truncated attribute of a component
+	data := append([]byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x14}, recordBody[:20]...)
+	if _, err := readAttribute(bytes.NewReader(data), recordConstPool()); err == nil {
+		t.Error("expected an error")
+	}
+
+	permitted := readAttributeBody(t, testConstPool("PermittedSubclasses"), permittedSubclassesBody)
+	if classes := permitted.PermittedSubclasses().Classes; !reflect.DeepEqual(classes, []ConstPoolIndex{12, 14}) {
+		t.Errorf("got permitted subclasses %v", classes)
+	}
+}
+
+func TestRecordComponents(t *testing.T) {
+	constPool := recordConstPool()
+	c := &ClassFile{
+		ConstantPool: constPool,
+		Attributes: Attributes{
+			readAttributeBody(t, constPool, recordBody),
+			readAttributeBody(t, testConstPool("PermittedSubclasses"), permittedSubclassesBody),
+		},
+	}
+
+	if !c.IsRecord() || !c.IsSealed() {
+		t.Errorf("record: %t, sealed: %t", c.IsRecord(), c.IsSealed())
+	}
+
+	components, err := c.RecordComponents()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(components) != 2 {
+		t.Fatalf("got %d components", len(components))
+	}
+
+	x := components[0]
+	if x.Name != "x" || x.Descriptor != "I" || x.Signature != "" || len(x.Annotations) != 0 {
+		t.Errorf("got %#v", x)
+	}
+
+	list := components[1]
+	if list.Name != "list" || list.Descriptor != "Ljava/util/List;" ||
+		list.Signature != "Ljava/util/List<Ljava/lang/String;>;" || len(list.Annotations) != 2 {
+		t.Errorf("got %#v", list)
+	}
+
+	if annotation, err := list.Annotations[1].TypeName(constPool); err != nil || annotation != "LBar;" {
+		t.Errorf("got %q, %v", annotation, err)
+	}
+
+	if names, err := c.PermittedSubclassNames(); err != nil || !reflect.DeepEqual(names, []string{"Circle", "Square"}) {
+		t.Errorf("got %v, %v", names, err)
+	}
+
+	// Constants of the wrong type
+	c.Attributes[0].Record().Components[1].DescriptorIndex = 12
+	if _, err := c.RecordComponents(); err == nil {
+		t.Error("Class used as descriptor not reported")
+	}
+
+	c.Attributes[1].PermittedSubclasses().Classes[0] = 13
+	if _, err := c.PermittedSubclassNames(); err == nil {
+		t.Error("Utf8 used as class not reported")
+	}
+
+	_, hello := readHelloWorld(t)
+	components, err = hello.RecordComponents()
+	names, err2 := hello.PermittedSubclassNames()
+	if hello.IsRecord() || hello.IsSealed() || components != nil || names != nil || err != nil || err2 != nil {
+		t.Errorf("got %v, %v for a plain class", components, names)
+	}
+}
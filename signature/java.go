@@ -0,0 +1,188 @@
+package signature
+
+import (
+	"strings"
+
+	"github.com/jcla1/jclass/descriptor"
+)
+
+// A Renderer renders signatures in Java source syntax, e.g.
+// "<T extends java.lang.Comparable<? super T>> void sort(java.util.List<T>)".
+type Renderer struct {
+	// Render classes by their simple name (e.g. List
+	// instead of java.util.List).
+	SimpleNames bool
+}
+
+// Java renders t in Java source syntax,
+// with fully qualified class names.
+func Java(t Type) string {
+	return Renderer{}.Type(t)
+}
+
+// Java renders the declaration of a method named name,
+// with fully qualified class names.
+func (s *MethodSignature) Java(name string) string {
+	return Renderer{}.Method(s, name)
+}
+
+// Java renders the declaration of a class named name,
+// with fully qualified class names.
+func (s *ClassSignature) Java(name string) string {
+	return Renderer{}.Class(s, name)
+}
+
+// Type renders a single type.
+func (r Renderer) Type(t Type) string {
+	switch t := t.(type) {
+	case descriptor.Primitive:
+		return t.Java()
+
+	case *ClassType:
+		var s string
+
+		if t.Outer != nil {
+			s = r.Type(t.Outer) + "." + t.Name
+		} else if r.SimpleNames {
+			s = t.Name[strings.LastIndexByte(t.Name, '/')+1:]
+		} else {
+			s = strings.Replace(t.Name, "/", ".", -1)
+		}
+
+		if len(t.TypeArgs) > 0 {
+			args := make([]string, 0, len(t.TypeArgs))
+			for _, arg := range t.TypeArgs {
+				args = append(args, r.typeArg(arg))
+			}
+
+			s += "<" + strings.Join(args, ", ") + ">"
+		}
+
+		return s
+
+	case *TypeVariable:
+		return t.Name
+
+	case *ArrayType:
+		return r.Type(t.Element) + "[]"
+	}
+
+	return ""
+}
+
+func (r Renderer) typeArg(arg *TypeArgument) string {
+	switch arg.Wildcard {
+	case WildcardAny:
+		return "?"
+	case WildcardExtends:
+		return "? extends " + r.Type(arg.Type)
+	case WildcardSuper:
+		return "? super " + r.Type(arg.Type)
+	}
+
+	return r.Type(arg.Type)
+}
+
+// TypeParams renders type parameters, e.g. "<K, V extends
+// java.lang.Comparable<V>>" or "" if there are none. Bounds
+// of just java.lang.Object are omitted.
+func (r Renderer) TypeParams(params []*TypeParameter) string {
+	if len(params) == 0 {
+		return ""
+	}
+
+	rendered := make([]string, 0, len(params))
+
+	for _, param := range params {
+		var bounds []string
+
+		if param.ClassBound != nil && !isObject(param.ClassBound) {
+			bounds = append(bounds, r.Type(param.ClassBound))
+		}
+
+		for _, bound := range param.InterfaceBounds {
+			bounds = append(bounds, r.Type(bound))
+		}
+
+		if len(bounds) > 0 {
+			rendered = append(rendered, param.Name+" extends "+strings.Join(bounds, " & "))
+		} else {
+			rendered = append(rendered, param.Name)
+		}
+	}
+
+	return "<" + strings.Join(rendered, ", ") + ">"
+}
+
+func isObject(t Type) bool {
+	class, ok := t.(*ClassType)
+	return ok && class.Outer == nil && class.Name == "java/lang/Object" && len(class.TypeArgs) == 0
+}
+
+// Method renders the declaration of a method named name,
+// without modifiers and parameter names.
+func (r Renderer) Method(s *MethodSignature, name string) string {
+	var decl string
+
+	if len(s.TypeParams) > 0 {
+		decl = r.TypeParams(s.TypeParams) + " "
+	}
+
+	params := make([]string, 0, len(s.Params))
+	for _, param := range s.Params {
+		params = append(params, r.Type(param))
+	}
+
+	decl += r.Type(s.Result) + " " + name + "(" + strings.Join(params, ", ") + ")"
+
+	if len(s.Throws) > 0 {
+		throws := make([]string, 0, len(s.Throws))
+		for _, t := range s.Throws {
+			throws = append(throws, r.Type(t))
+		}
+
+		decl += " throws " + strings.Join(throws, ", ")
+	}
+
+	return decl
+}
+
+// Class renders the declaration of a class named name,
+// without modifiers and the class keyword. Extending
+// java.lang.Object is omitted. Use Interface for interfaces.
+func (r Renderer) Class(s *ClassSignature, name string) string {
+	decl := name + r.TypeParams(s.TypeParams)
+
+	if !isObject(s.Super) {
+		decl += " extends " + r.Type(s.Super)
+	}
+
+	if len(s.Interfaces) > 0 {
+		decl += " implements " + r.types(s.Interfaces)
+	}
+
+	return decl
+}
+
+// Interface renders the declaration of an interface named
+// name, without modifiers and the interface keyword. The
+// superinterfaces are rendered after "extends", the super
+// class (always java.lang.Object) is omitted.
+func (r Renderer) Interface(s *ClassSignature, name string) string {
+	decl := name + r.TypeParams(s.TypeParams)
+
+	if len(s.Interfaces) > 0 {
+		decl += " extends " + r.types(s.Interfaces)
+	}
+
+	return decl
+}
+
+func (r Renderer) types(types []*ClassType) string {
+	rendered := make([]string, 0, len(types))
+	for _, t := range types {
+		rendered = append(rendered, r.Type(t))
+	}
+
+	return strings.Join(rendered, ", ")
+}
@@ -0,0 +1,442 @@
+// Package signature parses generic signatures, as found in the
+// Signature attribute of Java class files, into a typed syntax
+// tree. Trees can be printed back to the JVMS grammar (String)
+// or rendered in Java source syntax (Renderer).
+// http://docs.oracle.com/javase/specs/jvms/se8/html/jvms-4.html#jvms-4.7.9.1
+package signature
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jcla1/jclass/descriptor"
+)
+
+// A Type is a type in a signature. It's one of descriptor.Primitive
+// (a base type, or void as a method's result), *ClassType,
+// *TypeVariable or *ArrayType. The latter three are reference types.
+type Type interface {
+	// String returns the type in the JVMS grammar.
+	String() string
+}
+
+// A ClassType is a (possibly parameterized) class or interface type.
+type ClassType struct {
+	// The enclosing class type of an inner class type, with its
+	// own type arguments (e.g. Map<K, V> of Map<K, V>.Entry),
+	// otherwise nil.
+	Outer *ClassType
+
+	// The internal name (e.g. java/util/List) or, if Outer is
+	// set, the simple name of the inner class (e.g. Entry).
+	Name string
+
+	TypeArgs []*TypeArgument
+}
+
+// A TypeArgument is a single type argument of a ClassType.
+type TypeArgument struct {
+	// One of the Wildcard* constants.
+	Wildcard Wildcard
+
+	// The (bounding) type, nil for an unbounded wildcard.
+	Type Type
+}
+
+// A Wildcard indicates whether (and how) a TypeArgument
+// is a wildcard.
+type Wildcard byte
+
+const (
+	NoWildcard      Wildcard = 0   // T
+	WildcardExtends Wildcard = '+' // ? extends T
+	WildcardSuper   Wildcard = '-' // ? super T
+	WildcardAny     Wildcard = '*' // ?
+)
+
+// A TypeVariable refers to a type parameter.
+type TypeVariable struct {
+	Name string
+}
+
+// An ArrayType is a single dimension of an array type,
+// Element may be an ArrayType itself.
+type ArrayType struct {
+	Element Type
+}
+
+// A TypeParameter is a formal type parameter
+// of a generic class or method.
+type TypeParameter struct {
+	Name string
+
+	// The class bound, nil if there is none (the bound is
+	// an interface, given by InterfaceBounds, instead).
+	ClassBound Type
+
+	InterfaceBounds []Type
+}
+
+// A ClassSignature is the signature of a generic class or interface
+// or of a class, that extends or implements a parameterized type.
+type ClassSignature struct {
+	TypeParams []*TypeParameter
+	Super      *ClassType
+	Interfaces []*ClassType
+}
+
+// A MethodSignature is the signature of a generic method or of
+// a method with parameterized parameter, return or throws types.
+type MethodSignature struct {
+	TypeParams []*TypeParameter
+	Params     []Type
+
+	// The result type, descriptor.Void if there is none.
+	Result Type
+
+	// Either *ClassType or *TypeVariable.
+	Throws []Type
+}
+
+func (t *ClassType) String() string {
+	return "L" + t.body() + ";"
+}
+
+// body returns the class type signature without the
+// leading L and the trailing semicolon.
+func (t *ClassType) body() string {
+	var s strings.Builder
+
+	if t.Outer != nil {
+		s.WriteString(t.Outer.body())
+		s.WriteByte('.')
+	}
+
+	s.WriteString(t.Name)
+
+	if len(t.TypeArgs) > 0 {
+		s.WriteByte('<')
+		for _, arg := range t.TypeArgs {
+			s.WriteString(arg.String())
+		}
+		s.WriteByte('>')
+	}
+
+	return s.String()
+}
+
+func (a *TypeArgument) String() string {
+	switch a.Wildcard {
+	case WildcardAny:
+		return "*"
+	case WildcardExtends, WildcardSuper:
+		return string(a.Wildcard) + a.Type.String()
+	}
+
+	return a.Type.String()
+}
+
+func (t *TypeVariable) String() string { return "T" + t.Name + ";" }
+func (t *ArrayType) String() string    { return "[" + t.Element.String() }
+
+func (p *TypeParameter) String() string {
+	s := p.Name + ":"
+	if p.ClassBound != nil {
+		s += p.ClassBound.String()
+	}
+
+	for _, bound := range p.InterfaceBounds {
+		s += ":" + bound.String()
+	}
+
+	return s
+}
+
+func typeParams(params []*TypeParameter) string {
+	if len(params) == 0 {
+		return ""
+	}
+
+	s := "<"
+	for _, param := range params {
+		s += param.String()
+	}
+
+	return s + ">"
+}
+
+func (s *ClassSignature) String() string {
+	str := typeParams(s.TypeParams) + s.Super.String()
+	for _, iface := range s.Interfaces {
+		str += iface.String()
+	}
+
+	return str
+}
+
+func (s *MethodSignature) String() string {
+	str := typeParams(s.TypeParams) + "("
+	for _, param := range s.Params {
+		str += param.String()
+	}
+
+	str += ")" + s.Result.String()
+	for _, t := range s.Throws {
+		str += "^" + t.String()
+	}
+
+	return str
+}
+
+// ParseClass parses a class signature,
+// e.g. "<T:Ljava/lang/Object;>Ljava/lang/Object;Ljava/lang/Comparable<TT;>;".
+func ParseClass(signature string) (*ClassSignature, error) {
+	p := &parser{s: signature}
+	s := &ClassSignature{}
+
+	s.TypeParams = p.typeParams()
+	s.Super = p.classType()
+
+	for p.err == nil && p.pos < len(p.s) {
+		s.Interfaces = append(s.Interfaces, p.classType())
+	}
+
+	if err := p.finish(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// ParseMethod parses a method signature,
+// e.g. "<T::Ljava/lang/Comparable<-TT;>;>(Ljava/util/List<TT;>;)V".
+func ParseMethod(signature string) (*MethodSignature, error) {
+	p := &parser{s: signature}
+	s := &MethodSignature{Params: []Type{}}
+
+	s.TypeParams = p.typeParams()
+
+	p.expect('(')
+	for p.err == nil && p.peek() != ')' {
+		s.Params = append(s.Params, p.javaType())
+	}
+	p.expect(')')
+
+	if p.peek() == 'V' {
+		p.pos++
+		s.Result = descriptor.Void
+	} else {
+		s.Result = p.javaType()
+	}
+
+	for p.err == nil && p.peek() == '^' {
+		p.pos++
+
+		if p.peek() == 'T' {
+			s.Throws = append(s.Throws, p.typeVariable())
+		} else {
+			s.Throws = append(s.Throws, p.classType())
+		}
+	}
+
+	if err := p.finish(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// ParseField parses a field signature, which
+// is a reference type, e.g. "Ljava/util/List<TT;>;".
+func ParseField(signature string) (Type, error) {
+	p := &parser{s: signature}
+	t := p.referenceType()
+
+	if err := p.finish(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+type parser struct {
+	s   string
+	pos int
+	err error
+}
+
+func (p *parser) fail(format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = fmt.Errorf("jclass: invalid signature %q at %d: %s", p.s, p.pos, fmt.Sprintf(format, args...))
+	}
+
+	// Stop parsing
+	p.pos = len(p.s)
+}
+
+// finish returns the parser's error, including
+// an error about trailing characters.
+func (p *parser) finish() error {
+	if p.err == nil && p.pos < len(p.s) {
+		p.fail("unexpected %q", p.s[p.pos])
+	}
+
+	return p.err
+}
+
+// peek returns the current character, or 0 at the end.
+func (p *parser) peek() byte {
+	if p.pos >= len(p.s) {
+		return 0
+	}
+
+	return p.s[p.pos]
+}
+
+func (p *parser) expect(c byte) {
+	if p.peek() != c {
+		p.fail("expected %q", c)
+		return
+	}
+
+	p.pos++
+}
+
+// identifier reads characters up to one of stop (or the end),
+// identifiers mustn't contain any of ".;[/<>:".
+func (p *parser) identifier(stop string) string {
+	start := p.pos
+	for p.pos < len(p.s) && !strings.ContainsRune(stop, rune(p.s[p.pos])) {
+		p.pos++
+	}
+
+	if p.pos == start {
+		p.fail("expected identifier")
+	}
+
+	return p.s[start:p.pos]
+}
+
+func (p *parser) typeParams() []*TypeParameter {
+	if p.peek() != '<' {
+		return nil
+	}
+	p.pos++
+
+	var params []*TypeParameter
+
+	for p.err == nil && p.peek() != '>' {
+		param := &TypeParameter{Name: p.identifier(".;[/<>:")}
+
+		p.expect(':')
+		if c := p.peek(); c == 'L' || c == 'T' || c == '[' {
+			param.ClassBound = p.referenceType()
+		}
+
+		for p.err == nil && p.peek() == ':' {
+			p.pos++
+			param.InterfaceBounds = append(param.InterfaceBounds, p.referenceType())
+		}
+
+		params = append(params, param)
+	}
+
+	if len(params) == 0 {
+		p.fail("empty type parameters")
+	}
+
+	p.expect('>')
+
+	return params
+}
+
+func (p *parser) javaType() Type {
+	switch c := descriptor.Primitive(p.peek()); c {
+	case descriptor.Byte, descriptor.Char, descriptor.Double, descriptor.Float,
+		descriptor.Int, descriptor.Long, descriptor.Short, descriptor.Boolean:
+		p.pos++
+		return c
+	}
+
+	return p.referenceType()
+}
+
+func (p *parser) referenceType() Type {
+	switch p.peek() {
+	case 'L':
+		return p.classType()
+	case 'T':
+		return p.typeVariable()
+	case '[':
+		p.pos++
+		return &ArrayType{Element: p.javaType()}
+	}
+
+	p.fail("expected reference type")
+	return nil
+}
+
+func (p *parser) typeVariable() *TypeVariable {
+	p.expect('T')
+	t := &TypeVariable{Name: p.identifier(".;[/<>:")}
+	p.expect(';')
+
+	return t
+}
+
+func (p *parser) classType() *ClassType {
+	p.expect('L')
+
+	// The package specifier is part of the outermost name.
+	t := &ClassType{Name: p.identifier(".;[<>:")}
+	if strings.HasPrefix(t.Name, "/") || strings.HasSuffix(t.Name, "/") || strings.Contains(t.Name, "//") {
+		p.fail("invalid class name %q", t.Name)
+	}
+	t.TypeArgs = p.typeArgs()
+
+	for p.err == nil && p.peek() == '.' {
+		p.pos++
+
+		t = &ClassType{Outer: t, Name: p.identifier(".;[/<>:")}
+		t.TypeArgs = p.typeArgs()
+	}
+
+	p.expect(';')
+
+	return t
+}
+
+func (p *parser) typeArgs() []*TypeArgument {
+	if p.peek() != '<' {
+		return nil
+	}
+	p.pos++
+
+	var args []*TypeArgument
+
+	for p.err == nil && p.peek() != '>' {
+		arg := &TypeArgument{}
+
+		switch c := Wildcard(p.peek()); c {
+		case WildcardAny:
+			p.pos++
+			arg.Wildcard = c
+		case WildcardExtends, WildcardSuper:
+			p.pos++
+			arg.Wildcard = c
+			arg.Type = p.referenceType()
+		default:
+			arg.Type = p.referenceType()
+		}
+
+		args = append(args, arg)
+	}
+
+	if len(args) == 0 {
+		p.fail("empty type arguments")
+	}
+
+	p.expect('>')
+
+	return args
+}
@@ -0,0 +1,136 @@
+package signature
+
+import "testing"
+
+func TestParseMethod(t *testing.T) {
+	tests := []struct {
+		signature string
+		java      string
+	}{
+		{
+			"<T::Ljava/lang/Comparable<-TT;>;>(Ljava/util/List<TT;>;)V",
+			"<T extends Comparable<? super T>> void sort(List<T>)",
+		},
+		{
+			"<X:Ljava/lang/Exception;>(Ljava/util/Map<TK;TV;>.Entry<TK;TV;>;)[TK;^TX;^Ljava/io/IOException;",
+			"<X extends Exception> K[] sort(Map<K, V>.Entry<K, V>) throws X, IOException",
+		},
+		{
+			"(Ljava/util/List<*>;Ljava/util/List<+[I>;)I",
+			"int sort(List<?>, List<? extends int[]>)",
+		},
+	}
+
+	for _, test := range tests {
+		s, err := ParseMethod(test.signature)
+		if err != nil {
+			t.Errorf("%q: %v", test.signature, err)
+			continue
+		}
+
+		if str := s.String(); str != test.signature {
+			t.Errorf("%q: got %q", test.signature, str)
+		}
+
+		if java := (Renderer{SimpleNames: true}).Method(s, "sort"); java != test.java {
+			t.Errorf("%q: got %q, expected %q", test.signature, java, test.java)
+		}
+	}
+}
+
+func TestParseField(t *testing.T) {
+	signature := "Ljava/util/Map<TK;TV;>.Entry<TK;TV;>;"
+
+	typ, err := ParseField(signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	class, ok := typ.(*ClassType)
+	if !ok || class.Name != "Entry" || class.Outer == nil || class.Outer.Name != "java/util/Map" {
+		t.Fatalf("got %#v", typ)
+	}
+
+	if str := typ.String(); str != signature {
+		t.Errorf("got %q", str)
+	}
+
+	if java := Java(typ); java != "java.util.Map<K, V>.Entry<K, V>" {
+		t.Errorf("got %q", java)
+	}
+}
+
+func TestParseClass(t *testing.T) {
+	tests := []struct {
+		signature string
+		class     string
+		iface     string
+	}{
+		{
+			"<T:Ljava/lang/Object;>Ljava/lang/Object;Ljava/lang/Comparable<TT;>;",
+			"Foo<T> implements java.lang.Comparable<T>",
+			"Foo<T> extends java.lang.Comparable<T>",
+		},
+		{
+			"<K:Ljava/lang/Object;V:Ljava/lang/Object;>Ljava/util/AbstractMap<TK;TV;>;Ljava/util/Map<TK;TV;>;Ljava/io/Serializable;",
+			"Foo<K, V> extends java.util.AbstractMap<K, V> implements java.util.Map<K, V>, java.io.Serializable",
+			"Foo<K, V> extends java.util.Map<K, V>, java.io.Serializable",
+		},
+	}
+
+	for _, test := range tests {
+		s, err := ParseClass(test.signature)
+		if err != nil {
+			t.Errorf("%q: %v", test.signature, err)
+			continue
+		}
+
+		if str := s.String(); str != test.signature {
+			t.Errorf("%q: got %q", test.signature, str)
+		}
+
+		if class := s.Java("Foo"); class != test.class {
+			t.Errorf("%q: got %q, expected %q", test.signature, class, test.class)
+		}
+
+		if iface := (Renderer{}).Interface(s, "Foo"); iface != test.iface {
+			t.Errorf("%q: got %q, expected %q", test.signature, iface, test.iface)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	methods := []string{
+		"",
+		"<>V",
+		"<>()V",
+		"(L;)V",
+		"(La<>;)V",
+		"(Ljava/util/List<TT;>)V",
+		"()",
+		"()V^",
+		"()V^I",
+		"(TT)V",
+	}
+
+	for _, signature := range methods {
+		if _, err := ParseMethod(signature); err == nil {
+			t.Errorf("%q: expected an error", signature)
+		}
+	}
+
+	fields := []string{
+		"",
+		"I",
+		"L;",
+		"La<>;",
+		"La<*>.;",
+		"Ljava/lang/Object;I",
+	}
+
+	for _, signature := range fields {
+		if _, err := ParseField(signature); err == nil {
+			t.Errorf("%q: expected an error", signature)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package class
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// These ranges of frame_type values determine which kind
+// of frame an entry in a StackMapTable is. The values that
+// are not covered (128-246) are reserved for future use.
+// http://docs.oracle.com/javase/specs/jvms/se7/html/jvms-4.html#jvms-4.7.4
+const (
+	SAME_FRAME                              uint8 = 0   // 0-63
+	SAME_LOCALS_1_STACK_ITEM_FRAME                = 64  // 64-127
+	SAME_LOCALS_1_STACK_ITEM_FRAME_EXTENDED       = 247 // 247
+	CHOP_FRAME                                    = 248 // 248-250
+	SAME_FRAME_EXTENDED                           = 251 // 251
+	APPEND_FRAME                                  = 252 // 252-254
+	FULL_FRAME                                    = 255 // 255
+)
+
+// These constants are the tags of the verification_type_info
+// union, that describes the type of a local variable or an
+// operand stack entry in a stack map frame.
+// http://docs.oracle.com/javase/specs/jvms/se7/html/jvms-4.html#jvms-4.7.4
+const (
+	ITEM_Top               VerificationType = 0
+	ITEM_Integer                            = 1
+	ITEM_Float                              = 2
+	ITEM_Double                             = 3
+	ITEM_Long                               = 4
+	ITEM_Null                               = 5
+	ITEM_UninitializedThis                  = 6
+	ITEM_Object                             = 7
+	ITEM_Uninitialized                      = 8
+)
+
+// A StackMapFrame specifies (either explicitly or implicitly)
+// the bytecode offset at which it applies, and the
+// verification types of local variables and operand stack
+// entries for that offset. Which of the concrete frame types
+// it is, can be told by looking at the frame type.
+type StackMapFrame interface {
+	Dumper
+
+	read(io.Reader) error
+
+	// The raw frame_type byte, which for some frames
+	// also encodes the offset delta or the number of
+	// chopped/appended locals.
+	GetFrameType() uint8
+
+	// The offset delta, either taken from frame_type
+	// or from the explicit offset_delta field.
+	OffsetDelta() uint16
+}
+
+func readStackMapFrame(r io.Reader) (StackMapFrame, error) {
+	frameBase := baseFrame{}
+
+	err := binary.Read(r, byteOrder, &frameBase.FrameType)
+	if err != nil {
+		return nil, err
+	}
+
+	return fillStackMapFrame(r, frameBase)
+}
+
+func fillStackMapFrame(r io.Reader, frameBase baseFrame) (StackMapFrame, error) {
+	var frame StackMapFrame
+
+	switch t := frameBase.FrameType; {
+	case t < SAME_LOCALS_1_STACK_ITEM_FRAME:
+		frame = &SameFrame{baseFrame: frameBase}
+	case t < 128:
+		frame = &SameLocals1StackItemFrame{baseFrame: frameBase}
+	case t == SAME_LOCALS_1_STACK_ITEM_FRAME_EXTENDED:
+		frame = &SameLocals1StackItemFrameExtended{baseFrame: frameBase}
+	case t >= CHOP_FRAME && t < SAME_FRAME_EXTENDED:
+		frame = &ChopFrame{baseFrame: frameBase}
+	case t == SAME_FRAME_EXTENDED:
+		frame = &SameFrameExtended{baseFrame: frameBase}
+	case t >= APPEND_FRAME && t < FULL_FRAME:
+		frame = &AppendFrame{baseFrame: frameBase}
+	case t == FULL_FRAME:
+		frame = &FullFrame{baseFrame: frameBase}
+	default:
+		return nil, errors.New("jclass: reserved stack map frame type")
+	}
+
+	err := frame.read(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return frame, nil
+}
+
+type baseFrame struct {
+	FrameType uint8
+}
+
+func (f baseFrame) GetFrameType() uint8 {
+	return f.FrameType
+}
+
+// same_frame, the frame has exactly the same locals as
+// the previous frame and the operand stack is empty.
+type SameFrame struct {
+	baseFrame
+}
+
+func (f *SameFrame) OffsetDelta() uint16 { return uint16(f.FrameType) }
+
+func (f *SameFrame) read(r io.Reader) error { return nil }
+
+func (f *SameFrame) Dump(w io.Writer) error { return binary.Write(w, byteOrder, f.baseFrame) }
+
+// same_locals_1_stack_item_frame, the frame has the same
+// locals as the previous frame and the operand stack has
+// exactly one entry.
+type SameLocals1StackItemFrame struct {
+	baseFrame
+	Stack VerificationTypeInfo
+}
+
+func (f *SameLocals1StackItemFrame) OffsetDelta() uint16 {
+	return uint16(f.FrameType - SAME_LOCALS_1_STACK_ITEM_FRAME)
+}
+
+func (f *SameLocals1StackItemFrame) read(r io.Reader) error {
+	var err error
+	f.Stack, err = readVerificationTypeInfo(r)
+	return err
+}
+
+func (f *SameLocals1StackItemFrame) Dump(w io.Writer) error {
+	return multiError([]error{
+		binary.Write(w, byteOrder, f.baseFrame),
+		f.Stack.Dump(w),
+	})
+}
+
+// same_locals_1_stack_item_frame_extended, like
+// SameLocals1StackItemFrame, but with an explicit delta.
+type SameLocals1StackItemFrameExtended struct {
+	baseFrame
+	Delta uint16
+	Stack VerificationTypeInfo
+}
+
+func (f *SameLocals1StackItemFrameExtended) OffsetDelta() uint16 { return f.Delta }
+
+func (f *SameLocals1StackItemFrameExtended) read(r io.Reader) error {
+	err := binary.Read(r, byteOrder, &f.Delta)
+	if err != nil {
+		return err
+	}
+
+	f.Stack, err = readVerificationTypeInfo(r)
+	return err
+}
+
+func (f *SameLocals1StackItemFrameExtended) Dump(w io.Writer) error {
+	return multiError([]error{
+		binary.Write(w, byteOrder, f.baseFrame),
+		binary.Write(w, byteOrder, f.Delta),
+		f.Stack.Dump(w),
+	})
+}
+
+// chop_frame, the operand stack is empty and the current
+// locals are the same as in the previous frame, except
+// that the last k locals are absent.
+type ChopFrame struct {
+	baseFrame
+	Delta uint16
+}
+
+func (f *ChopFrame) OffsetDelta() uint16 { return f.Delta }
+
+// The number of locals chopped off (1-3).
+func (f *ChopFrame) Chopped() int { return int(SAME_FRAME_EXTENDED - f.FrameType) }
+
+func (f *ChopFrame) read(r io.Reader) error {
+	return binary.Read(r, byteOrder, &f.Delta)
+}
+
+func (f *ChopFrame) Dump(w io.Writer) error { return binary.Write(w, byteOrder, f) }
+
+// same_frame_extended, like SameFrame, but with an
+// explicit delta.
+type SameFrameExtended struct {
+	baseFrame
+	Delta uint16
+}
+
+func (f *SameFrameExtended) OffsetDelta() uint16 { return f.Delta }
+
+func (f *SameFrameExtended) read(r io.Reader) error {
+	return binary.Read(r, byteOrder, &f.Delta)
+}
+
+func (f *SameFrameExtended) Dump(w io.Writer) error { return binary.Write(w, byteOrder, f) }
+
+// append_frame, the operand stack is empty and the
+// current locals are the same as in the previous frame,
+// except that k additional locals are defined.
+type AppendFrame struct {
+	baseFrame
+	Delta  uint16
+	Locals []VerificationTypeInfo
+}
+
+func (f *AppendFrame) OffsetDelta() uint16 { return f.Delta }
+
+func (f *AppendFrame) read(r io.Reader) error {
+	err := binary.Read(r, byteOrder, &f.Delta)
+	if err != nil {
+		return err
+	}
+
+	f.Locals, err = readVerificationTypeInfos(r, uint16(f.FrameType-SAME_FRAME_EXTENDED))
+	return err
+}
+
+func (f *AppendFrame) Dump(w io.Writer) error {
+	return multiError([]error{
+		binary.Write(w, byteOrder, f.baseFrame),
+		binary.Write(w, byteOrder, f.Delta),
+		writeVerificationTypeInfos(w, f.Locals),
+	})
+}
+
+// full_frame, explicitly lists all locals and operand
+// stack entries.
+type FullFrame struct {
+	baseFrame
+	Delta  uint16
+	Locals []VerificationTypeInfo
+	Stack  []VerificationTypeInfo
+}
+
+func (f *FullFrame) OffsetDelta() uint16 { return f.Delta }
+
+func (f *FullFrame) read(r io.Reader) error {
+	var err error
+
+	var localsCount uint16
+	err = multiError([]error{
+		binary.Read(r, byteOrder, &f.Delta),
+		binary.Read(r, byteOrder, &localsCount),
+	})
+	if err != nil {
+		return err
+	}
+
+	f.Locals, err = readVerificationTypeInfos(r, localsCount)
+	if err != nil {
+		return err
+	}
+
+	var stackCount uint16
+	err = binary.Read(r, byteOrder, &stackCount)
+	if err != nil {
+		return err
+	}
+
+	f.Stack, err = readVerificationTypeInfos(r, stackCount)
+	return err
+}
+
+func (f *FullFrame) Dump(w io.Writer) error {
+	return multiError([]error{
+		binary.Write(w, byteOrder, f.baseFrame),
+		binary.Write(w, byteOrder, f.Delta),
+		binary.Write(w, byteOrder, uint16(len(f.Locals))),
+		writeVerificationTypeInfos(w, f.Locals),
+		binary.Write(w, byteOrder, uint16(len(f.Stack))),
+		writeVerificationTypeInfos(w, f.Stack),
+	})
+}
+
+type VerificationType uint8
+
+// A VerificationTypeInfo describes the type of a single
+// local variable or operand stack entry. Long and Double
+// take up two locals, but are represented by one entry.
+type VerificationTypeInfo interface {
+	Dumper
+
+	read(io.Reader) error
+
+	GetTag() VerificationType
+}
+
+func readVerificationTypeInfos(r io.Reader, count uint16) ([]VerificationTypeInfo, error) {
+	infos := make([]VerificationTypeInfo, 0, count)
+
+	for i := uint16(0); i < count; i++ {
+		info, err := readVerificationTypeInfo(r)
+		if err != nil {
+			return nil, err
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+func writeVerificationTypeInfos(w io.Writer, infos []VerificationTypeInfo) error {
+	for _, info := range infos {
+		err := info.Dump(w)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readVerificationTypeInfo(r io.Reader) (VerificationTypeInfo, error) {
+	infoBase := baseVerificationType{}
+
+	err := binary.Read(r, byteOrder, &infoBase.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	var info VerificationTypeInfo
+
+	switch infoBase.Tag {
+	case ITEM_Top:
+		info = &TopVariable{infoBase}
+	case ITEM_Integer:
+		info = &IntegerVariable{infoBase}
+	case ITEM_Float:
+		info = &FloatVariable{infoBase}
+	case ITEM_Double:
+		info = &DoubleVariable{infoBase}
+	case ITEM_Long:
+		info = &LongVariable{infoBase}
+	case ITEM_Null:
+		info = &NullVariable{infoBase}
+	case ITEM_UninitializedThis:
+		info = &UninitializedThisVariable{infoBase}
+	case ITEM_Object:
+		info = &ObjectVariable{baseVerificationType: infoBase}
+	case ITEM_Uninitialized:
+		info = &UninitializedVariable{baseVerificationType: infoBase}
+	default:
+		return nil, errors.New("jclass: unknown verification type tag")
+	}
+
+	err = info.read(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+type baseVerificationType struct {
+	Tag VerificationType
+}
+
+func (v baseVerificationType) GetTag() VerificationType { return v.Tag }
+
+func (v *baseVerificationType) read(r io.Reader) error { return nil }
+
+func (v *baseVerificationType) Dump(w io.Writer) error { return binary.Write(w, byteOrder, v) }
+
+type TopVariable struct{ baseVerificationType }
+type IntegerVariable struct{ baseVerificationType }
+type FloatVariable struct{ baseVerificationType }
+type DoubleVariable struct{ baseVerificationType }
+type LongVariable struct{ baseVerificationType }
+type NullVariable struct{ baseVerificationType }
+type UninitializedThisVariable struct{ baseVerificationType }
+
+// The type is an instance of the class referenced by ClassIndex
+// (a CONSTANT_Class_info entry in the constant pool).
+type ObjectVariable struct {
+	baseVerificationType
+	ClassIndex ConstPoolIndex
+}
+
+func (v *ObjectVariable) read(r io.Reader) error {
+	return binary.Read(r, byteOrder, &v.ClassIndex)
+}
+
+func (v *ObjectVariable) Dump(w io.Writer) error { return binary.Write(w, byteOrder, v) }
+
+// The type is the result of the new instruction at
+// Offset, that has not been initialized yet.
+type UninitializedVariable struct {
+	baseVerificationType
+	Offset uint16
+}
+
+func (v *UninitializedVariable) read(r io.Reader) error {
+	return binary.Read(r, byteOrder, &v.Offset)
+}
+
+func (v *UninitializedVariable) Dump(w io.Writer) error { return binary.Write(w, byteOrder, v) }
@@ -0,0 +1,86 @@
+package class
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestStackMapFrameReadDump(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		frame StackMapFrame
+		delta uint16
+	}{
+		{"same_frame", []byte{5}, &SameFrame{}, 5},
+		{"same_locals_1_stack_item_frame", []byte{64 + 3, ITEM_Integer}, &SameLocals1StackItemFrame{}, 3},
+		{"same_locals_1_stack_item_frame_extended", []byte{247, 0x01, 0x00, ITEM_Object, 0x00, 0x07}, &SameLocals1StackItemFrameExtended{}, 256},
+		{"chop_frame", []byte{249, 0x00, 0x09}, &ChopFrame{}, 9},
+		{"same_frame_extended", []byte{251, 0x12, 0x34}, &SameFrameExtended{}, 0x1234},
+		{"append_frame", []byte{254, 0x00, 0x02, ITEM_Long, ITEM_Uninitialized, 0x00, 0x10, ITEM_Null}, &AppendFrame{}, 2},
+		{"full_frame", []byte{
+			255, 0x00, 0x04,
+			0x00, 0x03, byte(ITEM_Top), ITEM_UninitializedThis, ITEM_Double,
+			0x00, 0x02, ITEM_Float, ITEM_Object, 0x00, 0x02,
+		}, &FullFrame{}, 4},
+		{"full_frame, empty", []byte{255, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, &FullFrame{}, 0},
+	}
+
+	for _, test := range tests {
+		frame, err := readStackMapFrame(bytes.NewReader(test.data))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if reflect.TypeOf(frame) != reflect.TypeOf(test.frame) {
+			t.Errorf("%s: read as %T", test.name, frame)
+		}
+
+		if frame.OffsetDelta() != test.delta {
+			t.Errorf("%s: offset delta %d, want %d", test.name, frame.OffsetDelta(), test.delta)
+		}
+
+		if got := dump(t, frame); !bytes.Equal(got, test.data) {
+			t.Errorf("%s: dumped as % x, want % x", test.name, got, test.data)
+		}
+	}
+}
+
+func TestVerificationTypeInfoReadDump(t *testing.T) {
+	tests := []struct {
+		data []byte
+		info VerificationTypeInfo
+	}{
+		{[]byte{byte(ITEM_Top)}, &TopVariable{baseVerificationType{ITEM_Top}}},
+		{[]byte{ITEM_Integer}, &IntegerVariable{baseVerificationType{ITEM_Integer}}},
+		{[]byte{ITEM_Float}, &FloatVariable{baseVerificationType{ITEM_Float}}},
+		{[]byte{ITEM_Double}, &DoubleVariable{baseVerificationType{ITEM_Double}}},
+		{[]byte{ITEM_Long}, &LongVariable{baseVerificationType{ITEM_Long}}},
+		{[]byte{ITEM_Null}, &NullVariable{baseVerificationType{ITEM_Null}}},
+		{[]byte{ITEM_UninitializedThis}, &UninitializedThisVariable{baseVerificationType{ITEM_UninitializedThis}}},
+		{[]byte{ITEM_Object, 0x01, 0x02}, &ObjectVariable{baseVerificationType{ITEM_Object}, 0x0102}},
+		{[]byte{ITEM_Uninitialized, 0x03, 0x04}, &UninitializedVariable{baseVerificationType{ITEM_Uninitialized}, 0x0304}},
+	}
+
+	for _, test := range tests {
+		info, err := readVerificationTypeInfo(bytes.NewReader(test.data))
+		if err != nil {
+			t.Errorf("% x: %v", test.data, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(info, test.info) {
+			t.Errorf("% x: read as %#v", test.data, info)
+		}
+
+		if got := dump(t, info); !bytes.Equal(got, test.data) {
+			t.Errorf("% x: dumped as % x", test.data, got)
+		}
+	}
+
+	if _, err := readVerificationTypeInfo(bytes.NewReader([]byte{9})); err == nil {
+		t.Error("unknown verification type tag not reported")
+	}
+}
@@ -0,0 +1,328 @@
+package class
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// These constants are the possible values of target_type in a
+// type_annotation. They determine in which kind of type the
+// annotated type appears and which target_info follows.
+// 0x00-0x17 appear on ClassFile, field_info and method_info,
+// 0x40-0x4B only on Code.
+// http://docs.oracle.com/javase/specs/jvms/se8/html/jvms-4.html#jvms-4.7.20
+const (
+	TARGET_CLASS_TYPE_PARAMETER                 TargetType = 0x00 // type_parameter_target
+	TARGET_METHOD_TYPE_PARAMETER                           = 0x01 // type_parameter_target
+	TARGET_CLASS_EXTENDS                                   = 0x10 // supertype_target
+	TARGET_CLASS_TYPE_PARAMETER_BOUND                      = 0x11 // type_parameter_bound_target
+	TARGET_METHOD_TYPE_PARAMETER_BOUND                     = 0x12 // type_parameter_bound_target
+	TARGET_FIELD                                           = 0x13 // empty_target
+	TARGET_METHOD_RETURN                                   = 0x14 // empty_target
+	TARGET_METHOD_RECEIVER                                 = 0x15 // empty_target
+	TARGET_METHOD_FORMAL_PARAMETER                         = 0x16 // formal_parameter_target
+	TARGET_THROWS                                          = 0x17 // throws_target
+	TARGET_LOCAL_VARIABLE                                  = 0x40 // localvar_target
+	TARGET_RESOURCE_VARIABLE                               = 0x41 // localvar_target
+	TARGET_EXCEPTION_PARAMETER                             = 0x42 // catch_target
+	TARGET_INSTANCEOF                                      = 0x43 // offset_target
+	TARGET_NEW                                             = 0x44 // offset_target
+	TARGET_CONSTRUCTOR_REFERENCE                           = 0x45 // offset_target
+	TARGET_METHOD_REFERENCE                                = 0x46 // offset_target
+	TARGET_CAST                                            = 0x47 // type_argument_target
+	TARGET_CONSTRUCTOR_INVOCATION_TYPE_ARGUMENT            = 0x48 // type_argument_target
+	TARGET_METHOD_INVOCATION_TYPE_ARGUMENT                 = 0x49 // type_argument_target
+	TARGET_CONSTRUCTOR_REFERENCE_TYPE_ARGUMENT             = 0x4A // type_argument_target
+	TARGET_METHOD_REFERENCE_TYPE_ARGUMENT                  = 0x4B // type_argument_target
+)
+
+// These constants are the possible values of type_path_kind,
+// describing one step from a type to the part of it that is
+// actually annotated.
+// http://docs.oracle.com/javase/specs/jvms/se8/html/jvms-4.html#jvms-4.7.20.2
+const (
+	TYPE_PATH_ARRAY         TypePathKind = 0 // deeper in an array type
+	TYPE_PATH_INNER_TYPE                 = 1 // deeper in a nested type
+	TYPE_PATH_WILDCARD                   = 2 // on the bound of a wildcard type argument
+	TYPE_PATH_TYPE_ARGUMENT              = 3 // on a type argument of a parameterized type
+)
+
+type TargetType uint8
+
+type TypePathKind uint8
+
+// A TypeAnnotation is an annotation on a use of a type. Besides
+// the annotation itself, it records where the type appears
+// (TargetType and TargetInfo) and which part of the type
+// is annotated (TargetPath).
+type TypeAnnotation struct {
+	TargetType TargetType
+	TargetInfo TargetInfo
+	TargetPath []TypePathEntry
+	Annotation
+}
+
+// A single step of a type_path. TypeArgumentIndex is only
+// meaningful for TYPE_PATH_TYPE_ARGUMENT and zero otherwise.
+type TypePathEntry struct {
+	TypePathKind      TypePathKind
+	TypeArgumentIndex uint8
+}
+
+// TypeAnnotations returns the type annotations of all
+// RuntimeVisibleTypeAnnotations and RuntimeInvisibleTypeAnnotations
+// attributes in attrs.
+func (attrs Attributes) TypeAnnotations() []*TypeAnnotation {
+	var annotations []*TypeAnnotation
+
+	for _, attr := range attrs {
+		switch attr.GetTag() {
+		case RuntimeVisibleTypeAnnotationsTag:
+			annotations = append(annotations, attr.RuntimeVisibleTypeAnnotations().Annotations...)
+		case RuntimeInvisibleTypeAnnotationsTag:
+			annotations = append(annotations, attr.RuntimeInvisibleTypeAnnotations().Annotations...)
+		}
+	}
+
+	return annotations
+}
+
+func readTypeAnnotations(r io.Reader) ([]*TypeAnnotation, error) {
+	var count uint16
+	err := binary.Read(r, byteOrder, &count)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := make([]*TypeAnnotation, 0, count)
+
+	for i := uint16(0); i < count; i++ {
+		annotation := &TypeAnnotation{}
+		err := annotation.read(r)
+		if err != nil {
+			return nil, err
+		}
+
+		annotations = append(annotations, annotation)
+	}
+
+	return annotations, nil
+}
+
+func writeTypeAnnotations(w io.Writer, annotations []*TypeAnnotation) error {
+	err := binary.Write(w, byteOrder, uint16(len(annotations)))
+	if err != nil {
+		return err
+	}
+
+	for _, annotation := range annotations {
+		err := annotation.dump(w)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *TypeAnnotation) read(r io.Reader) error {
+	var err error
+
+	err = binary.Read(r, byteOrder, &a.TargetType)
+	if err != nil {
+		return err
+	}
+
+	a.TargetInfo, err = readTargetInfo(r, a.TargetType)
+	if err != nil {
+		return err
+	}
+
+	var pathLength uint8
+	err = binary.Read(r, byteOrder, &pathLength)
+	if err != nil {
+		return err
+	}
+
+	a.TargetPath = make([]TypePathEntry, pathLength)
+	err = binary.Read(r, byteOrder, a.TargetPath)
+	if err != nil {
+		return err
+	}
+
+	return a.Annotation.read(r)
+}
+
+func (a *TypeAnnotation) dump(w io.Writer) error {
+	return multiError([]error{
+		binary.Write(w, byteOrder, a.TargetType),
+		a.TargetInfo.Dump(w),
+		binary.Write(w, byteOrder, uint8(len(a.TargetPath))),
+		binary.Write(w, byteOrder, a.TargetPath),
+		a.Annotation.dump(w),
+	})
+}
+
+// TargetInfo is one of the target_info union members. Which
+// one it is, is determined by the TargetType of the enclosing
+// TypeAnnotation.
+type TargetInfo interface {
+	Dumper
+
+	read(io.Reader) error
+}
+
+func readTargetInfo(r io.Reader, targetType TargetType) (TargetInfo, error) {
+	var info TargetInfo
+
+	switch targetType {
+	case TARGET_CLASS_TYPE_PARAMETER, TARGET_METHOD_TYPE_PARAMETER:
+		info = &TypeParameterTarget{}
+	case TARGET_CLASS_EXTENDS:
+		info = &SupertypeTarget{}
+	case TARGET_CLASS_TYPE_PARAMETER_BOUND, TARGET_METHOD_TYPE_PARAMETER_BOUND:
+		info = &TypeParameterBoundTarget{}
+	case TARGET_FIELD, TARGET_METHOD_RETURN, TARGET_METHOD_RECEIVER:
+		info = &EmptyTarget{}
+	case TARGET_METHOD_FORMAL_PARAMETER:
+		info = &FormalParameterTarget{}
+	case TARGET_THROWS:
+		info = &ThrowsTarget{}
+	case TARGET_LOCAL_VARIABLE, TARGET_RESOURCE_VARIABLE:
+		info = &LocalVarTarget{}
+	case TARGET_EXCEPTION_PARAMETER:
+		info = &CatchTarget{}
+	case TARGET_INSTANCEOF, TARGET_NEW, TARGET_CONSTRUCTOR_REFERENCE, TARGET_METHOD_REFERENCE:
+		info = &OffsetTarget{}
+	case TARGET_CAST, TARGET_CONSTRUCTOR_INVOCATION_TYPE_ARGUMENT, TARGET_METHOD_INVOCATION_TYPE_ARGUMENT,
+		TARGET_CONSTRUCTOR_REFERENCE_TYPE_ARGUMENT, TARGET_METHOD_REFERENCE_TYPE_ARGUMENT:
+		info = &TypeArgumentTarget{}
+	default:
+		return nil, errors.New("jclass: unknown type annotation target type")
+	}
+
+	err := info.read(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// The annotation appears on the declaration of the i'th
+// type parameter of a generic class, interface or method.
+type TypeParameterTarget struct {
+	TypeParameterIndex uint8
+}
+
+func (t *TypeParameterTarget) read(r io.Reader) error { return binary.Read(r, byteOrder, t) }
+func (t *TypeParameterTarget) Dump(w io.Writer) error { return binary.Write(w, byteOrder, t) }
+
+// The annotation appears on a type in the extends (0xFFFF)
+// or implements clause (index into ClassFile.Interfaces)
+// of a class or interface declaration.
+type SupertypeTarget struct {
+	SupertypeIndex uint16
+}
+
+func (t *SupertypeTarget) read(r io.Reader) error { return binary.Read(r, byteOrder, t) }
+func (t *SupertypeTarget) Dump(w io.Writer) error { return binary.Write(w, byteOrder, t) }
+
+// The annotation appears on the BoundIndex'th bound of the
+// TypeParameterIndex'th type parameter.
+type TypeParameterBoundTarget struct {
+	TypeParameterIndex uint8
+	BoundIndex         uint8
+}
+
+func (t *TypeParameterBoundTarget) read(r io.Reader) error { return binary.Read(r, byteOrder, t) }
+func (t *TypeParameterBoundTarget) Dump(w io.Writer) error { return binary.Write(w, byteOrder, t) }
+
+// The annotation appears on the type in a field declaration,
+// the return type of a method, or the receiver of a method.
+type EmptyTarget struct{}
+
+func (t *EmptyTarget) read(r io.Reader) error { return nil }
+func (t *EmptyTarget) Dump(w io.Writer) error { return nil }
+
+// The annotation appears on the type in a formal parameter
+// declaration of a method, constructor or lambda expression.
+type FormalParameterTarget struct {
+	FormalParameterIndex uint8
+}
+
+func (t *FormalParameterTarget) read(r io.Reader) error { return binary.Read(r, byteOrder, t) }
+func (t *FormalParameterTarget) Dump(w io.Writer) error { return binary.Write(w, byteOrder, t) }
+
+// The annotation appears on the i'th type in the throws clause
+// of a method, i.e. the i'th entry of its Exceptions attribute.
+type ThrowsTarget struct {
+	ThrowsTypeIndex uint16
+}
+
+func (t *ThrowsTarget) read(r io.Reader) error { return binary.Read(r, byteOrder, t) }
+func (t *ThrowsTarget) Dump(w io.Writer) error { return binary.Write(w, byteOrder, t) }
+
+// The annotation appears on the type in a local variable
+// declaration, which may span multiple code ranges.
+type LocalVarTarget struct {
+	Table []LocalVarTargetRange
+}
+
+// The local variable at Index has a value in the code
+// range [StartPC, StartPC+Length).
+type LocalVarTargetRange struct {
+	StartPC uint16
+	Length  uint16
+	Index   uint16
+}
+
+func (t *LocalVarTarget) read(r io.Reader) error {
+	var tableLength uint16
+	err := binary.Read(r, byteOrder, &tableLength)
+	if err != nil {
+		return err
+	}
+
+	t.Table = make([]LocalVarTargetRange, tableLength)
+	return binary.Read(r, byteOrder, t.Table)
+}
+
+func (t *LocalVarTarget) Dump(w io.Writer) error {
+	return multiError([]error{
+		binary.Write(w, byteOrder, uint16(len(t.Table))),
+		binary.Write(w, byteOrder, t.Table),
+	})
+}
+
+// The annotation appears on the type of the exception
+// parameter of the ExceptionTableIndex'th entry of the
+// Code.ExceptionsTable.
+type CatchTarget struct {
+	ExceptionTableIndex uint16
+}
+
+func (t *CatchTarget) read(r io.Reader) error { return binary.Read(r, byteOrder, t) }
+func (t *CatchTarget) Dump(w io.Writer) error { return binary.Write(w, byteOrder, t) }
+
+// The annotation appears on the type in an instanceof or new
+// expression, or a method reference, whose instruction is at
+// the bytecode Offset.
+type OffsetTarget struct {
+	Offset uint16
+}
+
+func (t *OffsetTarget) read(r io.Reader) error { return binary.Read(r, byteOrder, t) }
+func (t *OffsetTarget) Dump(w io.Writer) error { return binary.Write(w, byteOrder, t) }
+
+// The annotation appears on the TypeArgumentIndex'th type in
+// a cast, or in the explicit type argument list of the
+// instruction at the bytecode Offset.
+type TypeArgumentTarget struct {
+	Offset            uint16
+	TypeArgumentIndex uint8
+}
+
+func (t *TypeArgumentTarget) read(r io.Reader) error { return binary.Read(r, byteOrder, t) }
+func (t *TypeArgumentTarget) Dump(w io.Writer) error { return binary.Write(w, byteOrder, t) }
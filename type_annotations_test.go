@@ -0,0 +1,153 @@
+package class
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestTypeAnnotationReadDump(t *testing.T) {
+	// @Foo without elements, after target_info and type_path
+	annotation := []byte{0x00, 0x02, 0x00, 0x00}
+	noPath := []byte{0x00}
+
+	tests := []struct {
+		name       string
+		targetType TargetType
+		info       []byte
+		path       []byte
+		target     TargetInfo
+		typePath   []TypePathEntry
+	}{
+		{"class type parameter", TARGET_CLASS_TYPE_PARAMETER, []byte{0x01}, noPath, &TypeParameterTarget{1}, nil},
+		{"method type parameter", TARGET_METHOD_TYPE_PARAMETER, []byte{0x00}, noPath, &TypeParameterTarget{0}, nil},
+		{"extends", TARGET_CLASS_EXTENDS, []byte{0xff, 0xff}, noPath, &SupertypeTarget{0xffff}, nil},
+		{"implements", TARGET_CLASS_EXTENDS, []byte{0x00, 0x02}, noPath, &SupertypeTarget{2}, nil},
+		{"type parameter bound", TARGET_METHOD_TYPE_PARAMETER_BOUND, []byte{0x01, 0x02}, noPath, &TypeParameterBoundTarget{1, 2}, nil},
+		{"field", TARGET_FIELD, nil, noPath, &EmptyTarget{}, nil},
+		{"return", TARGET_METHOD_RETURN, nil, noPath, &EmptyTarget{}, nil},
+		{"receiver", TARGET_METHOD_RECEIVER, nil, noPath, &EmptyTarget{}, nil},
+		{"formal parameter", TARGET_METHOD_FORMAL_PARAMETER, []byte{0x03}, noPath, &FormalParameterTarget{3}, nil},
+		{"throws", TARGET_THROWS, []byte{0x00, 0x01}, noPath, &ThrowsTarget{1}, nil},
+		{
+			"local variable",
+			TARGET_LOCAL_VARIABLE,
+			[]byte{0x00, 0x02, 0x00, 0x00, 0x00, 0x10, 0x00, 0x01, 0x00, 0x20, 0x00, 0x04, 0x00, 0x01},
+			noPath,
+			&LocalVarTarget{[]LocalVarTargetRange{{0, 16, 1}, {32, 4, 1}}},
+			nil,
+		},
+		{"resource variable", TARGET_RESOURCE_VARIABLE, []byte{0x00, 0x00}, noPath, &LocalVarTarget{[]LocalVarTargetRange{}}, nil},
+		{"exception parameter", TARGET_EXCEPTION_PARAMETER, []byte{0x00, 0x05}, noPath, &CatchTarget{5}, nil},
+		{"instanceof", TARGET_INSTANCEOF, []byte{0x01, 0x00}, noPath, &OffsetTarget{256}, nil},
+		{"method reference", TARGET_METHOD_REFERENCE, []byte{0x00, 0x07}, noPath, &OffsetTarget{7}, nil},
+		{"cast", TARGET_CAST, []byte{0x00, 0x09, 0x01}, noPath, &TypeArgumentTarget{9, 1}, nil},
+		{
+			"method reference type argument",
+			TARGET_METHOD_REFERENCE_TYPE_ARGUMENT,
+			[]byte{0x00, 0x0a, 0x00},
+			noPath,
+			&TypeArgumentTarget{10, 0},
+			nil,
+		},
+		{
+			"array and inner type path",
+			TARGET_FIELD,
+			nil,
+			[]byte{0x03, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00},
+			&EmptyTarget{},
+			[]TypePathEntry{{TYPE_PATH_ARRAY, 0}, {TYPE_PATH_ARRAY, 0}, {TYPE_PATH_INNER_TYPE, 0}},
+		},
+		{
+			"type argument and wildcard type path",
+			TARGET_METHOD_RETURN,
+			nil,
+			[]byte{0x02, 0x03, 0x01, 0x02, 0x00},
+			&EmptyTarget{},
+			[]TypePathEntry{{TYPE_PATH_TYPE_ARGUMENT, 1}, {TYPE_PATH_WILDCARD, 0}},
+		},
+	}
+
+	for _, test := range tests {
+		data := append([]byte{byte(test.targetType)}, test.info...)
+		data = append(append(data, test.path...), annotation...)
+
+		a := &TypeAnnotation{}
+		if err := a.read(bytes.NewReader(data)); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		typePath := test.typePath
+		if typePath == nil {
+			typePath = []TypePathEntry{}
+		}
+
+		expected := &TypeAnnotation{test.targetType, test.target, typePath, Annotation{2, []ElementValuePair{}}}
+		if !reflect.DeepEqual(a, expected) {
+			t.Errorf("%s: read as %#v", test.name, a)
+		}
+
+		var buf bytes.Buffer
+		if err := a.dump(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(buf.Bytes(), data) {
+			t.Errorf("%s: dumped as % x, want % x", test.name, buf.Bytes(), data)
+		}
+	}
+
+	invalid := [][]byte{
+		{0x20, 0x00, 0x00, 0x02, 0x00, 0x00},         // unknown target type
+		{byte(TARGET_THROWS), 0x00},                  // truncated target_info
+		{byte(TARGET_FIELD), 0x02, 0x00, 0x00},       // truncated type_path
+		{byte(TARGET_FIELD), 0x00, 0x00, 0x02, 0x00}, // truncated annotation
+	}
+
+	for _, data := range invalid {
+		if err := (&TypeAnnotation{}).read(bytes.NewReader(data)); err == nil {
+			t.Errorf("% x: expected an error", data)
+		}
+	}
+}
+
+func TestTypeAnnotationsReadDump(t *testing.T) {
+	body := []byte{
+		0x00, 0x02,
+		// @Foo on the second bound of the first type parameter
+		byte(TARGET_CLASS_TYPE_PARAMETER_BOUND), 0x00, 0x01, 0x00, 0x00, 0x02, 0x00, 0x00,
+		// @Foo(value = 4) on List<@Foo String> of a local variable
+		byte(TARGET_LOCAL_VARIABLE), 0x00, 0x01, 0x00, 0x00, 0x00, 0x08, 0x00, 0x02,
+		0x01, 0x03, 0x00,
+		0x00, 0x02, 0x00, 0x01, 0x00, 0x03, 'I', 0x00, 0x04,
+	}
+
+	for _, name := range []string{"RuntimeVisibleTypeAnnotations", "RuntimeInvisibleTypeAnnotations"} {
+		attr := readAttributeBody(t, testConstPool(name, "LFoo;", "value", int32(4)), body)
+
+		annotations := Attributes{attr}.TypeAnnotations()
+		if len(annotations) != 2 {
+			t.Fatalf("%s: got %d type annotations", name, len(annotations))
+		}
+
+		if bound, ok := annotations[0].TargetInfo.(*TypeParameterBoundTarget); !ok || bound.BoundIndex != 1 {
+			t.Errorf("%s: got %#v", name, annotations[0].TargetInfo)
+		}
+
+		local := annotations[1]
+		if len(local.TargetInfo.(*LocalVarTarget).Table) != 1 || len(local.TargetPath) != 1 || len(local.Pairs) != 1 {
+			t.Errorf("%s: got %#v", name, local)
+		}
+	}
+
+	attrs := Attributes{
+		&RuntimeVisibleTypeAnnotations{Annotations: make([]*TypeAnnotation, 2)},
+		&RuntimeInvisibleTypeAnnotations{Annotations: make([]*TypeAnnotation, 1)},
+		&RuntimeVisibleAnnotations{Annotations: make([]*Annotation, 1)},
+	}
+
+	if annotations := attrs.TypeAnnotations(); len(annotations) != 3 {
+		t.Errorf("got %d type annotations", len(annotations))
+	}
+}
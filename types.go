@@ -103,10 +103,11 @@ type Attribute interface {
 	// You shouldn't call any of the following functions if you
 	// aren't sure about what type an Attribute actually has,
 	// since if you are wrong, the function will panic.
+	// Use As for a checked conversion instead.
 	UnknownAttr() *UnknownAttr
 	ConstantValue() *ConstantValue
 	Code() *Code
-	// StackMapTable() *StackMapTable
+	StackMapTable() *StackMapTable
 	Exceptions() *Exceptions
 	InnerClasses() *InnerClasses
 	EnclosingMethod() *EnclosingMethod
@@ -118,12 +119,22 @@ type Attribute interface {
 	LocalVariableTable() *LocalVariableTable
 	LocalVariableTypeTable() *LocalVariableTypeTable
 	Deprecated() *Deprecated
-	// RuntimeVisibleAnnotations() *RuntimeVisibleAnnotations
-	// RuntimeInvisibleAnnotations() *RuntimeInvisibleAnnotations
-	// RuntimeVisibleParameterAnnotations() *RuntimeVisibleParameterAnnotations
-	// RuntimeInvisibleParameterAnnotations() *RuntimeInvisibleParameterAnnotations
-	// AnnotationDefault() *AnnotationDefault
+	RuntimeVisibleAnnotations() *RuntimeVisibleAnnotations
+	RuntimeInvisibleAnnotations() *RuntimeInvisibleAnnotations
+	RuntimeVisibleParameterAnnotations() *RuntimeVisibleParameterAnnotations
+	RuntimeInvisibleParameterAnnotations() *RuntimeInvisibleParameterAnnotations
+	AnnotationDefault() *AnnotationDefault
 	BootstrapMethods() *BootstrapMethods
+	RuntimeVisibleTypeAnnotations() *RuntimeVisibleTypeAnnotations
+	RuntimeInvisibleTypeAnnotations() *RuntimeInvisibleTypeAnnotations
+	Module() *Module
+	ModulePackages() *ModulePackages
+	ModuleMainClass() *ModuleMainClass
+	NestHost() *NestHost
+	NestMembers() *NestMembers
+	Record() *Record
+	PermittedSubclasses() *PermittedSubclasses
+	MethodParameters() *MethodParameters
 }
 
 // Costants reside in a class files constant pool and
@@ -162,7 +173,10 @@ type Constant interface {
 	UTF8() *UTF8Ref
 	MethodHandle() *MethodHandleRef
 	MethodType() *MethodTypeRef
+	Dynamic() *DynamicRef
 	InvokeDynamic() *InvokeDynamicRef
+	Module() *ModuleRef
+	Package() *PackageRef
 }
 
 // Describes a set of attributes as you would find them in a
@@ -0,0 +1,248 @@
+package class
+
+import (
+	"fmt"
+)
+
+// ClassView is a resolved, read-only view of a ClassFile. All
+// names are looked up in the constant pool when the view is
+// created, so a view of a malformed class file can't be built
+// and the accessors never fail. The view doesn't follow later
+// changes to the underlying ClassFile.
+type ClassView struct {
+	c *ClassFile
+
+	name           string
+	superName      string
+	interfaceNames []string
+	fields         []*FieldView
+	methods        []*MethodView
+	sourceFile     string
+}
+
+// FieldView is a resolved, read-only view of a Field.
+type FieldView struct {
+	f *Field
+
+	name       string
+	descriptor string
+}
+
+// MethodView is a resolved, read-only view of a Method.
+type MethodView struct {
+	m *Method
+
+	name       string
+	descriptor string
+}
+
+// Version is the version of a class file, e.g. 52.0 for Java 8.
+type Version struct {
+	Major uint16
+	Minor uint16
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// View resolves the names of c's class, super class, interfaces,
+// fields and methods and its source file.
+func (c *ClassFile) View() (*ClassView, error) {
+	v := &ClassView{c: c, interfaceNames: make([]string, 0, len(c.Interfaces))}
+
+	var err error
+
+	v.name, err = c.className(c.ThisClass)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only java/lang/Object and module-info have no super class
+	if c.SuperClass != 0 {
+		v.superName, err = c.className(c.SuperClass)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, index := range c.Interfaces {
+		name, err := c.className(index)
+		if err != nil {
+			return nil, err
+		}
+
+		v.interfaceNames = append(v.interfaceNames, name)
+	}
+
+	v.fields = make([]*FieldView, 0, len(c.Fields))
+	for _, field := range c.Fields {
+		name, descriptor, err := c.nameAndDescriptor(&field.fieldMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		v.fields = append(v.fields, &FieldView{field, name, descriptor})
+	}
+
+	v.methods = make([]*MethodView, 0, len(c.Methods))
+	for _, method := range c.Methods {
+		name, descriptor, err := c.nameAndDescriptor(&method.fieldMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		v.methods = append(v.methods, &MethodView{method, name, descriptor})
+	}
+
+	if sourceFile := c.SourceFile(); sourceFile != nil {
+		v.sourceFile, err = c.LookupUTF8(sourceFile.SourceFileIndex)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+func (c *ClassFile) nameAndDescriptor(fom *fieldMethod) (string, string, error) {
+	name, err := c.LookupUTF8(fom.NameIndex)
+	if err != nil {
+		return "", "", err
+	}
+
+	descriptor, err := c.LookupUTF8(fom.DescriptorIndex)
+	if err != nil {
+		return "", "", err
+	}
+
+	return name, descriptor, nil
+}
+
+// ClassFile returns the underlying class file.
+func (v *ClassView) ClassFile() *ClassFile { return v.c }
+
+// Name returns the internal name of the class (e.g. java/lang/String).
+func (v *ClassView) Name() string { return v.name }
+
+// SuperName returns the internal name of the super class,
+// "" for java/lang/Object and modules.
+func (v *ClassView) SuperName() string { return v.superName }
+
+// InterfaceNames returns the internal names of
+// the direct super interfaces, in declaration order.
+func (v *ClassView) InterfaceNames() []string {
+	return append([]string(nil), v.interfaceNames...)
+}
+
+// Fields returns the fields declared by the class.
+func (v *ClassView) Fields() []*FieldView {
+	return append([]*FieldView(nil), v.fields...)
+}
+
+// Methods returns the methods declared by the class,
+// including constructors and the static initializer.
+func (v *ClassView) Methods() []*MethodView {
+	return append([]*MethodView(nil), v.methods...)
+}
+
+// Field returns the field with the given name, or nil.
+func (v *ClassView) Field(name string) *FieldView {
+	for _, field := range v.fields {
+		if field.name == name {
+			return field
+		}
+	}
+
+	return nil
+}
+
+// Method returns the method with the given name and
+// descriptor (e.g. "(I)V"), or nil.
+func (v *ClassView) Method(name, descriptor string) *MethodView {
+	for _, method := range v.methods {
+		if method.name == name && method.descriptor == descriptor {
+			return method
+		}
+	}
+
+	return nil
+}
+
+// SourceFile returns the name of the source file from the
+// SourceFile attribute (e.g. "String.java"), "" if there is none.
+func (v *ClassView) SourceFile() string { return v.sourceFile }
+
+// Version returns the class file version.
+func (v *ClassView) Version() Version {
+	return Version{v.c.MajorVersion, v.c.MinorVersion}
+}
+
+// AccessFlags returns the class' access flags, see CLASS_ACC_*.
+func (v *ClassView) AccessFlags() AccessFlags { return v.c.AccessFlags }
+
+func (v *ClassView) IsPublic() bool     { return v.c.AccessFlags&CLASS_ACC_PUBLIC != 0 }
+func (v *ClassView) IsFinal() bool      { return v.c.AccessFlags&CLASS_ACC_FINAL != 0 }
+func (v *ClassView) IsSuper() bool      { return v.c.AccessFlags&CLASS_ACC_SUPER != 0 }
+func (v *ClassView) IsInterface() bool  { return v.c.AccessFlags&CLASS_ACC_INTERFACE != 0 }
+func (v *ClassView) IsAbstract() bool   { return v.c.AccessFlags&CLASS_ACC_ABSTRACT != 0 }
+func (v *ClassView) IsSynthetic() bool  { return v.c.AccessFlags&CLASS_ACC_SYNTHETIC != 0 }
+func (v *ClassView) IsAnnotation() bool { return v.c.AccessFlags&CLASS_ACC_ANNOTATION != 0 }
+func (v *ClassView) IsEnum() bool       { return v.c.AccessFlags&CLASS_ACC_ENUM != 0 }
+func (v *ClassView) IsModule() bool     { return v.c.AccessFlags&CLASS_ACC_MODULE != 0 }
+
+// Field returns the underlying field.
+func (v *FieldView) Field() *Field { return v.f }
+
+// Name returns the field's name.
+func (v *FieldView) Name() string { return v.name }
+
+// Descriptor returns the field descriptor of the
+// field's type (e.g. "I" or "Ljava/lang/String;").
+func (v *FieldView) Descriptor() string { return v.descriptor }
+
+// AccessFlags returns the field's access flags, see FIELD_ACC_*.
+func (v *FieldView) AccessFlags() AccessFlags { return v.f.AccessFlags }
+
+func (v *FieldView) IsPublic() bool    { return v.f.AccessFlags&FIELD_ACC_PUBLIC != 0 }
+func (v *FieldView) IsPrivate() bool   { return v.f.AccessFlags&FIELD_ACC_PRIVATE != 0 }
+func (v *FieldView) IsProtected() bool { return v.f.AccessFlags&FIELD_ACC_PROTECTED != 0 }
+func (v *FieldView) IsStatic() bool    { return v.f.AccessFlags&FIELD_ACC_STATIC != 0 }
+func (v *FieldView) IsFinal() bool     { return v.f.AccessFlags&FIELD_ACC_FINAL != 0 }
+func (v *FieldView) IsVolatile() bool  { return v.f.AccessFlags&FIELD_ACC_VOLATILE != 0 }
+func (v *FieldView) IsTransient() bool { return v.f.AccessFlags&FIELD_ACC_TRANSIENT != 0 }
+func (v *FieldView) IsSynthetic() bool { return v.f.AccessFlags&FIELD_ACC_SYNTHETIC != 0 }
+func (v *FieldView) IsEnum() bool      { return v.f.AccessFlags&FIELD_ACC_ENUM != 0 }
+
+// Method returns the underlying method.
+func (v *MethodView) Method() *Method { return v.m }
+
+// Name returns the method's name, "<init>" for
+// constructors and "<clinit>" for the static initializer.
+func (v *MethodView) Name() string { return v.name }
+
+// Descriptor returns the method descriptor (e.g. "(I)V").
+func (v *MethodView) Descriptor() string { return v.descriptor }
+
+// IsConstructor reports whether the method is an instance initializer.
+func (v *MethodView) IsConstructor() bool { return v.name == "<init>" }
+
+// IsStaticInitializer reports whether the method is
+// the class or interface initialization method.
+func (v *MethodView) IsStaticInitializer() bool { return v.name == "<clinit>" }
+
+// AccessFlags returns the method's access flags, see METHOD_ACC_*.
+func (v *MethodView) AccessFlags() AccessFlags { return v.m.AccessFlags }
+
+func (v *MethodView) IsPublic() bool       { return v.m.AccessFlags&METHOD_ACC_PUBLIC != 0 }
+func (v *MethodView) IsPrivate() bool      { return v.m.AccessFlags&METHOD_ACC_PRIVATE != 0 }
+func (v *MethodView) IsProtected() bool    { return v.m.AccessFlags&METHOD_ACC_PROTECTED != 0 }
+func (v *MethodView) IsStatic() bool       { return v.m.AccessFlags&METHOD_ACC_STATIC != 0 }
+func (v *MethodView) IsFinal() bool        { return v.m.AccessFlags&METHOD_ACC_FINAL != 0 }
+func (v *MethodView) IsSynchronized() bool { return v.m.AccessFlags&METHOD_ACC_SYNCHRONIZED != 0 }
+func (v *MethodView) IsBridge() bool       { return v.m.AccessFlags&METHOD_ACC_BRIDGE != 0 }
+func (v *MethodView) IsVarargs() bool      { return v.m.AccessFlags&METHOD_ACC_VARARGS != 0 }
+func (v *MethodView) IsNative() bool       { return v.m.AccessFlags&METHOD_ACC_NATIVE != 0 }
+func (v *MethodView) IsAbstract() bool     { return v.m.AccessFlags&METHOD_ACC_ABSTRACT != 0 }
+func (v *MethodView) IsStrict() bool       { return v.m.AccessFlags&METHOD_ACC_STRICT != 0 }
+func (v *MethodView) IsSynthetic() bool    { return v.m.AccessFlags&METHOD_ACC_SYNTHETIC != 0 }
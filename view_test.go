@@ -0,0 +1,120 @@
+package class
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassView(t *testing.T) {
+	_, c := readHelloWorld(t)
+	c.Interfaces = []ConstPoolIndex{35, 38}
+
+	v, err := c.View()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v.ClassFile() != c || v.Name() != "HelloWorld" || v.SuperName() != "java/lang/Object" {
+		t.Errorf("got %q extends %q", v.Name(), v.SuperName())
+	}
+
+	if names := v.InterfaceNames(); !reflect.DeepEqual(names, []string{"java/lang/System", "java/io/PrintStream"}) {
+		t.Errorf("got interfaces %q", names)
+	}
+
+	if v.SourceFile() != "HelloWorld.java" || v.Version().String() != "50.0" {
+		t.Errorf("got source file %q and version %s", v.SourceFile(), v.Version())
+	}
+
+	if !v.IsPublic() || !v.IsFinal() || !v.IsSuper() || v.IsInterface() || v.IsAbstract() ||
+		v.IsSynthetic() || v.IsAnnotation() || v.IsEnum() || v.IsModule() {
+		t.Errorf("got class flags %q", v.AccessFlags().In(ClassFlags))
+	}
+
+	if fields := v.Fields(); len(fields) != 3 || fields[0].Field() != c.Fields[0] || fields[0].Name() != "myField" {
+		t.Errorf("got fields %v", fields)
+	}
+
+	myOtherField := v.Field("myOtherField")
+	if myOtherField == nil || myOtherField.Descriptor() != "Ljava/lang/String;" ||
+		!myOtherField.IsPublic() || !myOtherField.IsStatic() || !myOtherField.IsFinal() || myOtherField.IsVolatile() {
+		t.Errorf("got %#v", myOtherField)
+	}
+
+	myList := v.Field("myList")
+	if myList == nil || !myList.IsPrivate() || !myList.IsVolatile() || myList.IsPublic() || myList.IsStatic() ||
+		myList.IsProtected() || myList.IsTransient() || myList.IsSynthetic() || myList.IsEnum() {
+		t.Errorf("got %#v", myList)
+	}
+
+	if field := v.Field("missing"); field != nil {
+		t.Errorf("got %#v", field)
+	}
+
+	if methods := v.Methods(); len(methods) != 3 || methods[2].Method() != c.Methods[2] {
+		t.Errorf("got methods %v", methods)
+	}
+
+	constructor := v.Method("<init>", "()V")
+	if constructor == nil || !constructor.IsConstructor() || constructor.IsStaticInitializer() ||
+		!constructor.IsPublic() || constructor.IsStatic() {
+		t.Errorf("got %#v", constructor)
+	}
+
+	main := v.Method("main", "([Ljava/lang/String;)V")
+	if main == nil || main.IsConstructor() || !main.IsPublic() || !main.IsStatic() || main.IsFinal() ||
+		main.IsSynchronized() || main.IsBridge() || main.IsVarargs() || main.IsNative() || main.IsAbstract() ||
+		main.IsStrict() || main.IsSynthetic() {
+		t.Errorf("got %#v", main)
+	}
+
+	giveItToMe := v.Method("giveItToMe", "()Ljava/lang/String;")
+	if giveItToMe == nil || !giveItToMe.IsProtected() || !giveItToMe.IsFinal() || giveItToMe.IsPrivate() {
+		t.Errorf("got %#v", giveItToMe)
+	}
+
+	if method := v.Method("main", "()V"); method != nil {
+		t.Errorf("got %#v", method)
+	}
+
+	// The view doesn't follow changes to the class file
+	c.AccessFlags = CLASS_ACC_INTERFACE | CLASS_ACC_ABSTRACT
+	c.SuperClass = 0
+	c.Attributes = nil
+	if !v.IsInterface() || v.SuperName() != "java/lang/Object" || v.SourceFile() != "HelloWorld.java" {
+		t.Error("view changed with the class file")
+	}
+
+	v, err = c.View()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v.SuperName() != "" || v.SourceFile() != "" {
+		t.Errorf("got super class %q and source file %q", v.SuperName(), v.SourceFile())
+	}
+}
+
+func TestClassViewErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *ClassFile)
+	}{
+		{"invalid this class", func(c *ClassFile) { c.ThisClass = 9999 }},
+		{"this class not a Class", func(c *ClassFile) { c.ThisClass = 41 }},
+		{"invalid super class", func(c *ClassFile) { c.SuperClass = 49 }},
+		{"invalid interface", func(c *ClassFile) { c.Interfaces = []ConstPoolIndex{10, 3} }},
+		{"invalid field name", func(c *ClassFile) { c.Fields[1].NameIndex = 9 }},
+		{"invalid method descriptor", func(c *ClassFile) { c.Methods[2].DescriptorIndex = 0 }},
+		{"invalid source file", func(c *ClassFile) { c.SourceFile().SourceFileIndex = 2 }},
+	}
+
+	for _, test := range tests {
+		_, c := readHelloWorld(t)
+		test.modify(c)
+
+		if v, err := c.View(); err == nil || v != nil {
+			t.Errorf("%s: got %v, %v", test.name, v, err)
+		}
+	}
+}